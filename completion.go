@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// completionCommands lists the subcommands offered by shell completion.
+var completionCommands = []string{"login", "logout", "info", "models", "refresh", "health", "instructions", "config", "doctor", "usage", "serve", "completion", "version", "help"}
+
+const bashCompletion = `# bash completion for opencompat
+_opencompat() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "{{commands}}" -- "$cur"))
+        return
+    fi
+
+    case "$prev" in
+        login|logout|refresh|health)
+            COMPREPLY=($(compgen -W "{{providers}}" -- "$cur"))
+            ;;
+        info|models|config)
+            COMPREPLY=($(compgen -W "--json" -- "$cur"))
+            ;;
+        serve)
+            COMPREPLY=($(compgen -W "--host --port" -- "$cur"))
+            ;;
+        usage)
+            COMPREPLY=($(compgen -W "--since --json" -- "$cur"))
+            ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            ;;
+    esac
+}
+complete -F _opencompat opencompat
+`
+
+const zshCompletion = `#compdef opencompat
+
+_opencompat() {
+    local -a commands providers
+    commands=({{commands}})
+    providers=({{providers}})
+
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+
+    case "${words[2]}" in
+        login|logout|refresh|health)
+            (( CURRENT == 3 )) && _describe 'provider' providers
+            ;;
+        info|models|config)
+            _arguments '--json[Output as JSON]'
+            ;;
+        serve)
+            _arguments '--host[Bind address]:address:' '--port[Listen port]:port:'
+            ;;
+        usage)
+            _arguments '--since[Only include usage since a date or age]:since:' '--json[Output as JSON]'
+            ;;
+        completion)
+            (( CURRENT == 3 )) && _values 'shell' bash zsh fish
+            ;;
+    esac
+}
+
+compdef _opencompat opencompat
+`
+
+const fishCompletion = `# fish completion for opencompat
+complete -c opencompat -f
+complete -c opencompat -n __fish_use_subcommand -a "{{commands}}"
+complete -c opencompat -n "__fish_seen_subcommand_from login logout refresh health" -a "{{providers}}"
+complete -c opencompat -n "__fish_seen_subcommand_from info models config" -l json -d "Output as JSON"
+complete -c opencompat -n "__fish_seen_subcommand_from serve" -l host -r -d "Bind address"
+complete -c opencompat -n "__fish_seen_subcommand_from serve" -l port -r -d "Listen port"
+complete -c opencompat -n "__fish_seen_subcommand_from usage" -l since -r -d "Only include usage since a date or age"
+complete -c opencompat -n "__fish_seen_subcommand_from usage" -l json -d "Output as JSON"
+complete -c opencompat -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
+
+func cmdCompletion() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Error: shell argument required")
+		fmt.Fprintln(os.Stderr, "Usage: opencompat completion <bash|zsh|fish>")
+		os.Exit(1)
+	}
+
+	var script string
+	switch strings.ToLower(os.Args[2]) {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		fmt.Fprintf(os.Stderr, "Unsupported shell: %s (supported: bash, zsh, fish)\n", os.Args[2])
+		os.Exit(1)
+	}
+
+	// Provider IDs are baked in at generation time from the registry
+	script = strings.ReplaceAll(script, "{{commands}}", strings.Join(completionCommands, " "))
+	script = strings.ReplaceAll(script, "{{providers}}", strings.Join(getProviderIDs(), " "))
+	fmt.Print(script)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/provider"
+	"github.com/edgard/opencompat/internal/provider/anthropic"
+	"github.com/edgard/opencompat/internal/provider/chatgpt"
+	"github.com/edgard/opencompat/internal/provider/copilot"
+)
+
+// envPrefix is shared by all OpenCompat environment variables.
+const envPrefix = "OPENCOMPAT_"
+
+// configEntry is a resolved configuration value and where it came from.
+type configEntry struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	EnvVar  string `json:"env_var,omitempty"`
+	Source  string `json:"source"` // env, file, default, or fixed (not configurable)
+}
+
+// configReport is the JSON output of the config command.
+type configReport struct {
+	Settings       []configEntry `json:"settings"`
+	UnknownEnvVars []string      `json:"unknown_env_vars"`
+}
+
+// newConfigEntry builds an entry, attributing it to the first set env var.
+// An entry without env vars is reported as fixed.
+func newConfigEntry(section, key string, value any, envVars ...string) configEntry {
+	entry := configEntry{
+		Section: section,
+		Key:     key,
+		Value:   fmt.Sprint(value),
+		Source:  "fixed",
+	}
+	if len(envVars) == 0 {
+		return entry
+	}
+
+	entry.EnvVar = envVars[0]
+	entry.Source = "default"
+	for _, name := range envVars {
+		if config.Getenv(name) != "" {
+			entry.EnvVar = name
+			entry.Source = "env"
+			if config.FromFile(name) {
+				entry.Source = "file"
+			}
+			break
+		}
+	}
+	return entry
+}
+
+func cmdConfig() {
+	report := collectConfig()
+
+	if hasFlag("--json") {
+		printJSON(report)
+		return
+	}
+
+	section := ""
+	for _, e := range report.Settings {
+		if e.Section != section {
+			if section != "" {
+				fmt.Println()
+			}
+			section = e.Section
+			fmt.Printf("%s:\n", section)
+		}
+
+		value := e.Value
+		if value == "" {
+			value = `""`
+		}
+		source := e.Source
+		if e.Source == "env" {
+			source = "env: " + e.EnvVar
+		}
+		fmt.Printf("  %-22s %-36s (%s)\n", e.Key, value, source)
+	}
+
+	if len(report.UnknownEnvVars) > 0 {
+		fmt.Println()
+		fmt.Println("Unknown environment variables (ignored):")
+		for _, name := range report.UnknownEnvVars {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+}
+
+// collectConfig resolves global and provider configuration.
+func collectConfig() configReport {
+	cfg := config.Load()
+	gpt := chatgpt.LoadConfig()
+	cop := copilot.LoadConfig()
+	ant := anthropic.LoadConfig()
+
+	githubToken := ""
+	if gpt.GitHubToken != "" {
+		githubToken = maskSecret(gpt.GitHubToken)
+	}
+
+	settings := []configEntry{
+		newConfigEntry("global", "host", cfg.Host, "OPENCOMPAT_HOST"),
+		newConfigEntry("global", "port", cfg.Port, "OPENCOMPAT_PORT"),
+		newConfigEntry("global", "listen", cfg.Listen, "OPENCOMPAT_LISTEN"),
+		newConfigEntry("global", "log_level", cfg.LogLevel, "OPENCOMPAT_LOG_LEVEL"),
+		newConfigEntry("global", "log_format", cfg.LogFormat, "OPENCOMPAT_LOG_FORMAT"),
+		newConfigEntry("global", "access_log_level", cfg.AccessLogLevel, "OPENCOMPAT_ACCESS_LOG_LEVEL"),
+		newConfigEntry("global", "metrics", cfg.Metrics, "OPENCOMPAT_METRICS"),
+		newConfigEntry("global", "cors_origins", strings.Join(cfg.CORSOrigins, ","), "OPENCOMPAT_CORS_ORIGINS"),
+		newConfigEntry("global", "upstream_timeout", cfg.UpstreamTimeout, "OPENCOMPAT_UPSTREAM_TIMEOUT"),
+		newConfigEntry("global", "first_chunk_timeout", cfg.FirstChunkTimeout, "OPENCOMPAT_FIRST_CHUNK_TIMEOUT"),
+		newConfigEntry("global", "upstream_retries", cfg.UpstreamRetries, "OPENCOMPAT_UPSTREAM_RETRIES"),
+		newConfigEntry("global", "max_concurrent", cfg.MaxConcurrent, "OPENCOMPAT_MAX_CONCURRENT"),
+		newConfigEntry("global", "queue_timeout", cfg.QueueTimeout, "OPENCOMPAT_QUEUE_TIMEOUT"),
+		newConfigEntry("global", "response_cache", cfg.ResponseCache, "OPENCOMPAT_RESPONSE_CACHE"),
+		newConfigEntry("global", "response_cache_size", cfg.ResponseCacheSize, "OPENCOMPAT_RESPONSE_CACHE_SIZE"),
+		newConfigEntry("global", "response_cache_ttl", cfg.ResponseCacheTTL, "OPENCOMPAT_RESPONSE_CACHE_TTL"),
+		newConfigEntry("global", "token_expiry_margin", cfg.TokenExpiryMargin, "OPENCOMPAT_TOKEN_EXPIRY_MARGIN"),
+		newConfigEntry("global", "reauth_prompt", cfg.ReauthPrompt, "OPENCOMPAT_REAUTH_PROMPT"),
+		newConfigEntry("global", "default_provider", cfg.DefaultProvider, "OPENCOMPAT_DEFAULT_PROVIDER"),
+		newConfigEntry("global", "disabled_providers", strings.Join(cfg.DisabledProviders, ","), "OPENCOMPAT_DISABLED_PROVIDERS"),
+		newConfigEntry("global", "routes", cfg.RoutesFile, "OPENCOMPAT_ROUTES"),
+		newConfigEntry("global", "api_keys", cfg.APIKeysFile, "OPENCOMPAT_API_KEYS"),
+		newConfigEntry("global", "enforce_context", cfg.EnforceContext, "OPENCOMPAT_ENFORCE_CONTEXT"),
+		newConfigEntry("global", "strict_effort", cfg.StrictEffort, "OPENCOMPAT_STRICT_EFFORT"),
+		newConfigEntry("global", "inline_images", cfg.InlineImages, "OPENCOMPAT_INLINE_IMAGES"),
+		newConfigEntry("global", "max_image_bytes", cfg.MaxImageBytes, "OPENCOMPAT_MAX_IMAGE_BYTES"),
+		newConfigEntry("global", "usage_log", cfg.UsageLog, "OPENCOMPAT_USAGE_LOG"),
+		newConfigEntry("global", "enable_mock", cfg.EnableMock, "OPENCOMPAT_ENABLE_MOCK"),
+		newConfigEntry("global", "selftest", cfg.SelfTest, "OPENCOMPAT_SELFTEST"),
+		newConfigEntry("global", "allow_no_providers", cfg.AllowNoProviders, "OPENCOMPAT_ALLOW_NO_PROVIDERS"),
+		newConfigEntry("global", "debug_bodies", cfg.DebugBodies, "OPENCOMPAT_DEBUG_BODIES"),
+		newConfigEntry("global", "debug", cfg.Debug, "OPENCOMPAT_DEBUG"),
+		newConfigEntry("global", "tls_cert", cfg.TLSCert, "OPENCOMPAT_TLS_CERT"),
+		newConfigEntry("global", "tls_key", cfg.TLSKey, "OPENCOMPAT_TLS_KEY"),
+		newConfigEntry("global", "tls_self_signed", cfg.TLSSelfSigned, "OPENCOMPAT_TLS_SELF_SIGNED"),
+		newConfigEntry("global", "config_file", config.LoadedFile(), "OPENCOMPAT_CONFIG"),
+		newConfigEntry("global", "data_dir", config.DataDir(), "XDG_DATA_HOME"),
+
+		newConfigEntry(chatgpt.ProviderID, "reasoning_effort", gpt.ReasoningEffort),
+		newConfigEntry(chatgpt.ProviderID, "reasoning_summary", gpt.ReasoningSummary),
+		newConfigEntry(chatgpt.ProviderID, "reasoning_compat", gpt.ReasoningCompat),
+		newConfigEntry(chatgpt.ProviderID, "text_verbosity", gpt.TextVerbosity),
+		newConfigEntry(chatgpt.ProviderID, "instructions_refresh", gpt.InstructionsRefresh, chatgpt.EnvInstructionsRefresh),
+		newConfigEntry(chatgpt.ProviderID, "min_reasoning_effort", gpt.MinReasoningEffort, chatgpt.EnvMinReasoningEffort),
+		newConfigEntry(chatgpt.ProviderID, "instructions_dir", gpt.InstructionsDir, chatgpt.EnvInstructionsDir),
+		newConfigEntry(chatgpt.ProviderID, "offline", gpt.Offline, chatgpt.EnvOffline),
+		newConfigEntry(chatgpt.ProviderID, "github_token", githubToken, chatgpt.EnvGitHubToken, "GITHUB_TOKEN"),
+		newConfigEntry(chatgpt.ProviderID, "think_open", gpt.ThinkOpen, chatgpt.EnvThinkOpen),
+		newConfigEntry(chatgpt.ProviderID, "think_close", gpt.ThinkClose, chatgpt.EnvThinkClose),
+		newConfigEntry(chatgpt.ProviderID, "enforce_stop", gpt.EnforceStop, chatgpt.EnvEnforceStop),
+		newConfigEntry(chatgpt.ProviderID, "expose_web_search", gpt.ExposeWebSearch, chatgpt.EnvExposeWebSearch),
+		newConfigEntry(chatgpt.ProviderID, "interim_usage", gpt.InterimUsage, chatgpt.EnvInterimUsage),
+		newConfigEntry(chatgpt.ProviderID, "system_prompt", gpt.SystemPrompt, chatgpt.EnvSystemPrompt),
+		newConfigEntry(chatgpt.ProviderID, "stateful", gpt.Stateful, chatgpt.EnvStateful),
+		newConfigEntry(chatgpt.ProviderID, "require_all_instructions", gpt.RequireAllInstructions, chatgpt.EnvRequireAllInstructions),
+		newConfigEntry(chatgpt.ProviderID, "timeout", int(gpt.UpstreamTimeout.Seconds()), chatgpt.EnvTimeout),
+		newConfigEntry(chatgpt.ProviderID, "oauth_client_id", chatgpt.OAuthClientID),
+		newConfigEntry(chatgpt.ProviderID, "cache_dir", chatgpt.CacheDir(), "XDG_CACHE_HOME"),
+
+		newConfigEntry(copilot.ProviderID, "models_refresh", cop.ModelsRefresh, copilot.EnvModelsRefresh),
+		newConfigEntry(copilot.ProviderID, "base_url", cop.BaseURL, copilot.EnvBaseURL),
+		newConfigEntry(copilot.ProviderID, "token_url", cop.TokenURL, copilot.EnvTokenURL),
+		newConfigEntry(copilot.ProviderID, "chat_url", cop.ChatURL, copilot.EnvChatURL),
+		newConfigEntry(copilot.ProviderID, "timeout", int(cop.UpstreamTimeout.Seconds()), copilot.EnvTimeout),
+
+		newConfigEntry(anthropic.ProviderID, "max_tokens", ant.MaxTokens, anthropic.EnvMaxTokens),
+		newConfigEntry(anthropic.ProviderID, "timeout", int(ant.UpstreamTimeout.Seconds()), anthropic.EnvTimeout),
+	}
+
+	return configReport{
+		Settings:       settings,
+		UnknownEnvVars: unknownEnvVars(settings),
+	}
+}
+
+// unknownEnvVars returns OPENCOMPAT_* variables in the environment that no
+// setting reads, which usually indicates a typo.
+func unknownEnvVars(settings []configEntry) []string {
+	known := make(map[string]bool)
+	for _, e := range settings {
+		known[e.EnvVar] = true
+	}
+
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+	for _, meta := range registry.ListMetas() {
+		for _, env := range meta.EnvVars {
+			known[env.Name] = true
+		}
+		if meta.APIKeyEnv != "" {
+			known[meta.APIKeyEnv] = true
+		}
+	}
+
+	unknown := []string{}
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, envPrefix) && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/provider"
+	"github.com/edgard/opencompat/internal/provider/chatgpt"
+)
+
+// checkStatus is the outcome of a doctor check.
+type checkStatus string
+
+const (
+	checkPass checkStatus = "PASS"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+)
+
+// doctor collects check results and tracks critical failures.
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) report(status checkStatus, name, detail string) {
+	if status == checkFail {
+		d.failed = true
+	}
+	fmt.Printf("  [%s] %-28s %s\n", status, name, detail)
+}
+
+func cmdDoctor() {
+	cfg := config.Load()
+	store := auth.NewStore()
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+
+	d := &doctor{}
+
+	fmt.Println("Directories:")
+	d.checkWritableDir("data directory", config.DataDir())
+	d.checkWritableDir("cache directory", chatgpt.CacheDir())
+
+	fmt.Println("\nProviders:")
+	d.checkProviders(store, registry)
+
+	fmt.Println("\nNetwork:")
+	d.checkGitHub()
+	d.checkListen(cfg)
+
+	fmt.Println("\nSetup:")
+	ackPath := filepath.Join(config.DataDir(), acknowledgmentFile)
+	if _, err := os.Stat(ackPath); err == nil {
+		d.report(checkPass, "acknowledgment", "terms accepted")
+	} else {
+		d.report(checkWarn, "acknowledgment", "not accepted yet (serve will prompt)")
+	}
+
+	fmt.Println()
+	if d.failed {
+		fmt.Println("Some critical checks failed.")
+		os.Exit(1)
+	}
+	fmt.Println("No critical problems found.")
+}
+
+// checkWritableDir verifies a directory exists (creating it if needed) and is writable.
+func (d *doctor) checkWritableDir(name, dir string) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		d.report(checkFail, name, fmt.Sprintf("%s: %v", dir, err))
+		return
+	}
+
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		d.report(checkFail, name, fmt.Sprintf("%s: not writable: %v", dir, err))
+		return
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	d.report(checkPass, name, dir)
+}
+
+// checkProviders verifies stored credentials parse and reports expiry.
+func (d *doctor) checkProviders(store *auth.Store, registry *provider.Registry) {
+	loggedIn := 0
+	for _, meta := range registry.ListMetas() {
+		if !meta.LoggedIn(store) {
+			d.report(checkWarn, meta.ID, fmt.Sprintf("not logged in (opencompat login %s)", meta.ID))
+			continue
+		}
+
+		switch meta.AuthMethod {
+		case auth.AuthMethodOAuth, auth.AuthMethodDeviceFlow:
+			creds, err := store.GetOAuthCredentials(meta.ID)
+			if err != nil {
+				d.report(checkFail, meta.ID, fmt.Sprintf("invalid credentials: %v", err))
+				continue
+			}
+			if meta.AuthMethod == auth.AuthMethodOAuth && creds.IsExpired() {
+				d.report(checkWarn, meta.ID, "access token expired (will refresh on next request)")
+			} else {
+				d.report(checkPass, meta.ID, "credentials valid")
+			}
+		case auth.AuthMethodAPIKey:
+			if _, err := store.GetAPIKeyCredentials(meta.ID); err != nil {
+				d.report(checkFail, meta.ID, fmt.Sprintf("invalid credentials: %v", err))
+				continue
+			}
+			d.report(checkPass, meta.ID, "credentials valid")
+		case auth.AuthMethodNone:
+			d.report(checkPass, meta.ID, "no credentials required")
+		}
+		loggedIn++
+	}
+
+	if loggedIn == 0 {
+		d.report(checkFail, "providers", "no providers logged in")
+	}
+}
+
+// checkGitHub verifies instructions can be fetched from GitHub.
+// Not critical: the disk cache is used when GitHub is unreachable.
+func (d *doctor) checkGitHub() {
+	gptCfg := chatgpt.LoadConfig()
+	if gptCfg.Offline {
+		d.report(checkPass, "github", "skipped (offline mode)")
+		return
+	}
+
+	cache := chatgpt.NewInstructionsCache()
+	cache.SetGitHubToken(gptCfg.GitHubToken)
+	defer cache.Close()
+	tag, err := cache.LatestReleaseTag(context.Background())
+	if err != nil {
+		d.report(checkWarn, "github", fmt.Sprintf("unreachable, disk cache will be used: %v", err))
+		return
+	}
+	d.report(checkPass, "github", "reachable (latest release "+tag+")")
+}
+
+// checkListen verifies the configured listen address can be bound.
+func (d *doctor) checkListen(cfg *config.Config) {
+	network, address := cfg.ListenAddress()
+
+	if network == "unix" {
+		dir := filepath.Dir(address)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			d.report(checkFail, "listen address", fmt.Sprintf("socket directory %s does not exist", dir))
+			return
+		}
+		d.report(checkPass, "listen address", "unix:"+address)
+		return
+	}
+
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		d.report(checkFail, "listen address", fmt.Sprintf("cannot bind %s: %v", address, err))
+		return
+	}
+	_ = ln.Close()
+	d.report(checkPass, "listen address", address)
+}
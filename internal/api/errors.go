@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 // ErrorResponse represents an OpenAI API error response.
@@ -22,10 +23,12 @@ type ErrorDetail struct {
 const (
 	ErrorTypeInvalidRequest     = "invalid_request_error"
 	ErrorTypeAuthentication     = "authentication_error"
+	ErrorTypePermission         = "permission_error"
 	ErrorTypeNotFound           = "not_found_error"
 	ErrorTypeRateLimit          = "rate_limit_error"
 	ErrorTypeServer             = "server_error"
 	ErrorTypeServiceUnavailable = "service_unavailable"
+	ErrorTypeInsufficientQuota  = "insufficient_quota"
 )
 
 // WriteError writes an OpenAI-compatible error response.
@@ -71,16 +74,35 @@ func WriteServerError(w http.ResponseWriter, message string) {
 }
 
 // WriteModelNotFound writes a model not found error.
-func WriteModelNotFound(w http.ResponseWriter, model string) {
+// Suggestions, if any, are appended as a "did you mean" hint.
+func WriteModelNotFound(w http.ResponseWriter, model string, suggestions ...string) {
 	code := "model_not_found"
 	message := "The model `" + model + "` does not exist or you do not have access to it."
+	if len(suggestions) > 0 {
+		message += " Did you mean " + quoteList(suggestions) + "?"
+	}
 	WriteError(w, http.StatusNotFound, ErrorTypeNotFound, message, &code, nil)
 }
 
+// quoteList formats names as "`a`, `b` or `c`".
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
 // UpstreamError represents an error from an upstream provider with HTTP status.
+// Type and Code carry the upstream's structured error fields when available.
 type UpstreamError struct {
 	StatusCode int
 	Message    string
+	Type       string
+	Code       string
 }
 
 // Error implements the error interface.
@@ -104,21 +126,36 @@ func WriteUpstreamError(w http.ResponseWriter, err *UpstreamError) {
 		return
 	}
 
-	switch err.StatusCode {
+	status, errType := upstreamStatus(err.StatusCode)
+	if err.Type != "" {
+		errType = err.Type
+	}
+	var code *string
+	if err.Code != "" {
+		code = &err.Code
+	}
+	WriteError(w, status, errType, err.Message, code, nil)
+}
+
+// upstreamStatus maps an upstream HTTP status code to the status and error type returned to clients.
+func upstreamStatus(statusCode int) (int, string) {
+	switch statusCode {
 	case http.StatusBadRequest:
-		WriteError(w, http.StatusBadRequest, ErrorTypeInvalidRequest, err.Message, nil, nil)
+		return http.StatusBadRequest, ErrorTypeInvalidRequest
 	case http.StatusUnauthorized:
-		WriteError(w, http.StatusUnauthorized, ErrorTypeAuthentication, err.Message, nil, nil)
+		return http.StatusUnauthorized, ErrorTypeAuthentication
+	case http.StatusPaymentRequired:
+		return http.StatusPaymentRequired, ErrorTypeInsufficientQuota
 	case http.StatusForbidden:
-		WriteError(w, http.StatusForbidden, ErrorTypeAuthentication, err.Message, nil, nil)
+		return http.StatusForbidden, ErrorTypeAuthentication
 	case http.StatusNotFound:
-		WriteError(w, http.StatusNotFound, ErrorTypeNotFound, err.Message, nil, nil)
+		return http.StatusNotFound, ErrorTypeNotFound
 	case http.StatusTooManyRequests:
-		WriteError(w, http.StatusTooManyRequests, ErrorTypeRateLimit, err.Message, nil, nil)
+		return http.StatusTooManyRequests, ErrorTypeRateLimit
 	case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
-		WriteError(w, err.StatusCode, ErrorTypeServiceUnavailable, err.Message, nil, nil)
+		return statusCode, ErrorTypeServiceUnavailable
 	default:
 		// For other errors, use 502 Bad Gateway to indicate upstream failure
-		WriteError(w, http.StatusBadGateway, ErrorTypeServer, err.Message, nil, nil)
+		return http.StatusBadGateway, ErrorTypeServer
 	}
 }
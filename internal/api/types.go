@@ -26,6 +26,11 @@ type ChatCompletionRequest struct {
 	Seed                *int            `json:"seed,omitempty"`
 	// OpenAI-specific reasoning parameters (passed through)
 	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+
+	// OpenCompat extensions (override the X-Reasoning-*/X-Text-Verbosity headers)
+	ReasoningSummary string `json:"reasoning_summary,omitempty"` // auto, concise, detailed
+	ReasoningCompat  string `json:"reasoning_compat,omitempty"`  // none, think-tags, o3, legacy, reasoning-content
+	TextVerbosity    string `json:"text_verbosity,omitempty"`    // low, medium, high
 }
 
 // StreamOptions specifies options for streaming responses.
@@ -35,14 +40,16 @@ type StreamOptions struct {
 
 // Message represents a chat message.
 type Message struct {
-	Role             string           `json:"role"`
-	Content          json.RawMessage  `json:"content"` // string or []ContentPart
-	Name             string           `json:"name,omitempty"`
-	Refusal          string           `json:"refusal,omitempty"` // Model refusal message
-	ToolCalls        []ToolCall       `json:"tool_calls,omitempty"`
-	ToolCallID       string           `json:"tool_call_id,omitempty"`
-	Reasoning        *ReasoningOutput `json:"reasoning,omitempty"`         // For o3 mode
-	ReasoningSummary string           `json:"reasoning_summary,omitempty"` // For legacy mode
+	Role             string            `json:"role"`
+	Content          json.RawMessage   `json:"content"` // string or []ContentPart
+	Name             string            `json:"name,omitempty"`
+	Refusal          string            `json:"refusal,omitempty"` // Model refusal message
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
+	ToolCallID       string            `json:"tool_call_id,omitempty"`
+	Reasoning        *ReasoningOutput  `json:"reasoning,omitempty"`          // For o3 mode
+	ReasoningSummary string            `json:"reasoning_summary,omitempty"`  // For legacy mode
+	ReasoningContent string            `json:"reasoning_content,omitempty"`  // For reasoning-content mode
+	WebSearchResults []WebSearchResult `json:"web_search_results,omitempty"` // Built-in web search sources (opt-in)
 }
 
 // ContentPart represents a part of a multimodal message.
@@ -130,12 +137,27 @@ type LogprobContent struct {
 
 // Delta represents incremental content in streaming responses.
 type Delta struct {
-	Role             string           `json:"role,omitempty"`
-	Content          string           `json:"content,omitempty"`
-	Refusal          string           `json:"refusal,omitempty"` // Model refusal message
-	ToolCalls        []ToolCall       `json:"tool_calls,omitempty"`
-	Reasoning        *ReasoningOutput `json:"reasoning,omitempty"`         // For o3 mode
-	ReasoningSummary string           `json:"reasoning_summary,omitempty"` // For legacy mode
+	Role             string            `json:"role,omitempty"`
+	Content          string            `json:"content,omitempty"`
+	Refusal          string            `json:"refusal,omitempty"` // Model refusal message
+	ToolCalls        []ToolCall        `json:"tool_calls,omitempty"`
+	Reasoning        *ReasoningOutput  `json:"reasoning,omitempty"`          // For o3 mode
+	ReasoningSummary string            `json:"reasoning_summary,omitempty"`  // For legacy mode
+	ReasoningContent string            `json:"reasoning_content,omitempty"`  // For reasoning-content mode
+	WebSearchResults []WebSearchResult `json:"web_search_results,omitempty"` // Built-in web search sources (opt-in)
+}
+
+// WebSearchResult lists the sources returned by a built-in web search call.
+type WebSearchResult struct {
+	ToolCallID string            `json:"tool_call_id"` // ID of the matching web_search tool call
+	Query      string            `json:"query,omitempty"`
+	Sources    []WebSearchSource `json:"sources"`
+}
+
+// WebSearchSource is a single web search result.
+type WebSearchSource struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
 }
 
 // ReasoningOutput represents reasoning content in o3 format.
@@ -156,6 +178,7 @@ type Usage struct {
 	TotalTokens             int                     `json:"total_tokens"`
 	PromptTokensDetails     *PromptTokenDetails     `json:"prompt_tokens_details,omitempty"`
 	CompletionTokensDetails *CompletionTokenDetails `json:"completion_tokens_details,omitempty"`
+	Estimated               bool                    `json:"estimated,omitempty"` // OpenCompat extension: counts are approximate (upstream reported none)
 }
 
 // PromptTokenDetails contains detailed breakdown of prompt tokens.
@@ -191,6 +214,10 @@ type Model struct {
 	Object  string `json:"object"`
 	Created int64  `json:"created"`
 	OwnedBy string `json:"owned_by"`
+
+	// Optional metadata reported by providers with dynamic model lists (Copilot)
+	Name   string `json:"name,omitempty"`   // display name
+	Family string `json:"family,omitempty"` // model family, for grouping related versions
 }
 
 // GetContentString extracts string content from a message.
@@ -9,6 +9,16 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/edgard/opencompat/internal/logging"
+	"github.com/edgard/opencompat/internal/qrcode"
+)
+
+// Device flow polling bounds (RFC 8628 section 3.5)
+const (
+	deviceFlowMinInterval = 5 * time.Second  // floor for the server-provided interval
+	deviceFlowSlowDown    = 5 * time.Second  // added to the interval on each slow_down
+	deviceFlowMaxInterval = 30 * time.Second // ceiling, so slow_down can't eat the whole window
 )
 
 // DeviceCodeResponse represents the response from the device code endpoint.
@@ -18,6 +28,10 @@ type DeviceCodeResponse struct {
 	VerificationURI string `json:"verification_uri"`
 	ExpiresIn       int    `json:"expires_in"`
 	Interval        int    `json:"interval"`
+
+	// VerificationURIComplete embeds the user code, so no code entry is
+	// needed (RFC 8628 section 3.3.1). Optional; not every server sends it.
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
 }
 
 // DeviceFlowTokenResponse represents the token response during polling.
@@ -30,37 +44,58 @@ type DeviceFlowTokenResponse struct {
 
 // PerformDeviceFlowLogin performs the OAuth device authorization flow.
 // This flow is used by providers like GitHub that support device code authentication.
-func PerformDeviceFlowLogin(store *Store, providerID string, cfg *DeviceFlowConfig) error {
+// With showQR, the verification URI is also printed as a terminal QR code.
+func PerformDeviceFlowLogin(store *Store, providerID string, cfg *DeviceFlowConfig, showQR bool) error {
 	// Step 1: Request device code
 	deviceCode, err := requestDeviceCode(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to request device code: %w", err)
 	}
 
-	// Step 2: Display instructions to user
+	// Step 2: Display instructions to user, preferring the URI with the code embedded
+	openURI := deviceCode.VerificationURI
 	fmt.Println()
-	fmt.Println("To authenticate, please:")
-	fmt.Printf("  1. Open: %s\n", deviceCode.VerificationURI)
-	fmt.Printf("  2. Enter code: %s\n", deviceCode.UserCode)
+	if deviceCode.VerificationURIComplete != "" {
+		openURI = deviceCode.VerificationURIComplete
+		fmt.Println("To authenticate, open:")
+		fmt.Printf("  %s\n", openURI)
+		fmt.Printf("and confirm the code %s\n", deviceCode.UserCode)
+		fmt.Printf("(or open %s and enter it manually)\n", deviceCode.VerificationURI)
+	} else {
+		fmt.Println("To authenticate, please:")
+		fmt.Printf("  1. Open: %s\n", deviceCode.VerificationURI)
+		fmt.Printf("  2. Enter code: %s\n", deviceCode.UserCode)
+	}
 	fmt.Println()
 
+	if showQR {
+		if code, err := qrcode.Encode(openURI); err == nil {
+			fmt.Println("Or scan with your phone:")
+			fmt.Print(code.String())
+			fmt.Println()
+		} else {
+			fmt.Printf("Could not render QR code: %v\n", err)
+		}
+	}
+
 	// Try to open browser
-	if err := openBrowser(deviceCode.VerificationURI); err != nil {
+	if err := openBrowser(openURI); err != nil {
 		fmt.Println("Could not open browser automatically. Please open the URL manually.")
 	}
 
 	fmt.Println("Waiting for authorization...")
 
 	// Step 3: Poll for token
-	interval := deviceCode.Interval
-	if interval < 5 {
-		interval = 5 // Minimum 5 seconds as per RFC 8628
-	}
-
-	deadline := time.Now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
-
-	for time.Now().Before(deadline) {
-		time.Sleep(time.Duration(interval) * time.Second)
+	interval := min(max(time.Duration(deviceCode.Interval)*time.Second, deviceFlowMinInterval), deviceFlowMaxInterval)
+	expiresIn := time.Duration(deviceCode.ExpiresIn) * time.Second
+	deadline := time.Now().Add(expiresIn)
+
+	for {
+		// Stop as soon as the next poll would land past the code's expiry
+		if time.Until(deadline) < interval {
+			return fmt.Errorf("authorization request timed out after %s - please try again", expiresIn)
+		}
+		time.Sleep(interval)
 
 		token, err := pollForToken(cfg, deviceCode.DeviceCode)
 		if err != nil {
@@ -72,8 +107,8 @@ func PerformDeviceFlowLogin(store *Store, providerID string, cfg *DeviceFlowConf
 					// Continue polling
 					continue
 				case "slow_down":
-					// Increase interval
-					interval += 5
+					// Back off, up to the ceiling
+					interval = min(interval+deviceFlowSlowDown, deviceFlowMaxInterval)
 					continue
 				case "expired_token":
 					return errors.New("authorization request expired - please try again")
@@ -104,8 +139,6 @@ func PerformDeviceFlowLogin(store *Store, providerID string, cfg *DeviceFlowConf
 		fmt.Println("Login successful!")
 		return nil
 	}
-
-	return errors.New("authorization request timed out - please try again")
 }
 
 // requestDeviceCode requests a device code from the authorization server.
@@ -138,7 +171,7 @@ func requestDeviceCode(cfg *DeviceFlowConfig) (*DeviceCodeResponse, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("device code request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("device code request failed with status %d: %s", resp.StatusCode, logging.Redact(string(body)))
 	}
 
 	var deviceCode DeviceCodeResponse
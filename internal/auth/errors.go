@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReauthRequiredError indicates that stored credentials were rejected upstream
+// (expired or revoked refresh token) and the user must log in again.
+type ReauthRequiredError struct {
+	ProviderID string
+	Reason     string
+}
+
+// Error implements the error interface.
+func (e *ReauthRequiredError) Error() string {
+	msg := fmt.Sprintf("%s session expired or was revoked - run 'opencompat login %s'", e.ProviderID, e.ProviderID)
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	return msg
+}
+
+// IsReauthRequired reports whether err (or any error it wraps) requires a new login.
+// Returns the typed error so callers can recover the provider ID.
+func IsReauthRequired(err error) (*ReauthRequiredError, bool) {
+	var reauthErr *ReauthRequiredError
+	if errors.As(err, &reauthErr) {
+		return reauthErr, true
+	}
+	return nil, false
+}
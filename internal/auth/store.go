@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/metrics"
 )
 
 // Store manages credential persistence for all providers.
@@ -201,6 +202,34 @@ func (s *Store) GetAPIKeyCredentials(providerID string) (*APIKeyCredentials, err
 	return copyAPIKeyCredentials(credsCopy), nil
 }
 
+// writeFileAtomic writes data to a temp file in the same directory and renames
+// it over path, so readers see either the old or the new file, never a partial one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }() // no-op after a successful rename
+
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
 // SaveOAuthCredentials stores OAuth credentials for a provider.
 func (s *Store) SaveOAuthCredentials(providerID string, creds *OAuthCredentials) error {
 	if err := config.EnsureDataDir(); err != nil {
@@ -217,7 +246,7 @@ func (s *Store) SaveOAuthCredentials(providerID string, creds *OAuthCredentials)
 	}
 
 	path := s.credentialsPath(providerID)
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := writeFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write credentials: %w", err)
 	}
 
@@ -245,7 +274,7 @@ func (s *Store) SaveAPIKeyCredentials(providerID string, creds *APIKeyCredential
 	}
 
 	path := s.credentialsPath(providerID)
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := writeFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write credentials: %w", err)
 	}
 
@@ -358,8 +387,15 @@ func (s *Store) RefreshOAuth(providerID string, oauthCfg *OAuthConfig) error {
 	if resp.StatusCode != http.StatusOK {
 		var oauthErr OAuthError
 		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			// invalid_grant means the refresh token itself was expired or revoked
+			if oauthErr.Error == "invalid_grant" {
+				return &ReauthRequiredError{ProviderID: providerID, Reason: oauthErr.ErrorDescription}
+			}
 			return fmt.Errorf("token refresh failed: %s - %s", oauthErr.Error, oauthErr.ErrorDescription)
 		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return &ReauthRequiredError{ProviderID: providerID}
+		}
 		return fmt.Errorf("token refresh failed with status %d", resp.StatusCode)
 	}
 
@@ -373,6 +409,8 @@ func (s *Store) RefreshOAuth(providerID string, oauthCfg *OAuthConfig) error {
 		tokens.RefreshToken = creds.RefreshToken
 	}
 
+	metrics.IncTokenRefresh(providerID)
+
 	return s.SetOAuthFromTokenData(providerID, &tokens, oauthCfg)
 }
 
@@ -411,3 +449,21 @@ func (s *Store) GetOAuthCredentialsRefreshed(providerID string, oauthCfg *OAuthC
 
 	return creds, nil
 }
+
+// GetBundle returns a fresh access token and account ID for a provider,
+// refreshing expired credentials first. If the stored credentials carry no
+// account ID, it is extracted from the access token with oauthCfg.ExtractAccountID.
+func (s *Store) GetBundle(providerID string, oauthCfg *OAuthConfig) (*TokenBundle, error) {
+	creds, err := s.GetOAuthCredentialsRefreshed(providerID, oauthCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &TokenBundle{AccessToken: creds.AccessToken, AccountID: creds.AccountID}
+	if bundle.AccountID == "" && oauthCfg != nil && oauthCfg.ExtractAccountID != nil {
+		if accountID, err := oauthCfg.ExtractAccountID(creds.AccessToken); err == nil {
+			bundle.AccountID = accountID
+		}
+	}
+	return bundle, nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetBundleRefreshesExpiredToken(t *testing.T) {
+	var refreshes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if got := r.PostForm.Get("grant_type"); got != "refresh_token" {
+			t.Errorf("grant_type = %q, want refresh_token", got)
+		}
+		if got := r.PostForm.Get("refresh_token"); got != "old-refresh" {
+			t.Errorf("refresh_token = %q, want old-refresh", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"new-access","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	s := &Store{dataDir: t.TempDir(), cache: make(map[string]any)}
+	if err := s.SaveOAuthCredentials("test", &OAuthCredentials{
+		Type:         "oauth",
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	oauthCfg := &OAuthConfig{
+		TokenURL: srv.URL,
+		ClientID: "client",
+		ExtractAccountID: func(token string) (string, error) {
+			return "acct-for-" + token, nil
+		},
+	}
+
+	bundle, err := s.GetBundle("test", oauthCfg)
+	if err != nil {
+		t.Fatalf("GetBundle() error = %v", err)
+	}
+	if bundle.AccessToken != "new-access" {
+		t.Errorf("AccessToken = %q, want new-access", bundle.AccessToken)
+	}
+	if bundle.AccountID != "acct-for-new-access" {
+		t.Errorf("AccountID = %q, want acct-for-new-access", bundle.AccountID)
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1", got)
+	}
+
+	// The refreshed credentials are persisted with the old refresh token kept
+	creds, err := s.GetOAuthCredentials("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.RefreshToken != "old-refresh" || creds.IsExpired() {
+		t.Errorf("stored credentials = %+v, want unexpired with refresh token kept", creds)
+	}
+
+	// A fresh token is returned without another refresh
+	if _, err := s.GetBundle("test", oauthCfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times after a valid token, want 1", got)
+	}
+}
@@ -1,7 +1,12 @@
 // Package auth provides OAuth authentication for the Codex API.
 package auth
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/edgard/opencompat/internal/config"
+)
 
 // AuthMethod defines how a provider authenticates.
 type AuthMethod int
@@ -13,6 +18,8 @@ const (
 	AuthMethodAPIKey
 	// AuthMethodDeviceFlow uses OAuth device authorization flow.
 	AuthMethodDeviceFlow
+	// AuthMethodNone needs no credentials (mock provider).
+	AuthMethodNone
 )
 
 // String returns the string representation of the auth method.
@@ -24,6 +31,8 @@ func (m AuthMethod) String() string {
 		return "api_key"
 	case AuthMethodDeviceFlow:
 		return "device_flow"
+	case AuthMethodNone:
+		return "none"
 	default:
 		return "unknown"
 	}
@@ -50,10 +59,22 @@ type OAuthCredentials struct {
 	Email        string    `json:"email,omitempty"`
 }
 
+// TokenBundle is what an upstream request needs from stored OAuth credentials.
+type TokenBundle struct {
+	AccessToken string
+	AccountID   string // empty if the provider has no account concept
+}
+
+// ExpiryMargin is how long before their expiry tokens are treated as expired
+// (OPENCOMPAT_TOKEN_EXPIRY_MARGIN). Read once, after the config file is applied.
+var ExpiryMargin = sync.OnceValue(func() time.Duration {
+	return config.Load().TokenExpiryMarginDuration()
+})
+
 // IsExpired returns true if the access token has expired.
 func (c *OAuthCredentials) IsExpired() bool {
-	// Consider expired 60 seconds before actual expiry for safety margin
-	return time.Now().Add(60 * time.Second).After(c.ExpiresAt)
+	// Consider expired a margin before actual expiry for clock skew and request latency
+	return time.Now().Add(ExpiryMargin()).After(c.ExpiresAt)
 }
 
 // IsValid returns true if the credentials have required tokens.
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadAPIKeys reads the server API keys from a JSON file mapping each key to
+// the model prefixes it may use, e.g. {"sk-tools": ["copilot/"], "sk-admin": []}.
+// An empty or null list grants access to every model. Returns nil if path is empty.
+func LoadAPIKeys(path string) (map[string][]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+
+	var keys map[string][]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys file %s: %w", path, err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("API keys file %s defines no keys", path)
+	}
+
+	for key, prefixes := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("empty API key in %s", path)
+		}
+		for _, prefix := range prefixes {
+			if prefix == "" {
+				return nil, fmt.Errorf("empty model prefix for an API key in %s", path)
+			}
+		}
+	}
+	return keys, nil
+}
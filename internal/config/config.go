@@ -2,9 +2,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Application name for XDG paths
@@ -16,6 +20,20 @@ const (
 	DefaultPort      = 8080
 	DefaultLogLevel  = "info"
 	DefaultLogFormat = "text"
+
+	DefaultAccessLogLevel = "debug"
+
+	DefaultUpstreamTimeout = 300 // seconds
+	DefaultUpstreamRetries = 2
+
+	DefaultTokenExpiryMargin = 60 // seconds
+
+	DefaultQueueTimeout = 10 // seconds
+
+	DefaultResponseCacheSize = 256
+	DefaultResponseCacheTTL  = 3600 // seconds
+
+	DefaultMaxImageBytes = 20 << 20 // 20MB decoded
 )
 
 // Config holds global runtime configuration (server-level only).
@@ -23,8 +41,59 @@ const (
 type Config struct {
 	Host      string
 	Port      int
+	Listen    string // host:port or unix:/path/to.sock (overrides Host/Port)
 	LogLevel  string // debug, info, warn, error
 	LogFormat string // text, json
+	Metrics   bool   // expose /metrics endpoint
+
+	AccessLogLevel string // level of the per-request access log line
+
+	CORSOrigins []string // allowed CORS origins ("*" allows any)
+
+	UpstreamTimeout   int // upstream idle timeout in seconds (0 = no deadline)
+	FirstChunkTimeout int // seconds to wait for the first streamed chunk (0 = no deadline)
+	UpstreamRetries   int // retries for connection failures before response headers
+
+	MaxConcurrent int // maximum concurrent chat completions (0 = unlimited)
+	QueueTimeout  int // seconds a request over MaxConcurrent waits for a slot before a 503
+
+	ResponseCache     bool // cache non-streaming responses to deterministic requests in memory
+	ResponseCacheSize int  // maximum cached responses (least recently used are evicted)
+	ResponseCacheTTL  int  // seconds a cached response is served
+
+	TokenExpiryMargin int // seconds before expiry at which access tokens are refreshed
+
+	ReauthPrompt bool // offer inline re-login in interactive CLI commands
+
+	DefaultProvider   string   // provider for models without a prefix (empty = prefix required)
+	DisabledProviders []string // providers never activated, even when logged in
+
+	RoutesFile string            // JSON file mapping friendly model names to provider/model
+	Routes     map[string]string // loaded from RoutesFile by the serve command
+
+	APIKeysFile string              // JSON file mapping server API keys to allowed model prefixes
+	APIKeys     map[string][]string // loaded from APIKeysFile by the serve command (nil = no auth)
+
+	EnforceContext bool // reject requests whose estimated size exceeds the model's context window
+	StrictEffort   bool // reject unsupported reasoning efforts instead of clamping them
+	InlineImages   bool // download http(s) image URLs and send them as base64 data URLs
+	MaxImageBytes  int  // maximum decoded size of a base64 or inlined image
+
+	UsageLog bool // append per-request token usage to a JSONL ledger in the data directory
+
+	EnableMock bool // register the credential-free mock provider for testing
+
+	SelfTest bool // send a probe completion to each provider at startup
+
+	AllowNoProviders bool // start serving even when no provider is logged in
+
+	DebugBodies bool // log upstream request bodies and event streams at debug level
+	Debug       bool // expose /debug/* endpoints
+
+	// TLS configuration (plain HTTP when unset)
+	TLSCert       string // path to PEM certificate
+	TLSKey        string // path to PEM private key
+	TLSSelfSigned bool   // generate a self-signed certificate when no cert/key is set
 }
 
 // Load reads global configuration from environment variables.
@@ -32,11 +101,139 @@ func Load() *Config {
 	return &Config{
 		Host:      getEnv("OPENCOMPAT_HOST", DefaultHost),
 		Port:      getEnvInt("OPENCOMPAT_PORT", DefaultPort),
+		Listen:    getEnv("OPENCOMPAT_LISTEN", ""),
 		LogLevel:  getEnv("OPENCOMPAT_LOG_LEVEL", DefaultLogLevel),
 		LogFormat: getEnv("OPENCOMPAT_LOG_FORMAT", DefaultLogFormat),
+		Metrics:   getEnvBool("OPENCOMPAT_METRICS", false),
+
+		AccessLogLevel: getEnv("OPENCOMPAT_ACCESS_LOG_LEVEL", DefaultAccessLogLevel),
+
+		CORSOrigins: getEnvList("OPENCOMPAT_CORS_ORIGINS", []string{"*"}),
+
+		UpstreamTimeout:   getEnvInt("OPENCOMPAT_UPSTREAM_TIMEOUT", DefaultUpstreamTimeout),
+		FirstChunkTimeout: getEnvInt("OPENCOMPAT_FIRST_CHUNK_TIMEOUT", 0),
+		UpstreamRetries:   getEnvInt("OPENCOMPAT_UPSTREAM_RETRIES", DefaultUpstreamRetries),
+
+		MaxConcurrent: getEnvInt("OPENCOMPAT_MAX_CONCURRENT", 0),
+		QueueTimeout:  getEnvInt("OPENCOMPAT_QUEUE_TIMEOUT", DefaultQueueTimeout),
+
+		ResponseCache:     getEnvBool("OPENCOMPAT_RESPONSE_CACHE", false),
+		ResponseCacheSize: getEnvInt("OPENCOMPAT_RESPONSE_CACHE_SIZE", DefaultResponseCacheSize),
+		ResponseCacheTTL:  getEnvInt("OPENCOMPAT_RESPONSE_CACHE_TTL", DefaultResponseCacheTTL),
+
+		TokenExpiryMargin: getEnvInt("OPENCOMPAT_TOKEN_EXPIRY_MARGIN", DefaultTokenExpiryMargin),
+
+		ReauthPrompt: getEnvBool("OPENCOMPAT_REAUTH_PROMPT", true),
+
+		DefaultProvider:   getEnv("OPENCOMPAT_DEFAULT_PROVIDER", ""),
+		DisabledProviders: getEnvList("OPENCOMPAT_DISABLED_PROVIDERS", nil),
+
+		RoutesFile: getEnv("OPENCOMPAT_ROUTES", ""),
+
+		APIKeysFile: getEnv("OPENCOMPAT_API_KEYS", ""),
+
+		EnforceContext: getEnvBool("OPENCOMPAT_ENFORCE_CONTEXT", false),
+		StrictEffort:   getEnvBool("OPENCOMPAT_STRICT_EFFORT", false),
+		InlineImages:   getEnvBool("OPENCOMPAT_INLINE_IMAGES", false),
+		MaxImageBytes:  getEnvInt("OPENCOMPAT_MAX_IMAGE_BYTES", DefaultMaxImageBytes),
+
+		UsageLog: getEnvBool("OPENCOMPAT_USAGE_LOG", false),
+
+		EnableMock: getEnvBool("OPENCOMPAT_ENABLE_MOCK", false),
+
+		SelfTest: getEnvBool("OPENCOMPAT_SELFTEST", false),
+
+		AllowNoProviders: getEnvBool("OPENCOMPAT_ALLOW_NO_PROVIDERS", false),
+
+		DebugBodies: getEnvBool("OPENCOMPAT_DEBUG_BODIES", false),
+		Debug:       getEnvBool("OPENCOMPAT_DEBUG", false),
+
+		TLSCert:       getEnv("OPENCOMPAT_TLS_CERT", ""),
+		TLSKey:        getEnv("OPENCOMPAT_TLS_KEY", ""),
+		TLSSelfSigned: getEnvBool("OPENCOMPAT_TLS_SELF_SIGNED", false),
 	}
 }
 
+// Validate checks enumerated settings and returns an error naming the
+// environment variable and its allowed values.
+func (c *Config) Validate() error {
+	if err := checkEnum("OPENCOMPAT_LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error"); err != nil {
+		return err
+	}
+	if err := checkEnum("OPENCOMPAT_LOG_FORMAT", strings.ToLower(c.LogFormat), "text", "json"); err != nil {
+		return err
+	}
+	return checkEnum("OPENCOMPAT_ACCESS_LOG_LEVEL", strings.ToLower(c.AccessLogLevel), "debug", "info", "warn", "warning", "error")
+}
+
+// checkEnum returns an error if value is not one of allowed.
+func checkEnum(name, value string, allowed ...string) error {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return fmt.Errorf("invalid %s %q: must be one of: %s", name, value, strings.Join(allowed, ", "))
+}
+
+// ListenAddress returns the network and address the server should listen on.
+// OPENCOMPAT_LISTEN takes precedence; "unix:" prefixed values select a Unix socket.
+func (c *Config) ListenAddress() (network, address string) {
+	if path, ok := strings.CutPrefix(c.Listen, "unix:"); ok {
+		return "unix", path
+	}
+	if c.Listen != "" {
+		return "tcp", c.Listen
+	}
+	return "tcp", fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// UpstreamTimeoutDuration returns the upstream timeout as a duration (0 = no deadline).
+func (c *Config) UpstreamTimeoutDuration() time.Duration {
+	if c.UpstreamTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.UpstreamTimeout) * time.Second
+}
+
+// ProviderUpstreamTimeout returns the upstream timeout set by a provider's own
+// variable (seconds, 0 = no deadline), falling back to OPENCOMPAT_UPSTREAM_TIMEOUT.
+func (c *Config) ProviderUpstreamTimeout(envName string) time.Duration {
+	if seconds := getEnvInt(envName, -1); seconds == 0 {
+		return 0
+	} else if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return c.UpstreamTimeoutDuration()
+}
+
+// FirstChunkTimeoutDuration returns the time-to-first-chunk deadline (0 = no deadline).
+func (c *Config) FirstChunkTimeoutDuration() time.Duration {
+	if c.FirstChunkTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.FirstChunkTimeout) * time.Second
+}
+
+// QueueTimeoutDuration returns how long a request waits for a concurrency slot (0 = no wait).
+func (c *Config) QueueTimeoutDuration() time.Duration {
+	if c.QueueTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.QueueTimeout) * time.Second
+}
+
+// TokenExpiryMarginDuration returns the token expiry safety margin (never negative).
+func (c *Config) TokenExpiryMarginDuration() time.Duration {
+	if c.TokenExpiryMargin <= 0 {
+		return 0
+	}
+	return time.Duration(c.TokenExpiryMargin) * time.Second
+}
+
+// TLSEnabled returns true if the server should serve HTTPS.
+func (c *Config) TLSEnabled() bool {
+	return (c.TLSCert != "" && c.TLSKey != "") || c.TLSSelfSigned
+}
+
 // DataDir returns the XDG data directory for the application.
 // Uses $XDG_DATA_HOME/opencompat or ~/.local/share/opencompat
 func DataDir() string {
@@ -57,17 +254,44 @@ func EnsureDataDir() error {
 }
 
 func getEnv(key, defaultVal string) string {
-	if val := os.Getenv(key); val != "" {
+	if val := Getenv(key); val != "" {
 		return val
 	}
 	return defaultVal
 }
 
 func getEnvInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
+	if val := Getenv(key); val != "" {
 		if i, err := strconv.Atoi(val); err == nil {
 			return i
 		}
 	}
 	return defaultVal
 }
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+// getEnvList parses a comma-separated list, ignoring empty entries.
+func getEnvList(key string, defaultVal []string) []string {
+	val := Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return defaultVal
+	}
+	return list
+}
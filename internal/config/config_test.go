@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateRejectsUnknownEnum(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "valid", cfg: Config{LogLevel: "INFO", LogFormat: "json", AccessLogLevel: "debug"}},
+		{
+			name:    "log level",
+			cfg:     Config{LogLevel: "verbose", LogFormat: "text", AccessLogLevel: "info"},
+			wantErr: `invalid OPENCOMPAT_LOG_LEVEL "verbose": must be one of: debug, info, warn, warning, error`,
+		},
+		{
+			name:    "log format",
+			cfg:     Config{LogLevel: "info", LogFormat: "logfmt", AccessLogLevel: "info"},
+			wantErr: `invalid OPENCOMPAT_LOG_FORMAT "logfmt": must be one of: text, json`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
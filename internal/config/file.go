@@ -0,0 +1,258 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigFileName is the default config file name inside DataDir().
+const ConfigFileName = "config.yaml"
+
+// GlobalEnvVars lists the environment variables read by Load.
+var GlobalEnvVars = []string{
+	"OPENCOMPAT_HOST",
+	"OPENCOMPAT_PORT",
+	"OPENCOMPAT_LISTEN",
+	"OPENCOMPAT_LOG_LEVEL",
+	"OPENCOMPAT_LOG_FORMAT",
+	"OPENCOMPAT_ACCESS_LOG_LEVEL",
+	"OPENCOMPAT_METRICS",
+	"OPENCOMPAT_CORS_ORIGINS",
+	"OPENCOMPAT_UPSTREAM_TIMEOUT",
+	"OPENCOMPAT_FIRST_CHUNK_TIMEOUT",
+	"OPENCOMPAT_UPSTREAM_RETRIES",
+	"OPENCOMPAT_MAX_CONCURRENT",
+	"OPENCOMPAT_QUEUE_TIMEOUT",
+	"OPENCOMPAT_RESPONSE_CACHE",
+	"OPENCOMPAT_RESPONSE_CACHE_SIZE",
+	"OPENCOMPAT_RESPONSE_CACHE_TTL",
+	"OPENCOMPAT_TOKEN_EXPIRY_MARGIN",
+	"OPENCOMPAT_REAUTH_PROMPT",
+	"OPENCOMPAT_DEFAULT_PROVIDER",
+	"OPENCOMPAT_DISABLED_PROVIDERS",
+	"OPENCOMPAT_ROUTES",
+	"OPENCOMPAT_API_KEYS",
+	"OPENCOMPAT_ENFORCE_CONTEXT",
+	"OPENCOMPAT_STRICT_EFFORT",
+	"OPENCOMPAT_INLINE_IMAGES",
+	"OPENCOMPAT_MAX_IMAGE_BYTES",
+	"OPENCOMPAT_USAGE_LOG",
+	"OPENCOMPAT_ENABLE_MOCK",
+	"OPENCOMPAT_SELFTEST",
+	"OPENCOMPAT_ALLOW_NO_PROVIDERS",
+	"OPENCOMPAT_DEBUG_BODIES",
+	"OPENCOMPAT_DEBUG",
+	"OPENCOMPAT_TLS_CERT",
+	"OPENCOMPAT_TLS_KEY",
+	"OPENCOMPAT_TLS_SELF_SIGNED",
+}
+
+// fileValues holds the config file settings, keyed by environment variable.
+var fileValues = map[string]string{}
+
+// loadedFile is the path of the applied config file (empty if none).
+var loadedFile string
+
+// DefaultConfigFile returns the default config file path.
+func DefaultConfigFile() string {
+	return filepath.Join(DataDir(), ConfigFileName)
+}
+
+// LoadFile reads a config file and returns its settings keyed by the
+// OPENCOMPAT_* environment variable each one stands for.
+//
+// Keys map to environment variables by name: "port" sets OPENCOMPAT_PORT and
+// "base_url" under a "copilot:" section sets OPENCOMPAT_COPILOT_BASE_URL.
+// known lists the accepted variable names; any other key is an error.
+// A missing file returns nil values and no error unless required is true.
+// Only .yaml and .yml files are accepted, so a TOML file is not misread.
+func LoadFile(path string, required bool, known []string) (map[string]string, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("%s: unsupported config file format %q: only YAML (.yaml, .yml) is supported", path, ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	values, err := parseConfigFile(bufio.NewScanner(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	knownSet := make(map[string]bool, len(known))
+	for _, name := range known {
+		knownSet[name] = true
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	settings := make(map[string]string, len(names))
+	for _, name := range names {
+		if !knownSet[name] {
+			return nil, fmt.Errorf("%s: unknown key %q (maps to %s)", path, values[name].key, name)
+		}
+		settings[name] = values[name].value
+	}
+	return settings, nil
+}
+
+// UseFile makes settings returned by LoadFile the defaults beneath the
+// environment, so every Load/LoadConfig picks them up. Environment variables
+// (and flags) still take precedence.
+func UseFile(path string, values map[string]string) {
+	loadedFile = path
+	fileValues = values
+}
+
+// LoadedFile returns the path of the applied config file, or "" if none was found.
+func LoadedFile() string {
+	return loadedFile
+}
+
+// Lookup returns the value of a setting and whether it is set. A variable
+// present in the environment wins even when empty; otherwise the config file
+// value is used.
+func Lookup(name string) (string, bool) {
+	if val, ok := os.LookupEnv(name); ok {
+		return val, true
+	}
+	val, ok := fileValues[name]
+	return val, ok
+}
+
+// Getenv returns the value of a setting, like os.Getenv but falling back to
+// the config file.
+func Getenv(name string) string {
+	val, _ := Lookup(name)
+	return val
+}
+
+// FromFile returns true if a setting comes from the config file.
+func FromFile(name string) bool {
+	if _, ok := os.LookupEnv(name); ok {
+		return false
+	}
+	_, ok := fileValues[name]
+	return ok
+}
+
+// fileValue is a parsed config entry and the key it was written as.
+type fileValue struct {
+	key   string
+	value string
+}
+
+// parseConfigFile parses the supported YAML subset: "key: value" pairs at the
+// top level or indented under a "section:" line, "#" comments, quoted strings
+// and inline lists ("[a, b]", joined with commas).
+func parseConfigFile(scanner *bufio.Scanner) (map[string]fileValue, error) {
+	values := make(map[string]fileValue)
+	section := ""
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNum)
+		}
+
+		if !indented {
+			section = ""
+			if value == "" {
+				section = key // start of a section
+				continue
+			}
+		} else if section == "" {
+			return nil, fmt.Errorf("line %d: indented key %q outside a section", lineNum, key)
+		}
+
+		fullKey := key
+		if indented {
+			fullKey = section + "." + key
+		}
+		name := "OPENCOMPAT_" + strings.ToUpper(strings.ReplaceAll(fullKey, ".", "_"))
+		if _, dup := values[name]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNum, fullKey)
+		}
+
+		parsed, err := parseConfigValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		values[name] = fileValue{key: fullKey, value: parsed}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseConfigValue unquotes a scalar or joins an inline list with commas.
+func parseConfigValue(value string) (string, error) {
+	if inner, ok := strings.CutPrefix(value, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return "", fmt.Errorf("unterminated list")
+		}
+		var items []string
+		for _, item := range strings.Split(inner, ",") {
+			item, err := parseConfigValue(strings.TrimSpace(item))
+			if err != nil {
+				return "", err
+			}
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		return strings.Join(items, ","), nil
+	}
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if value[len(value)-1] != value[0] {
+			return "", fmt.Errorf("unterminated quoted string")
+		}
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+// stripComment removes a trailing "#" comment outside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return strings.TrimRight(line, " \t")
+}
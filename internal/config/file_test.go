@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file into a temp dir and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// useFile applies values for the duration of the test.
+func useFile(t *testing.T, path string, values map[string]string) {
+	t.Helper()
+	prevPath, prevValues := loadedFile, fileValues
+	t.Cleanup(func() { loadedFile, fileValues = prevPath, prevValues })
+	UseFile(path, values)
+}
+
+func TestLoadFileDoesNotTouchEnvironment(t *testing.T) {
+	path := writeConfig(t, ConfigFileName, "port: 9000\ncopilot:\n  models_refresh: 720\n")
+
+	values, err := LoadFile(path, true, []string{"OPENCOMPAT_PORT", "OPENCOMPAT_COPILOT_MODELS_REFRESH"})
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if values["OPENCOMPAT_PORT"] != "9000" || values["OPENCOMPAT_COPILOT_MODELS_REFRESH"] != "720" {
+		t.Errorf("values = %v", values)
+	}
+	if _, ok := os.LookupEnv("OPENCOMPAT_PORT"); ok {
+		t.Error("LoadFile() set OPENCOMPAT_PORT in the environment")
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		required bool
+		missing  bool
+		wantErr  string
+	}{
+		{name: "unknown key", content: "prot: 9000\n", wantErr: `unknown key "prot"`},
+		{name: "yml extension", file: "opencompat.yml", content: "port: 9000\n"},
+		{name: "toml file", file: "config.toml", content: "port = 9000\n", wantErr: `unsupported config file format ".toml"`},
+		{name: "no extension", file: "config", content: "port: 9000\n", wantErr: "unsupported config file format"},
+		{name: "missing optional", missing: true},
+		{name: "missing required", missing: true, required: true, wantErr: "failed to open config file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.file
+			if file == "" {
+				file = ConfigFileName
+			}
+			path := filepath.Join(t.TempDir(), file)
+			if !tt.missing {
+				path = writeConfig(t, file, tt.content)
+			}
+			values, err := LoadFile(path, tt.required, []string{"OPENCOMPAT_PORT"})
+			if tt.wantErr == "" {
+				if err != nil || (tt.missing && values != nil) || (!tt.missing && values["OPENCOMPAT_PORT"] != "9000") {
+					t.Errorf("LoadFile() = %v, %v", values, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	useFile(t, "config.yaml", map[string]string{
+		"OPENCOMPAT_PORT":      "9000",
+		"OPENCOMPAT_LOG_LEVEL": "debug",
+		"OPENCOMPAT_HOST":      "0.0.0.0",
+	})
+	t.Setenv("OPENCOMPAT_PORT", "9100")
+	// An explicitly empty variable still overrides the file
+	t.Setenv("OPENCOMPAT_LOG_LEVEL", "")
+
+	tests := []struct {
+		name      string
+		wantValue string
+		wantFile  bool
+	}{
+		{name: "OPENCOMPAT_PORT", wantValue: "9100"},
+		{name: "OPENCOMPAT_LOG_LEVEL", wantValue: ""},
+		{name: "OPENCOMPAT_HOST", wantValue: "0.0.0.0", wantFile: true},
+	}
+	for _, tt := range tests {
+		if got := Getenv(tt.name); got != tt.wantValue {
+			t.Errorf("Getenv(%s) = %q, want %q", tt.name, got, tt.wantValue)
+		}
+		if got := FromFile(tt.name); got != tt.wantFile {
+			t.Errorf("FromFile(%s) = %v, want %v", tt.name, got, tt.wantFile)
+		}
+	}
+
+	cfg := Load()
+	if cfg.Port != 9100 {
+		t.Errorf("Port = %d, want 9100", cfg.Port)
+	}
+	if cfg.LogLevel != DefaultLogLevel {
+		t.Errorf("LogLevel = %q, want default %q", cfg.LogLevel, DefaultLogLevel)
+	}
+	if cfg.Host != "0.0.0.0" {
+		t.Errorf("Host = %q, want the file value", cfg.Host)
+	}
+}
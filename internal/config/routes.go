@@ -0,0 +1,37 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadRoutes reads a model routing table from a JSON file mapping friendly
+// names to "provider/model" targets, e.g. {"gpt4": "copilot/gpt-4o"}.
+// Returns nil if path is empty.
+func LoadRoutes(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes file: %w", err)
+	}
+
+	var routes map[string]string
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse routes file %s: %w", path, err)
+	}
+
+	for name, target := range routes {
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid route name %q in %s: must be non-empty and contain no '/'", name, path)
+		}
+		if providerID, modelID, ok := strings.Cut(target, "/"); !ok || providerID == "" || modelID == "" {
+			return nil, fmt.Errorf("invalid route target %q for %q in %s: must be provider/model", target, name, path)
+		}
+	}
+	return routes, nil
+}
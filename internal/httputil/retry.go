@@ -0,0 +1,86 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// retryBaseBackoff is the delay before the first retry; it doubles after each attempt.
+const retryBaseBackoff = 250 * time.Millisecond
+
+// DoWithRetry sends req like DoWithTimeout, retrying up to retries times with
+// jittered exponential backoff when the connection fails before any response
+// headers arrive (connection reset or refused, EOF before headers).
+//
+// A request is never retried once a response has been returned: its body
+// belongs to the caller, so a stream that breaks part-way is surfaced as a
+// read error rather than replayed. req must be replayable (GetBody set, as it
+// is for bytes.Reader bodies) when retries > 0.
+func DoWithRetry(client *http.Client, req *http.Request, timeout time.Duration, retries int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := DoWithTimeout(client, attemptReq, timeout)
+		if err == nil || attempt >= retries || !retryableError(req.Context(), err) {
+			return resp, err
+		}
+
+		delay := retryBaseBackoff << attempt
+		delay += rand.N(delay) // full jitter on top of the base delay
+		slog.Debug("retrying upstream request",
+			"url", req.URL.String(),
+			"attempt", attempt+1,
+			"delay", delay,
+			"error", err,
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// rewind returns a copy of req with a fresh body for another attempt.
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed for retry")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
+
+// retryableError reports whether err is a transport failure worth retrying.
+// Cancellation and deadlines are final; so is anything the server answered.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,131 @@
+package httputil
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// countingTransport counts round trips. While refuse is positive, requests are
+// sent to refusedHost instead, so the dial fails with a real ECONNREFUSED.
+type countingTransport struct {
+	attempts    atomic.Int32
+	refuse      int32
+	refusedHost string
+	base        http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.attempts.Add(1) <= t.refuse {
+		req = req.Clone(req.Context())
+		req.URL.Host = t.refusedHost
+	}
+	return t.base.RoundTrip(req)
+}
+
+// closedAddr returns a loopback address with nothing listening on it.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+func newPost(t *testing.T, target string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(`{"stream":true}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestDoWithRetryDoesNotReplayPartialStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "data: {\"partial\":true}\n\n")
+		w.(http.Flusher).Flush()
+
+		// Reset the connection mid-body
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+		_ = conn.Close()
+	}))
+	defer srv.Close()
+
+	transport := &countingTransport{base: &http.Transport{}}
+	client := &http.Client{Transport: transport}
+
+	resp, err := DoWithRetry(client, newPost(t, srv.URL), 0, 3)
+	if err != nil {
+		t.Fatalf("DoWithRetry() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Error("reading the reset body succeeded, want an error")
+	}
+	if got := transport.attempts.Load(); got != 1 {
+		t.Errorf("upstream attempts = %d, want 1", got)
+	}
+}
+
+func TestDoWithRetryRetriesConnectionRefused(t *testing.T) {
+	var served atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	transport := &countingTransport{refuse: 1, refusedHost: closedAddr(t), base: &http.Transport{}}
+	client := &http.Client{Transport: transport}
+
+	resp, err := DoWithRetry(client, newPost(t, srv.URL), 0, 2)
+	if err != nil {
+		t.Fatalf("DoWithRetry() error = %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"stream":true}` {
+		t.Errorf("body = %q, want the replayed request body", body)
+	}
+	if got := transport.attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+	if got := served.Load(); got != 1 {
+		t.Errorf("server handled %d requests, want 1", got)
+	}
+}
+
+func TestDoWithRetryGivesUpAfterRetries(t *testing.T) {
+	addr := closedAddr(t)
+	transport := &countingTransport{refuse: 10, refusedHost: addr, base: &http.Transport{}}
+	client := &http.Client{Transport: transport}
+
+	target := (&url.URL{Scheme: "http", Host: addr}).String()
+	if _, err := DoWithRetry(client, newPost(t, target), 0, 1); err == nil {
+		t.Fatal("DoWithRetry() succeeded against a closed port")
+	}
+	if got := transport.attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DoWithTimeout sends req with a per-request deadline derived from timeout.
+// The deadline covers waiting for response headers and is extended whenever
+// response body data arrives, so long-running streams that keep making
+// progress are never severed; only stalled requests are cancelled.
+// A timeout of 0 disables the deadline.
+func DoWithTimeout(client *http.Client, req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return client.Do(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(timeout, cancel)
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &timeoutBody{
+		ReadCloser: resp.Body,
+		timer:      timer,
+		timeout:    timeout,
+		cancel:     cancel,
+	}
+	return resp, nil
+}
+
+// timeoutBody extends the request deadline on each successful read
+// and releases the request context when closed.
+type timeoutBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+}
+
+// Read reads from the body and resets the idle deadline on progress.
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+// Close stops the deadline timer and closes the underlying body.
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Upstream connection pool settings. Streaming completions hold connections
+// for minutes, so the pool is sized for several concurrent streams per host.
+const (
+	dialTimeout           = 30 * time.Second
+	keepAlive             = 30 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	idleConnTimeout       = 90 * time.Second
+	maxIdleConns          = 100
+	maxIdleConnsPerHost   = 16
+	expectContinueTimeout = time.Second
+)
+
+// sharedTransport is reused by every upstream client so idle connections
+// (and their TLS sessions) are pooled across requests.
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: keepAlive,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          maxIdleConns,
+	MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+	IdleConnTimeout:       idleConnTimeout,
+	TLSHandshakeTimeout:   tlsHandshakeTimeout,
+	ExpectContinueTimeout: expectContinueTimeout,
+}
+
+// NewClient returns an HTTP client for upstream APIs backed by the shared,
+// tuned transport. It has no overall timeout; use DoWithTimeout for
+// per-request deadlines that tolerate long streams.
+func NewClient() *http.Client {
+	return &http.Client{Transport: sharedTransport}
+}
@@ -0,0 +1,108 @@
+// Package ledger persists per-request token usage to an append-only JSONL ledger.
+//
+// The ledger is disabled by default; Record is a no-op until Enable is called.
+// Writes happen on a background goroutine so recording never blocks a request.
+package ledger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/edgard/opencompat/internal/config"
+)
+
+// LedgerFile is the ledger file name inside the data directory.
+const LedgerFile = "usage.jsonl"
+
+// queueSize bounds the records buffered for the writer; records beyond it are dropped.
+const queueSize = 1024
+
+// Entry is one ledger line.
+type Entry struct {
+	Time             time.Time `json:"ts"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	ReasoningTokens  int       `json:"reasoning_tokens,omitempty"`
+	CachedTokens     int       `json:"cached_tokens,omitempty"`
+	Estimated        bool      `json:"estimated,omitempty"`
+}
+
+// Path returns the ledger location under the data directory.
+func Path() string {
+	return filepath.Join(config.DataDir(), LedgerFile)
+}
+
+var (
+	mu      sync.Mutex
+	queue   chan Entry
+	stopped chan struct{}
+)
+
+// Enable starts the background writer appending to the ledger at path.
+func Enable(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if queue != nil {
+		_ = f.Close()
+		return nil
+	}
+	queue = make(chan Entry, queueSize)
+	stopped = make(chan struct{})
+	go write(f, queue, stopped)
+	return nil
+}
+
+// write appends queued entries to f until the queue is closed.
+func write(f *os.File, q <-chan Entry, done chan<- struct{}) {
+	defer close(done)
+	defer func() { _ = f.Close() }()
+
+	enc := json.NewEncoder(f)
+	for e := range q {
+		if err := enc.Encode(e); err != nil {
+			slog.Warn("failed to write usage ledger", "error", err)
+		}
+	}
+}
+
+// Record queues an entry for the ledger. It never blocks; when the writer
+// falls behind the entry is dropped.
+func Record(e Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- e:
+	default:
+		slog.Warn("usage ledger queue full, dropping record", "provider", e.Provider, "model", e.Model)
+	}
+}
+
+// Close flushes queued entries and stops the writer.
+func Close() {
+	mu.Lock()
+	q, done := queue, stopped
+	queue = nil
+	mu.Unlock()
+	if q == nil {
+		return
+	}
+	close(q)
+	<-done
+}
@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// Total aggregates ledger entries for one day, provider and model.
+type Total struct {
+	Day              string `json:"day"` // YYYY-MM-DD in local time
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Requests         int    `json:"requests"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	ReasoningTokens  int    `json:"reasoning_tokens"`
+	CachedTokens     int    `json:"cached_tokens"`
+	Estimated        int    `json:"estimated_requests"` // requests whose counts were estimated
+}
+
+// Summarize reads ledger lines from r and totals entries at or after since,
+// grouped by day, provider and model. Lines are decoded one at a time, so the
+// ledger is never loaded into memory; malformed lines are skipped.
+func Summarize(r io.Reader, since time.Time) ([]Total, error) {
+	type key struct{ day, provider, model string }
+	totals := map[key]*Total{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if e.Time.Before(since) {
+			continue
+		}
+
+		k := key{e.Time.Local().Format(time.DateOnly), e.Provider, e.Model}
+		t, ok := totals[k]
+		if !ok {
+			t = &Total{Day: k.day, Provider: k.provider, Model: k.model}
+			totals[k] = t
+		}
+		t.Requests++
+		t.PromptTokens += e.PromptTokens
+		t.CompletionTokens += e.CompletionTokens
+		t.ReasoningTokens += e.ReasoningTokens
+		t.CachedTokens += e.CachedTokens
+		if e.Estimated {
+			t.Estimated++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return result, nil
+}
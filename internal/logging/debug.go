@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// MaxDebugStreamBytes bounds how much of an upstream event stream is logged per request.
+const MaxDebugStreamBytes = 64 * 1024
+
+// debugBodies enables logging of upstream request and response bodies.
+var debugBodies atomic.Bool
+
+// SetDebugBodies enables or disables upstream body logging (OPENCOMPAT_DEBUG_BODIES).
+func SetDebugBodies(enabled bool) {
+	debugBodies.Store(enabled)
+}
+
+// DebugBodies reports whether upstream bodies should be logged at debug level.
+func DebugBodies() bool {
+	return debugBodies.Load()
+}
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID from ctx, or "" if none is set.
+func RequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -11,20 +11,8 @@ import (
 // level: debug, info, warn, error (default: info)
 // format: text, json (default: text)
 func Setup(level, format string) {
-	var lvl slog.Level
-	switch strings.ToLower(level) {
-	case "debug":
-		lvl = slog.LevelDebug
-	case "warn", "warning":
-		lvl = slog.LevelWarn
-	case "error":
-		lvl = slog.LevelError
-	default:
-		lvl = slog.LevelInfo
-	}
-
 	opts := &slog.HandlerOptions{
-		Level: lvl,
+		Level: ParseLevel(level),
 	}
 
 	var handler slog.Handler
@@ -37,3 +25,18 @@ func Setup(level, format string) {
 
 	slog.SetDefault(slog.New(handler))
 }
+
+// ParseLevel converts a level name (debug, info, warn, error) to a slog.Level.
+// Unknown names map to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
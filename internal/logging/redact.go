@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secret values in log output.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are masked entirely by RedactHeaders (canonical form).
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"X-Api-Key":           true,
+	"Api-Key":             true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactPatterns match secrets in free-form text; the first capture group is kept.
+var redactPatterns = []*regexp.Regexp{
+	// Authorization schemes: "Bearer abc", "Basic abc"
+	regexp.MustCompile(`(?i)\b((?:bearer|basic)\s+)[A-Za-z0-9._~+/=-]{8,}`),
+	// JSON or key=value fields with secret-looking names
+	regexp.MustCompile(`(?i)("?\b(?:access_token|refresh_token|id_token|api_key|apikey|x-api-key|authorization|client_secret|password|secret|token)"?\s*[:=]\s*"?)[^"\s,&}]+`),
+	// Well-known token formats: OpenAI/Anthropic keys, GitHub tokens, JWTs
+	regexp.MustCompile(`()\b(?:sk-(?:ant-)?[A-Za-z0-9_-]{16,}|gh[opsur]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]+)`),
+}
+
+// Redact masks authorization values, API keys and token-like strings in s.
+// Use it on any upstream body or header value before logging it.
+func Redact(s string) string {
+	for _, re := range redactPatterns {
+		s = re.ReplaceAllString(s, "${1}"+Redacted)
+	}
+	return s
+}
+
+// RedactHeaders returns a copy of h safe for logging: credential headers are
+// masked entirely and other values pass through Redact.
+func RedactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		masked := make([]string, len(values))
+		for i, v := range values {
+			if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+				masked[i] = Redacted
+			} else {
+				masked[i] = Redact(v)
+			}
+		}
+		out[name] = masked
+	}
+	return out
+}
+
+// Truncate redacts s and shortens it to at most n bytes, appending "..." when cut.
+// Redaction happens first so a secret is never left half-visible at the cut.
+func Truncate(s string, n int) string {
+	s = Redact(s)
+	if len(s) > n {
+		s = strings.ToValidUTF8(s[:n], "") + "..."
+	}
+	return s
+}
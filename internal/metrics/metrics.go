@@ -0,0 +1,293 @@
+// Package metrics provides lightweight Prometheus-compatible instrumentation.
+//
+// Metrics are collected in memory and exposed in the Prometheus text
+// exposition format. Collection is disabled by default; all recording
+// functions are no-ops until Enable is called.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// enabled controls whether recording functions collect data.
+var enabled atomic.Bool
+
+// Enable turns on metrics collection.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled reports whether metrics collection is on.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Default histogram buckets in seconds (covers fast errors up to long streams).
+var defaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Registered metrics
+var (
+	requestsTotal = newCounterVec(
+		"opencompat_http_requests_total",
+		"Total HTTP requests by path and status code.",
+		"path", "status",
+	)
+	requestDuration = newHistogramVec(
+		"opencompat_http_request_duration_seconds",
+		"HTTP request duration in seconds by path.",
+		defaultBuckets,
+		"path",
+	)
+	upstreamLatency = newHistogramVec(
+		"opencompat_upstream_latency_seconds",
+		"Time until upstream response headers are received, by provider.",
+		defaultBuckets,
+		"provider",
+	)
+	streamDuration = newHistogramVec(
+		"opencompat_stream_duration_seconds",
+		"Duration of streaming responses in seconds, by provider.",
+		defaultBuckets,
+		"provider",
+	)
+	tokensTotal = newCounterVec(
+		"opencompat_tokens_total",
+		"Total tokens reported by upstream, by provider, model and type.",
+		"provider", "model", "type",
+	)
+	tokenRefreshesTotal = newCounterVec(
+		"opencompat_token_refreshes_total",
+		"Total upstream token refreshes by provider.",
+		"provider",
+	)
+)
+
+// collectors lists all metrics in exposition order.
+var collectors = []collector{
+	requestsTotal,
+	requestDuration,
+	upstreamLatency,
+	streamDuration,
+	tokensTotal,
+	tokenRefreshesTotal,
+}
+
+// RecordRequest records a completed HTTP request.
+func RecordRequest(path string, status int, duration time.Duration) {
+	if !Enabled() {
+		return
+	}
+	requestsTotal.add(1, path, strconv.Itoa(status))
+	requestDuration.observe(duration.Seconds(), path)
+}
+
+// ObserveUpstreamLatency records the time taken for an upstream request to return.
+func ObserveUpstreamLatency(providerID string, duration time.Duration) {
+	if !Enabled() {
+		return
+	}
+	upstreamLatency.observe(duration.Seconds(), providerID)
+}
+
+// ObserveStreamDuration records the total duration of a streaming response.
+func ObserveStreamDuration(providerID string, duration time.Duration) {
+	if !Enabled() {
+		return
+	}
+	streamDuration.observe(duration.Seconds(), providerID)
+}
+
+// AddTokenUsage records token counts for a provider and model.
+func AddTokenUsage(providerID, model string, prompt, completion, reasoning, cached int) {
+	if !Enabled() {
+		return
+	}
+	tokensTotal.add(float64(prompt), providerID, model, "prompt")
+	tokensTotal.add(float64(completion), providerID, model, "completion")
+	if reasoning > 0 {
+		tokensTotal.add(float64(reasoning), providerID, model, "reasoning")
+	}
+	if cached > 0 {
+		tokensTotal.add(float64(cached), providerID, model, "cached")
+	}
+}
+
+// IncTokenRefresh records an upstream token refresh.
+func IncTokenRefresh(providerID string) {
+	if !Enabled() {
+		return
+	}
+	tokenRefreshesTotal.add(1, providerID)
+}
+
+// Handler returns an HTTP handler that serves metrics in Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+// collector is implemented by all metric types.
+type collector interface {
+	write(w io.Writer)
+}
+
+// seriesKey joins label values into a map key.
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels renders a Prometheus label set.
+func formatLabels(names, values []string, extra ...string) string {
+	var parts []string
+	for i, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatFloat renders a float without unnecessary trailing zeros.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// counterVec is a counter partitioned by label values.
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*counterSeries),
+	}
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	key := seriesKey(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: labelValues}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	_, _ = fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, s.values), formatFloat(s.value))
+	}
+}
+
+// histogramVec is a histogram partitioned by label values.
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // Per-bucket counts (non-cumulative)
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := seriesKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	_, _ = fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.values, "le", formatFloat(upper)), cumulative)
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.values, "le", "+Inf"), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.values), formatFloat(s.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.values), s.count)
+	}
+}
+
+// sortedKeys returns map keys in sorted order for stable output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,118 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/httputil"
+)
+
+// Client handles communication with the Anthropic Messages API.
+type Client struct {
+	store      *auth.Store
+	httpClient *http.Client
+	timeout    time.Duration // per-request idle timeout (0 = no deadline)
+}
+
+// NewClient creates a new Anthropic client.
+// No client-level timeout is set: requests are bounded individually by timeout.
+func NewClient(store *auth.Store, timeout time.Duration) *Client {
+	return &Client{
+		store:      store,
+		httpClient: httputil.NewClient(),
+		timeout:    timeout,
+	}
+}
+
+// getAPIKey retrieves the stored API key.
+func (c *Client) getAPIKey() (string, error) {
+	creds, err := c.store.GetAPIKeyCredentials(ProviderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if creds.APIKey == "" {
+		return "", fmt.Errorf("no API key found - please run: opencompat login %s", ProviderID)
+	}
+	return creds.APIKey, nil
+}
+
+// CheckAuth verifies the API key by listing a single model.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	apiKey, err := c.getAPIKey()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ModelsURL+"?limit=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", APIVersion)
+
+	resp, err := httputil.DoWithTimeout(c.httpClient, req, c.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return &auth.ReauthRequiredError{ProviderID: ProviderID, Reason: "API key rejected"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return parseUpstreamError(resp.StatusCode, body)
+	}
+	return nil
+}
+
+// SendRequest sends a Messages request to the Anthropic API.
+func (c *Client) SendRequest(ctx context.Context, msgReq *MessagesRequest) (*http.Response, error) {
+	apiKey, err := c.getAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Serialize request
+	body, err := json.Marshal(msgReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", MessagesURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set required headers
+	req.Header.Set("x-api-key", apiKey)
+	req.Header.Set("anthropic-version", APIVersion)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	// Send request
+	resp, err := httputil.DoWithTimeout(c.httpClient, req, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// API key was revoked or is invalid
+		_ = resp.Body.Close()
+		return nil, &auth.ReauthRequiredError{ProviderID: ProviderID, Reason: "API key rejected"}
+	}
+
+	// Handle gzip-encoded SSE from intermediate proxies
+	if err := httputil.DecompressBody(resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package anthropic
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/edgard/opencompat/internal/config"
+)
+
+// Provider identification
+const ProviderID = "anthropic"
+
+// Environment variable names for Anthropic provider
+const (
+	EnvAPIKey    = "OPENCOMPAT_ANTHROPIC_API_KEY"
+	EnvMaxTokens = "OPENCOMPAT_ANTHROPIC_MAX_TOKENS"
+	EnvTimeout   = "OPENCOMPAT_ANTHROPIC_TIMEOUT"
+)
+
+// Default values
+const (
+	// DefaultMaxTokens is used when the request sets no limit (max_tokens is required upstream).
+	DefaultMaxTokens = 8192
+
+	// contextWindow is the standard Claude context window (prompt plus completion).
+	contextWindow = 200000
+)
+
+// Anthropic API configuration
+const (
+	MessagesURL = "https://api.anthropic.com/v1/messages"
+	ModelsURL   = "https://api.anthropic.com/v1/models"
+	APIVersion  = "2023-06-01"
+)
+
+// Config holds Anthropic-specific configuration.
+type Config struct {
+	MaxTokens       int           // default max_tokens when the request sets none
+	UpstreamTimeout time.Duration // per-request idle timeout (0 = no deadline)
+}
+
+// LoadConfig reads Anthropic configuration from environment variables.
+func LoadConfig() *Config {
+	return &Config{
+		MaxTokens:       getEnvInt(EnvMaxTokens, DefaultMaxTokens),
+		UpstreamTimeout: config.Load().ProviderUpstreamTimeout(EnvTimeout),
+	}
+}
+
+// EnvVarDoc documents an environment variable.
+type EnvVarDoc struct {
+	Name        string
+	Description string
+	Default     string
+}
+
+// EnvVarDocs returns documentation for environment variables.
+func EnvVarDocs() []EnvVarDoc {
+	return []EnvVarDoc{
+		{Name: EnvAPIKey, Description: "API key used by 'opencompat login anthropic'", Default: "none"},
+		{Name: EnvMaxTokens, Description: "Default max_tokens when the request sets none", Default: strconv.Itoa(DefaultMaxTokens)},
+		{Name: EnvTimeout, Description: "Upstream timeout in seconds (0 = no deadline)", Default: "OPENCOMPAT_UPSTREAM_TIMEOUT"},
+	}
+}
+
+func getEnvInt(key string, defaultVal int) int {
+	if val := config.Getenv(key); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			return i
+		}
+	}
+	return defaultVal
+}
@@ -0,0 +1,113 @@
+// Package anthropic implements the Anthropic provider (Messages API with API key auth).
+package anthropic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+func init() {
+	provider.AddRegistration(func(r *provider.Registry) {
+		r.RegisterMeta(provider.ProviderMeta{
+			ID:         ProviderID,
+			Name:       "Anthropic",
+			AuthMethod: auth.AuthMethodAPIKey,
+			EnvVars:    convertEnvVarDocs(EnvVarDocs()),
+			APIKeyEnv:  EnvAPIKey,
+			Factory:    New,
+		})
+	})
+}
+
+// convertEnvVarDocs converts anthropic.EnvVarDoc to provider.EnvVarDoc.
+func convertEnvVarDocs(docs []EnvVarDoc) []provider.EnvVarDoc {
+	result := make([]provider.EnvVarDoc, len(docs))
+	for i, d := range docs {
+		result[i] = provider.EnvVarDoc{
+			Name:        d.Name,
+			Description: d.Description,
+			Default:     d.Default,
+		}
+	}
+	return result
+}
+
+// Provider implements the Anthropic provider.
+type Provider struct {
+	client *Client
+	cfg    *Config
+}
+
+// New creates a new Anthropic provider.
+func New(store *auth.Store) (provider.Provider, error) {
+	cfg := LoadConfig()
+	return &Provider{
+		client: NewClient(store, cfg.UpstreamTimeout),
+		cfg:    cfg,
+	}, nil
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return ProviderID
+}
+
+// Models returns the list of supported models.
+func (p *Provider) Models() []api.Model {
+	// Return models without provider prefix (registry will add it)
+	return []api.Model{
+		{ID: "claude-opus-4-1", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-opus-4-0", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-sonnet-4-5", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-sonnet-4-0", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-3-7-sonnet-latest", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-haiku-4-5", Object: "model", OwnedBy: "anthropic"},
+		{ID: "claude-3-5-haiku-latest", Object: "model", OwnedBy: "anthropic"},
+	}
+}
+
+// SupportsModel checks if a model ID is supported.
+// Any Claude model is forwarded, so dated snapshots work without a list update.
+func (p *Provider) SupportsModel(modelID string) bool {
+	return strings.HasPrefix(modelID, "claude-")
+}
+
+// SupportsTemperature returns true: temperature is forwarded to the Messages API.
+func (p *Provider) SupportsTemperature() bool {
+	return true
+}
+
+// ContextWindow returns the context window for a Claude model.
+func (p *Provider) ContextWindow(modelID string) int {
+	return contextWindow
+}
+
+// Health verifies the API key with a minimal models request.
+func (p *Provider) Health(ctx context.Context) error {
+	return p.client.CheckAuth(ctx)
+}
+
+// PreviewRequest returns the Messages API request that ChatCompletion would send.
+func (p *Provider) PreviewRequest(req *provider.ChatCompletionRequest) (any, error) {
+	return TransformRequest(req, p.cfg)
+}
+
+// ChatCompletion sends a chat completion request.
+func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
+	msgReq, err := TransformRequest(req, p.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.SendRequest(ctx, msgReq)
+	if err != nil {
+		return nil, err
+	}
+
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+	return NewStream(resp, req.Model, includeUsage), nil
+}
@@ -0,0 +1,149 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/logging"
+	"github.com/edgard/opencompat/internal/sse"
+)
+
+// Stream implements the provider.Stream interface for Anthropic responses.
+// The upstream request always streams; non-streaming responses are built at EOF.
+type Stream struct {
+	resp          *http.Response
+	reader        *sse.Reader
+	state         *StreamState
+	includeUsage  bool
+	done          bool
+	statusChecked bool
+	response      *api.ChatCompletionResponse
+	err           error
+	sentUsage     bool
+	pendingChunks []*api.ChatCompletionChunk // Buffer for multiple chunks from single event
+}
+
+// NewStream creates a new stream from an HTTP response.
+func NewStream(resp *http.Response, model string, includeUsage bool) *Stream {
+	state := NewStreamState()
+	state.Model = model
+	return &Stream{
+		resp:         resp,
+		reader:       sse.NewReader(resp.Body),
+		state:        state,
+		includeUsage: includeUsage,
+	}
+}
+
+// Next returns the next chunk.
+func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
+	// Return buffered chunks first
+	if len(s.pendingChunks) > 0 {
+		chunk := s.pendingChunks[0]
+		s.pendingChunks = s.pendingChunks[1:]
+		return chunk, nil
+	}
+
+	if s.done {
+		return nil, io.EOF
+	}
+
+	// Check HTTP status once
+	if !s.statusChecked {
+		s.statusChecked = true
+		if s.resp.StatusCode != http.StatusOK {
+			s.done = true
+			body, _ := io.ReadAll(s.resp.Body)
+			s.err = parseUpstreamError(s.resp.StatusCode, body)
+			return nil, s.err
+		}
+	}
+
+	for {
+		event, err := s.reader.ReadEvent()
+		if err != nil {
+			if err == io.EOF {
+				s.done = true
+				// Build final response for non-streaming
+				s.response = s.state.BuildNonStreamingResponse()
+
+				// Send usage chunk if requested and not sent yet
+				if s.includeUsage && !s.sentUsage {
+					s.sentUsage = true
+					if usageChunk := s.state.GetUsageChunk(); usageChunk != nil {
+						return usageChunk, nil
+					}
+				}
+
+				return nil, io.EOF
+			}
+			s.done = true
+			s.err = err
+			return nil, err
+		}
+
+		chunks, err := s.state.ProcessEvent(event)
+		if err != nil {
+			s.err = err
+			return nil, err
+		}
+
+		// Return first chunk and buffer the rest
+		if len(chunks) > 0 {
+			if len(chunks) > 1 {
+				s.pendingChunks = append(s.pendingChunks, chunks[1:]...)
+			}
+			return chunks[0], nil
+		}
+		// Continue reading if no chunks produced
+	}
+}
+
+// Response returns the accumulated non-streaming response.
+func (s *Stream) Response() *api.ChatCompletionResponse {
+	return s.response
+}
+
+// Err returns any error that occurred.
+func (s *Stream) Err() error {
+	// Prefer s.err as it may be an UpstreamError with status code
+	if s.err != nil {
+		return s.err
+	}
+	if upstreamErr := s.state.GetError(); upstreamErr != nil {
+		return &api.UpstreamError{
+			StatusCode: http.StatusBadGateway,
+			Message:    upstreamErr.Error.Message,
+			Type:       upstreamErr.Error.Type,
+		}
+	}
+	return nil
+}
+
+// Close releases resources.
+func (s *Stream) Close() error {
+	if s.resp != nil && s.resp.Body != nil {
+		return s.resp.Body.Close()
+	}
+	return nil
+}
+
+// parseUpstreamError builds an UpstreamError from an Anthropic error response.
+func parseUpstreamError(statusCode int, body []byte) *api.UpstreamError {
+	var data ErrorData
+	if err := json.Unmarshal(body, &data); err == nil && data.Error.Message != "" {
+		return &api.UpstreamError{
+			StatusCode: statusCode,
+			Message:    data.Error.Message,
+			Type:       data.Error.Type,
+		}
+	}
+
+	bodyStr := logging.Truncate(string(body), 500)
+	if bodyStr == "" {
+		bodyStr = "unknown error"
+	}
+	return api.NewUpstreamError(statusCode, bodyStr)
+}
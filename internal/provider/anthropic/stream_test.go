@@ -0,0 +1,198 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// toolUseTranscript is a recorded Messages stream: text, then a tool call.
+const toolUseTranscript = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"stop_reason":null,"usage":{"input_tokens":20,"cache_read_input_tokens":5,"cache_creation_input_tokens":0,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_01","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"type":"message_delta","stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":42}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+// newTestStream returns a Stream reading the given SSE body from a 200 response.
+func newTestStream(body string, includeUsage bool) *Stream {
+	return NewStream(&http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, "claude", includeUsage)
+}
+
+// drain reads every chunk from the stream.
+func drain(t *testing.T, s *Stream) []*api.ChatCompletionChunk {
+	t.Helper()
+	var chunks []*api.ChatCompletionChunk
+	for {
+		chunk, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestStreamToolUseTranscript(t *testing.T) {
+	s := newTestStream(toolUseTranscript, true)
+	chunks := drain(t, s)
+
+	var content, args string
+	var finish []string
+	var usage *api.Usage
+	for _, chunk := range chunks {
+		if chunk.ID != "msg_01" || chunk.Model != "claude-sonnet-4-5" {
+			t.Errorf("chunk id/model = %q/%q", chunk.ID, chunk.Model)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+			continue
+		}
+		choice := chunk.Choices[0]
+		content += choice.Delta.Content
+		for _, tc := range choice.Delta.ToolCalls {
+			if tc.Index == nil || *tc.Index != 0 {
+				t.Errorf("tool call index = %v, want 0", tc.Index)
+			}
+			if tc.ID != "" && (tc.ID != "toolu_01" || tc.Function.Name != "get_weather") {
+				t.Errorf("tool call = %+v", tc)
+			}
+			args += tc.Function.Arguments
+		}
+		if choice.FinishReason != nil {
+			finish = append(finish, *choice.FinishReason)
+		}
+	}
+
+	if content != "Let me check." {
+		t.Errorf("content = %q", content)
+	}
+	if args != `{"city":"Paris"}` {
+		t.Errorf("arguments = %q", args)
+	}
+	if len(finish) != 1 || finish[0] != "tool_calls" {
+		t.Errorf("finish reasons = %v, want [tool_calls]", finish)
+	}
+	if chunks[len(chunks)-1].Usage == nil {
+		t.Error("last chunk carries no usage")
+	}
+	wantUsage := api.Usage{PromptTokens: 25, CompletionTokens: 42, TotalTokens: 67}
+	if usage == nil || usage.PromptTokens != wantUsage.PromptTokens || usage.CompletionTokens != wantUsage.CompletionTokens || usage.TotalTokens != wantUsage.TotalTokens {
+		t.Errorf("usage = %+v, want %+v", usage, wantUsage)
+	}
+	if usage != nil && (usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 5) {
+		t.Errorf("cached tokens = %+v, want 5", usage.PromptTokensDetails)
+	}
+
+	resp := s.Response()
+	msg := resp.Choices[0].Message
+	if *resp.Choices[0].FinishReason != "tool_calls" || len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("response = %+v", resp.Choices[0])
+	}
+	if msg.ToolCalls[0].Index != nil {
+		t.Error("non-streaming tool call carries an index")
+	}
+	if s.Err() != nil {
+		t.Errorf("Err() = %v", s.Err())
+	}
+}
+
+func TestStreamStopReasons(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		want       string
+	}{
+		{stopReason: "end_turn", want: "stop"},
+		{stopReason: "max_tokens", want: "length"},
+		{stopReason: "tool_use", want: "tool_calls"},
+		{stopReason: "stop_sequence", want: "stop"},
+		{stopReason: "refusal", want: "content_filter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			body := "event: message_start\n" +
+				`data: {"type":"message_start","message":{"id":"msg_02","model":"claude","usage":{"input_tokens":3,"output_tokens":1}}}` + "\n\n" +
+				"event: content_block_delta\n" +
+				`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}` + "\n\n" +
+				"event: message_delta\n" +
+				fmt.Sprintf(`data: {"type":"message_delta","delta":{"stop_reason":%q},"usage":{"output_tokens":2}}`, tt.stopReason) + "\n\n" +
+				"event: message_stop\n" +
+				`data: {"type":"message_stop"}` + "\n\n"
+
+			s := newTestStream(body, false)
+			chunks := drain(t, s)
+			last := chunks[len(chunks)-1]
+			if last.Choices[0].FinishReason == nil || *last.Choices[0].FinishReason != tt.want {
+				t.Errorf("streamed finish_reason = %v, want %q", last.Choices[0].FinishReason, tt.want)
+			}
+			resp := s.Response()
+			if got := *resp.Choices[0].FinishReason; got != tt.want {
+				t.Errorf("response finish_reason = %q, want %q", got, tt.want)
+			}
+			if resp.Usage == nil || resp.Usage.PromptTokens != 3 || resp.Usage.CompletionTokens != 2 || resp.Usage.TotalTokens != 5 {
+				t.Errorf("usage = %+v, want 3/2/5", resp.Usage)
+			}
+		})
+	}
+}
+
+func TestStreamErrorEvent(t *testing.T) {
+	body := "event: message_start\n" +
+		`data: {"type":"message_start","message":{"id":"msg_03","model":"claude","usage":{"input_tokens":3}}}` + "\n\n" +
+		"event: error\n" +
+		`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}` + "\n\n"
+
+	s := newTestStream(body, false)
+	drain(t, s)
+
+	var upstreamErr *api.UpstreamError
+	if !errors.As(s.Err(), &upstreamErr) {
+		t.Fatalf("Err() = %v, want *api.UpstreamError", s.Err())
+	}
+	if upstreamErr.Type != "overloaded_error" || upstreamErr.Message != "Overloaded" {
+		t.Errorf("upstream error = %+v", upstreamErr)
+	}
+}
@@ -0,0 +1,478 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/provider"
+	"github.com/edgard/opencompat/internal/sse"
+)
+
+// emptyInputSchema is used for tools declared without parameters.
+var emptyInputSchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// TransformRequest converts a chat completion request to an Anthropic Messages request.
+// The upstream request always streams; non-streaming responses are accumulated.
+func TransformRequest(req *provider.ChatCompletionRequest, cfg *Config) (*MessagesRequest, error) {
+	out := &MessagesRequest{
+		Model:       req.Model,
+		MaxTokens:   cfg.MaxTokens,
+		Stream:      true,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+	}
+
+	if req.MaxCompletionTokens != nil {
+		out.MaxTokens = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil {
+		out.MaxTokens = *req.MaxTokens
+	}
+
+	stop, err := parseStop(req.Stop)
+	if err != nil {
+		return nil, err
+	}
+	out.StopSequences = stop
+
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := messageText(&msg); text != "" {
+				system = append(system, text)
+			}
+			continue
+		}
+
+		converted, err := transformMessage(&msg)
+		if err != nil {
+			return nil, err
+		}
+		if len(converted.Content) == 0 {
+			continue
+		}
+
+		// Anthropic requires alternating roles: merge consecutive turns
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == converted.Role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, converted.Content...)
+			continue
+		}
+		out.Messages = append(out.Messages, converted)
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, tool := range req.Tools {
+		if tool.Type != "function" {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = emptyInputSchema
+		}
+		out.Tools = append(out.Tools, Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	if len(out.Tools) > 0 {
+		choice, err := transformToolChoice(req.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+		if req.ParallelToolCalls != nil && !*req.ParallelToolCalls {
+			if choice == nil {
+				choice = &ToolChoice{Type: "auto"}
+			}
+			if choice.Type != "none" {
+				choice.DisableParallelToolUse = true
+			}
+		}
+		out.ToolChoice = choice
+	}
+
+	return out, nil
+}
+
+// transformMessage converts a user, assistant or tool message to an Anthropic message.
+func transformMessage(msg *api.Message) (Message, error) {
+	switch msg.Role {
+	case "user":
+		var blocks []ContentBlock
+		for _, part := range msg.GetContentParts() {
+			switch part.Type {
+			case "text":
+				if part.Text != "" {
+					blocks = append(blocks, ContentBlock{Type: "text", Text: part.Text})
+				}
+			case "image_url":
+				if part.ImageURL == nil {
+					continue
+				}
+				source, err := imageSource(part.ImageURL.URL)
+				if err != nil {
+					return Message{}, err
+				}
+				blocks = append(blocks, ContentBlock{Type: "image", Source: source})
+			}
+		}
+		return Message{Role: "user", Content: blocks}, nil
+
+	case "assistant":
+		var blocks []ContentBlock
+		if text := messageText(msg); text != "" {
+			blocks = append(blocks, ContentBlock{Type: "text", Text: text})
+		}
+		for _, tc := range msg.ToolCalls {
+			input := json.RawMessage(tc.Function.Arguments)
+			if strings.TrimSpace(tc.Function.Arguments) == "" {
+				input = json.RawMessage("{}")
+			} else if !json.Valid(input) {
+				return Message{}, fmt.Errorf("invalid arguments for tool call %s: not valid JSON", tc.ID)
+			}
+			blocks = append(blocks, ContentBlock{
+				Type:  "tool_use",
+				ID:    tc.ID,
+				Name:  tc.Function.Name,
+				Input: input,
+			})
+		}
+		return Message{Role: "assistant", Content: blocks}, nil
+
+	case "tool":
+		content, _ := json.Marshal(messageText(msg))
+		return Message{
+			Role: "user",
+			Content: []ContentBlock{{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   content,
+			}},
+		}, nil
+
+	default:
+		return Message{}, fmt.Errorf("unsupported message role: %s", msg.Role)
+	}
+}
+
+// messageText returns the concatenated text content of a message.
+func messageText(msg *api.Message) string {
+	var texts []string
+	for _, part := range msg.GetContentParts() {
+		if part.Type == "text" && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// imageSource converts an image URL (data URL or remote URL) to an image source.
+func imageSource(url string) (*ImageSource, error) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return &ImageSource{Type: "url", URL: url}, nil
+	}
+
+	header, data, ok := strings.Cut(rest, ",")
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !ok || !isBase64 || mediaType == "" {
+		return nil, fmt.Errorf("unsupported image data URL: expected data:<media-type>;base64,<data>")
+	}
+	return &ImageSource{Type: "base64", MediaType: mediaType, Data: data}, nil
+}
+
+// parseStop parses the OpenAI stop parameter (string or array of strings).
+func parseStop(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if single == "" {
+			return nil, nil
+		}
+		return []string{single}, nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("invalid stop parameter: must be a string or array of strings")
+	}
+	return list, nil
+}
+
+// transformToolChoice converts the OpenAI tool_choice parameter.
+// Returns nil when the upstream default (auto) applies.
+func transformToolChoice(raw json.RawMessage) (*ToolChoice, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch mode {
+		case "auto":
+			return &ToolChoice{Type: "auto"}, nil
+		case "required":
+			return &ToolChoice{Type: "any"}, nil
+		case "none":
+			return &ToolChoice{Type: "none"}, nil
+		default:
+			return nil, fmt.Errorf("invalid tool_choice: %s", mode)
+		}
+	}
+
+	var named struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &named); err != nil || named.Function.Name == "" {
+		return nil, fmt.Errorf("invalid tool_choice: expected a mode string or {\"type\":\"function\",\"function\":{\"name\":...}}")
+	}
+	return &ToolChoice{Type: "tool", Name: named.Function.Name}, nil
+}
+
+// StreamState accumulates Anthropic stream events into OpenAI-shaped chunks.
+type StreamState struct {
+	MessageID      string
+	Model          string
+	Created        int64
+	CurrentContent string
+	ToolCalls      []*api.ToolCall // in order of appearance
+	toolIndex      map[int]int     // content block index -> tool call index
+	FinishReason   string
+	InputUsage     Usage
+	OutputTokens   int
+	Error          *ErrorData // Upstream error from error events
+}
+
+// NewStreamState creates a new stream state.
+func NewStreamState() *StreamState {
+	return &StreamState{
+		Created:   time.Now().Unix(),
+		toolIndex: make(map[int]int),
+	}
+}
+
+// ProcessEvent processes an SSE event and returns the chunks to emit.
+func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk, error) {
+	if len(event.Data) == 0 {
+		return nil, nil
+	}
+
+	switch event.Event {
+	case EventMessageStart:
+		var data MessageStartData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, nil // Skip malformed events
+		}
+		s.MessageID = data.Message.ID
+		if data.Message.Model != "" {
+			s.Model = data.Message.Model
+		}
+		s.InputUsage = data.Message.Usage
+		s.OutputTokens = data.Message.Usage.OutputTokens
+		return []*api.ChatCompletionChunk{s.chunk(&api.Delta{Role: "assistant"}, nil)}, nil
+
+	case EventContentBlockStart:
+		var data ContentBlockStartData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, nil
+		}
+		if data.ContentBlock.Type != "tool_use" {
+			return nil, nil
+		}
+
+		idx := len(s.ToolCalls)
+		s.toolIndex[data.Index] = idx
+		s.ToolCalls = append(s.ToolCalls, &api.ToolCall{
+			ID:       data.ContentBlock.ID,
+			Type:     "function",
+			Function: api.FunctionCall{Name: data.ContentBlock.Name},
+		})
+		return []*api.ChatCompletionChunk{s.chunk(&api.Delta{
+			ToolCalls: []api.ToolCall{{
+				Index:    intPtr(idx),
+				ID:       data.ContentBlock.ID,
+				Type:     "function",
+				Function: api.FunctionCall{Name: data.ContentBlock.Name},
+			}},
+		}, nil)}, nil
+
+	case EventContentBlockDelta:
+		var data ContentBlockDeltaData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, nil
+		}
+		switch data.Delta.Type {
+		case "text_delta":
+			if data.Delta.Text == "" {
+				return nil, nil
+			}
+			s.CurrentContent += data.Delta.Text
+			return []*api.ChatCompletionChunk{s.chunk(&api.Delta{Content: data.Delta.Text}, nil)}, nil
+
+		case "input_json_delta":
+			idx, ok := s.toolIndex[data.Index]
+			if !ok || data.Delta.PartialJSON == "" {
+				return nil, nil
+			}
+			s.ToolCalls[idx].Function.Arguments += data.Delta.PartialJSON
+			return []*api.ChatCompletionChunk{s.chunk(&api.Delta{
+				ToolCalls: []api.ToolCall{{
+					Index:    intPtr(idx),
+					Function: api.FunctionCall{Arguments: data.Delta.PartialJSON},
+				}},
+			}, nil)}, nil
+		}
+		// thinking and signature deltas are not surfaced
+		return nil, nil
+
+	case EventMessageDelta:
+		var data MessageDeltaData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, nil
+		}
+		if data.Usage.OutputTokens > 0 {
+			s.OutputTokens = data.Usage.OutputTokens
+		}
+		if data.Delta.StopReason == "" {
+			return nil, nil
+		}
+		s.FinishReason = mapStopReason(data.Delta.StopReason)
+		return []*api.ChatCompletionChunk{s.chunk(&api.Delta{}, stringPtr(s.FinishReason))}, nil
+
+	case EventError:
+		var data ErrorData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return nil, nil
+		}
+		s.Error = &data
+		return nil, nil
+	}
+
+	// ping, content_block_stop, message_stop
+	return nil, nil
+}
+
+// chunk builds a streaming chunk with a single choice.
+func (s *StreamState) chunk(delta *api.Delta, finishReason *string) *api.ChatCompletionChunk {
+	return &api.ChatCompletionChunk{
+		ID:      s.MessageID,
+		Object:  "chat.completion.chunk",
+		Created: s.Created,
+		Model:   s.Model,
+		Choices: []api.Choice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finishReason,
+		}},
+	}
+}
+
+// Usage returns token usage in OpenAI format, or nil if none was reported.
+// Cached and cache-creation tokens are billed as input, so they count toward prompt tokens.
+func (s *StreamState) Usage() *api.Usage {
+	if s.MessageID == "" {
+		return nil
+	}
+
+	prompt := s.InputUsage.InputTokens + s.InputUsage.CacheReadInputTokens + s.InputUsage.CacheCreationInputTokens
+	usage := &api.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: s.OutputTokens,
+		TotalTokens:      prompt + s.OutputTokens,
+	}
+	if s.InputUsage.CacheReadInputTokens > 0 {
+		usage.PromptTokensDetails = &api.PromptTokenDetails{
+			CachedTokens: s.InputUsage.CacheReadInputTokens,
+		}
+	}
+	return usage
+}
+
+// GetUsageChunk returns a chunk with usage information for streaming.
+func (s *StreamState) GetUsageChunk() *api.ChatCompletionChunk {
+	usage := s.Usage()
+	if usage == nil {
+		return nil
+	}
+
+	return &api.ChatCompletionChunk{
+		ID:      s.MessageID,
+		Object:  "chat.completion.chunk",
+		Created: s.Created,
+		Model:   s.Model,
+		Choices: []api.Choice{}, // Empty choices array for usage-only chunk
+		Usage:   usage,
+	}
+}
+
+// GetError returns the upstream error reported by the stream, or nil.
+func (s *StreamState) GetError() *ErrorData {
+	return s.Error
+}
+
+// BuildNonStreamingResponse builds a complete ChatCompletionResponse from state.
+func (s *StreamState) BuildNonStreamingResponse() *api.ChatCompletionResponse {
+	msg := &api.Message{
+		Role: "assistant",
+	}
+	msg.SetContentString(s.CurrentContent)
+
+	// Non-streaming tool calls carry no Index field
+	for _, tc := range s.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, api.ToolCall{
+			ID:       tc.ID,
+			Type:     tc.Type,
+			Function: tc.Function,
+		})
+	}
+
+	finishReason := s.FinishReason
+	if finishReason == "" {
+		finishReason = "stop"
+	}
+
+	return &api.ChatCompletionResponse{
+		ID:      s.MessageID,
+		Object:  "chat.completion",
+		Created: s.Created,
+		Model:   s.Model,
+		Choices: []api.Choice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: stringPtr(finishReason),
+		}},
+		Usage: s.Usage(),
+	}
+}
+
+// mapStopReason converts an Anthropic stop_reason to an OpenAI finish_reason.
+func mapStopReason(reason string) string {
+	switch reason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default: // end_turn, stop_sequence, pause_turn
+		return "stop"
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,210 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// text returns a message with string content.
+func text(role, content string) api.Message {
+	msg := api.Message{Role: role}
+	msg.SetContentString(content)
+	return msg
+}
+
+var weatherTool = api.Tool{
+	Type: "function",
+	Function: api.Function{
+		Name:       "get_weather",
+		Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+	},
+}
+
+func TestTransformRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  provider.ChatCompletionRequest
+		want string
+	}{
+		{
+			name: "system messages extracted and same-role turns merged",
+			req: provider.ChatCompletionRequest{Messages: []api.Message{
+				text("system", "be brief"),
+				text("user", "hi"),
+				text("developer", "use metric"),
+				text("user", "there"),
+				text("assistant", "hello"),
+			}},
+			want: `{"model":"claude","system":"be brief\n\nuse metric","messages":[
+				{"role":"user","content":[{"type":"text","text":"hi"},{"type":"text","text":"there"}]},
+				{"role":"assistant","content":[{"type":"text","text":"hello"}]}
+			],"max_tokens":1024,"stream":true}`,
+		},
+		{
+			name: "tool calls and tool results",
+			req: provider.ChatCompletionRequest{
+				Messages: []api.Message{
+					text("user", "weather in Paris?"),
+					{Role: "assistant", ToolCalls: []api.ToolCall{{
+						ID:       "call_1",
+						Type:     "function",
+						Function: api.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+					}}},
+					{Role: "tool", ToolCallID: "call_1", Content: json.RawMessage(`"sunny"`)},
+					text("user", "thanks"),
+				},
+				Tools: []api.Tool{weatherTool},
+			},
+			want: `{"model":"claude","messages":[
+				{"role":"user","content":[{"type":"text","text":"weather in Paris?"}]},
+				{"role":"assistant","content":[{"type":"tool_use","id":"call_1","name":"get_weather","input":{"city":"Paris"}}]},
+				{"role":"user","content":[{"type":"tool_result","tool_use_id":"call_1","content":"sunny"},{"type":"text","text":"thanks"}]}
+			],"max_tokens":1024,"stream":true,
+			"tools":[{"name":"get_weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}}]}`,
+		},
+		{
+			name: "stop string and max_completion_tokens",
+			req: provider.ChatCompletionRequest{
+				Messages:            []api.Message{text("user", "count")},
+				Stop:                json.RawMessage(`"END"`),
+				MaxCompletionTokens: intPtr(64),
+			},
+			want: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"count"}]}],
+				"max_tokens":64,"stream":true,"stop_sequences":["END"]}`,
+		},
+		{
+			name: "stop list",
+			req: provider.ChatCompletionRequest{
+				Messages: []api.Message{text("user", "count")},
+				Stop:     json.RawMessage(`["a","b"]`),
+			},
+			want: `{"model":"claude","messages":[{"role":"user","content":[{"type":"text","text":"count"}]}],
+				"max_tokens":1024,"stream":true,"stop_sequences":["a","b"]}`,
+		},
+		{
+			name: "data URL and remote images",
+			req: provider.ChatCompletionRequest{Messages: []api.Message{{
+				Role: "user",
+				Content: json.RawMessage(`[
+					{"type":"text","text":"compare"},
+					{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0K"}},
+					{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}
+				]`),
+			}}},
+			want: `{"model":"claude","messages":[{"role":"user","content":[
+				{"type":"text","text":"compare"},
+				{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0K"}},
+				{"type":"image","source":{"type":"url","url":"https://example.com/cat.jpg"}}
+			]}],"max_tokens":1024,"stream":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Model = "claude"
+			out, err := TransformRequest(&tt.req, &Config{MaxTokens: 1024})
+			if err != nil {
+				t.Fatalf("TransformRequest() error = %v", err)
+			}
+			got, _ := json.Marshal(out)
+			var want bytes.Buffer
+			if err := json.Compact(&want, []byte(tt.want)); err != nil {
+				t.Fatalf("bad want JSON: %v", err)
+			}
+			if string(got) != want.String() {
+				t.Errorf("TransformRequest() =\n%s\nwant\n%s", got, want.String())
+			}
+		})
+	}
+}
+
+func TestTransformRequestToolChoice(t *testing.T) {
+	tests := []struct {
+		name       string
+		toolChoice string
+		parallel   *bool
+		want       *ToolChoice
+	}{
+		{name: "unset", want: nil},
+		{name: "auto", toolChoice: `"auto"`, want: &ToolChoice{Type: "auto"}},
+		{name: "none", toolChoice: `"none"`, want: &ToolChoice{Type: "none"}},
+		{name: "required", toolChoice: `"required"`, want: &ToolChoice{Type: "any"}},
+		{name: "named", toolChoice: `{"type":"function","function":{"name":"get_weather"}}`, want: &ToolChoice{Type: "tool", Name: "get_weather"}},
+		{name: "parallel disabled", parallel: new(bool), want: &ToolChoice{Type: "auto", DisableParallelToolUse: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &provider.ChatCompletionRequest{
+				Model:             "claude",
+				Messages:          []api.Message{text("user", "hi")},
+				Tools:             []api.Tool{weatherTool},
+				ToolChoice:        json.RawMessage(tt.toolChoice),
+				ParallelToolCalls: tt.parallel,
+			}
+			out, err := TransformRequest(req, &Config{MaxTokens: 1024})
+			if err != nil {
+				t.Fatalf("TransformRequest() error = %v", err)
+			}
+			switch {
+			case tt.want == nil && out.ToolChoice != nil:
+				t.Errorf("ToolChoice = %+v, want nil", out.ToolChoice)
+			case tt.want != nil && (out.ToolChoice == nil || *out.ToolChoice != *tt.want):
+				t.Errorf("ToolChoice = %+v, want %+v", out.ToolChoice, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     provider.ChatCompletionRequest
+		wantErr string
+	}{
+		{
+			name: "unknown tool_choice",
+			req: provider.ChatCompletionRequest{
+				Messages:   []api.Message{text("user", "hi")},
+				Tools:      []api.Tool{weatherTool},
+				ToolChoice: json.RawMessage(`"sometimes"`),
+			},
+			wantErr: "invalid tool_choice",
+		},
+		{
+			name: "non-base64 data URL",
+			req: provider.ChatCompletionRequest{Messages: []api.Message{{
+				Role:    "user",
+				Content: json.RawMessage(`[{"type":"image_url","image_url":{"url":"data:image/png,raw"}}]`),
+			}}},
+			wantErr: "unsupported image data URL",
+		},
+		{
+			name: "invalid tool call arguments",
+			req: provider.ChatCompletionRequest{Messages: []api.Message{{
+				Role:      "assistant",
+				ToolCalls: []api.ToolCall{{ID: "call_1", Function: api.FunctionCall{Name: "f", Arguments: "{"}}},
+			}}},
+			wantErr: "invalid arguments for tool call call_1",
+		},
+		{
+			name:    "bad stop",
+			req:     provider.ChatCompletionRequest{Messages: []api.Message{text("user", "hi")}, Stop: json.RawMessage(`3`)},
+			wantErr: "invalid stop parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TransformRequest(&tt.req, &Config{MaxTokens: 1024})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("TransformRequest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
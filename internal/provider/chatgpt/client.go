@@ -10,13 +10,11 @@ import (
 
 	"github.com/edgard/opencompat/internal/auth"
 	"github.com/edgard/opencompat/internal/httputil"
+	"github.com/edgard/opencompat/internal/provider"
 )
 
-// HTTP client configuration
+// Codex CLI client identification - matches official client
 const (
-	HTTPTimeout = 5 * time.Minute // Long timeout for streaming responses
-
-	// Codex CLI client identification - matches official client
 	DefaultOriginator = "codex_cli_rs"
 	CodexVersion      = "0.77.0" // Matches latest Codex CLI release
 )
@@ -40,13 +38,17 @@ type Client struct {
 
 // NewClient creates a new upstream client.
 func NewClient(store *auth.Store, cfg *Config) *Client {
+	cache := NewInstructionsCache()
+	cache.SetOverrideDir(cfg.InstructionsDir)
+	cache.SetOffline(cfg.Offline)
+	cache.SetGitHubToken(cfg.GitHubToken)
+
+	// No client-level timeout: streams are bounded per request via cfg.UpstreamTimeout
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: HTTPTimeout,
-		},
-		store: store,
-		cache: NewInstructionsCache(),
-		cfg:   cfg,
+		httpClient: httputil.NewClient(),
+		store:      store,
+		cache:      cache,
+		cfg:        cfg,
 	}
 }
 
@@ -79,12 +81,13 @@ func (c *Client) Close() {
 		c.cancelRefresh()
 		c.cancelRefresh = nil
 	}
+	c.cache.Close()
 }
 
 // SendRequest sends a chat completion request to ChatGPT and returns a reader for SSE events.
 func (c *Client) SendRequest(ctx context.Context, req *ResponsesRequest) (*http.Response, error) {
-	// Get auth credentials (auto-refreshes if expired)
-	creds, err := c.store.GetOAuthCredentialsRefreshed("chatgpt", GetOAuthConfig())
+	// Get access token and account ID (auto-refreshes if expired)
+	bundle, err := c.store.GetBundle(ProviderID, GetOAuthConfig())
 	if err != nil {
 		return nil, fmt.Errorf("auth error: %w", err)
 	}
@@ -102,15 +105,15 @@ func (c *Client) SendRequest(ctx context.Context, req *ResponsesRequest) (*http.
 	}
 
 	// Set headers to mimic Codex CLI client exactly
-	httpReq.Header.Set("Authorization", "Bearer "+creds.AccessToken)
+	httpReq.Header.Set("Authorization", "Bearer "+bundle.AccessToken)
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
 	httpReq.Header.Set("User-Agent", userAgent)
 	httpReq.Header.Set("OpenAI-Beta", "responses=experimental")
 	httpReq.Header.Set("originator", DefaultOriginator)
 
-	if creds.AccountID != "" {
-		httpReq.Header.Set("ChatGPT-Account-ID", creds.AccountID)
+	if bundle.AccountID != "" {
+		httpReq.Header.Set("ChatGPT-Account-ID", bundle.AccountID)
 	}
 
 	if req.PromptCacheKey != "" {
@@ -118,20 +121,57 @@ func (c *Client) SendRequest(ctx context.Context, req *ResponsesRequest) (*http.
 		httpReq.Header.Set("conversation_id", req.PromptCacheKey)
 	}
 
-	// Send request
-	resp, err := c.httpClient.Do(httpReq)
+	// Send request, retrying connection failures that happen before any response
+	resp, err := httputil.DoWithRetry(c.httpClient, httpReq, c.cfg.UpstreamTimeout, c.cfg.UpstreamRetries)
 	if err != nil {
 		return nil, err
 	}
 
+	// Handle gzip-encoded SSE from intermediate proxies
+	if err := httputil.DecompressBody(resp); err != nil {
+		return nil, err
+	}
+
 	return resp, nil
 }
 
+// CheckAuth refreshes the access token if needed and validates its claims.
+func (c *Client) CheckAuth() error {
+	bundle, err := c.store.GetBundle(ProviderID, GetOAuthConfig())
+	if err != nil {
+		return fmt.Errorf("auth error: %w", err)
+	}
+	if _, err := ExtractAccountID(bundle.AccessToken); err != nil {
+		return fmt.Errorf("invalid access token: %w", err)
+	}
+	return nil
+}
+
 // GetInstructions fetches instructions for a model.
 func (c *Client) GetInstructions(modelID string) (string, error) {
 	return c.cache.Get(modelID)
 }
 
+// DescribeInstructions returns the instructions for a model with their provenance.
+func (c *Client) DescribeInstructions(modelID string) (*provider.InstructionsInfo, error) {
+	return c.cache.Describe(modelID)
+}
+
+// InstructionsLoaded returns true if all instruction files are cached in memory.
+func (c *Client) InstructionsLoaded() bool {
+	return c.cache.Loaded()
+}
+
+// HasInstructions returns true if the prompt file is cached in memory.
+func (c *Client) HasInstructions(promptFile string) bool {
+	return c.cache.Has(promptFile)
+}
+
+// InstructionsVersion returns the release tag of the cached instructions.
+func (c *Client) InstructionsVersion() string {
+	return c.cache.Version()
+}
+
 // RefreshInstructions forces a refresh of all instruction files.
 func (c *Client) RefreshInstructions(ctx context.Context) error {
 	return c.cache.RefreshAll(ctx)
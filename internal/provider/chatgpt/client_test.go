@@ -0,0 +1,96 @@
+package chatgpt
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/edgard/opencompat/internal/auth"
+)
+
+// redirectTransport sends every request to target. Compression is disabled so,
+// as with a proxy compressing unprompted, the transport leaves gzip bodies alone.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return (&http.Transport{DisableCompression: true}).RoundTrip(req)
+}
+
+func TestSendRequestDecodesGzipSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz, "event: response.created\n"+
+			`data: {"type":"response.created","response":{"id":"resp_1","created_at":1}}`+"\n\n"+
+			"event: response.output_text.delta\n"+
+			`data: {"type":"response.output_text.delta","delta":"hello"}`+"\n\n"+
+			"event: response.completed\n"+
+			`data: {"type":"response.completed","response":{"id":"resp_1"}}`+"\n\n")
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	store := auth.NewStore()
+	if err := store.SaveOAuthCredentials(ProviderID, &auth.OAuthCredentials{
+		Type:         "oauth",
+		AccessToken:  "test-token",
+		RefreshToken: "refresh",
+		AccountID:    "acct",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	target, _ := url.Parse(srv.URL)
+	c := &Client{
+		httpClient: &http.Client{Transport: redirectTransport{target: target}},
+		store:      store,
+		cfg:        &Config{},
+	}
+
+	resp, err := c.SendRequest(context.Background(), &ResponsesRequest{Model: "gpt-5.2", Stream: true})
+	if err != nil {
+		t.Fatalf("SendRequest() error = %v", err)
+	}
+	if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q after decoding, want empty", enc)
+	}
+
+	s := newTestStream(resp.Body, true)
+	defer func() { _ = s.Close() }()
+	var content string
+	for {
+		chunk, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
+			content += chunk.Choices[0].Delta.Content
+		}
+	}
+	if content != "hello" {
+		t.Errorf("content = %q, want %q", content, "hello")
+	}
+	if s.state.FinishReason != "stop" {
+		t.Errorf("finish reason = %q, want stop", s.state.FinishReason)
+	}
+}
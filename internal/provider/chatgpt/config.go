@@ -1,16 +1,35 @@
 package chatgpt
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/config"
 )
 
 // Environment variable names for ChatGPT provider
 const (
 	EnvInstructionsRefresh = "OPENCOMPAT_CHATGPT_INSTRUCTIONS_REFRESH"
+	EnvMinReasoningEffort  = "OPENCOMPAT_MIN_REASONING_EFFORT"
+	EnvInstructionsDir     = "OPENCOMPAT_INSTRUCTIONS_DIR"
+	EnvOffline             = "OPENCOMPAT_OFFLINE"
+	EnvGitHubToken         = "OPENCOMPAT_GITHUB_TOKEN"
+	EnvThinkOpen           = "OPENCOMPAT_THINK_OPEN"
+	EnvThinkClose          = "OPENCOMPAT_THINK_CLOSE"
+	EnvEnforceStop         = "OPENCOMPAT_ENFORCE_STOP"
+	EnvExposeWebSearch     = "OPENCOMPAT_EXPOSE_WEB_SEARCH"
+	EnvInterimUsage        = "OPENCOMPAT_INTERIM_USAGE"
+	EnvSystemPrompt        = "OPENCOMPAT_SYSTEM_PROMPT"
+	EnvStateful            = "OPENCOMPAT_STATEFUL"
+	EnvTimeout             = "OPENCOMPAT_CHATGPT_TIMEOUT"
+
+	EnvRequireAllInstructions = "OPENCOMPAT_REQUIRE_ALL_INSTRUCTIONS"
 )
 
 // Default values
@@ -20,6 +39,8 @@ const (
 	DefaultReasoningCompat     = "none"
 	DefaultTextVerbosity       = "medium"
 	DefaultInstructionsRefresh = 24 * 60 // 24 hours in minutes
+	DefaultThinkOpen           = "<think>"
+	DefaultThinkClose          = "</think>"
 	OAuthClientID              = "app_EMoamEEZ73f0CkXaXp7hrann"
 )
 
@@ -56,11 +77,34 @@ const AppName = "opencompat"
 type Config struct {
 	ReasoningEffort     string // none, low, medium, high, xhigh (default, overridable via header)
 	ReasoningSummary    string // auto, concise, detailed (default, overridable via header)
-	ReasoningCompat     string // none, think-tags, o3, legacy (default, overridable via header)
+	ReasoningCompat     string // none, think-tags, o3, legacy, reasoning-content (default, overridable via header)
 	TextVerbosity       string // low, medium, high (default, overridable via header)
 	InstructionsRefresh int    // refresh interval in minutes
+	MinReasoningEffort  string // server-wide effort floor (empty = no floor)
+	InstructionsDir     string // local instruction override directory (empty = disabled)
+	Offline             bool   // load instructions from disk cache only
+	GitHubToken         string // token for authenticated instruction fetches (empty = anonymous)
+	ThinkOpen           string // opening delimiter for think-tags reasoning compat
+	ThinkClose          string // closing delimiter for think-tags reasoning compat
+	EnforceStop         bool   // truncate output at stop sequences locally
+	ExposeWebSearch     bool   // return built-in web search sources to the client
+	InterimUsage        bool   // stream estimated usage at reasoning boundaries
+	SystemPrompt        string // text or file path appended to the resolved instructions
+	Stateful            bool   // store=true and keep item ids/references for server-side state
+
+	RequireAllInstructions bool // refuse to start unless every prompt file is loaded
+
+	UpstreamTimeout time.Duration // per-request idle timeout (0 = no deadline)
+	UpstreamRetries int           // retries for connection failures before response headers
 }
 
+// Allowed values for reasoning and verbosity settings
+var (
+	ValidReasoningSummaries = []string{"auto", "concise", "detailed"}
+	ValidReasoningCompats   = []string{"none", "think-tags", "o3", "legacy", "reasoning-content"}
+	ValidTextVerbosities    = []string{"low", "medium", "high"}
+)
+
 // LoadConfig reads ChatGPT configuration from environment variables.
 func LoadConfig() *Config {
 	return &Config{
@@ -69,7 +113,61 @@ func LoadConfig() *Config {
 		ReasoningCompat:     DefaultReasoningCompat,
 		TextVerbosity:       DefaultTextVerbosity,
 		InstructionsRefresh: getEnvInt(EnvInstructionsRefresh, DefaultInstructionsRefresh),
+		MinReasoningEffort:  config.Getenv(EnvMinReasoningEffort),
+		InstructionsDir:     config.Getenv(EnvInstructionsDir),
+		Offline:             getEnvBool(EnvOffline, false),
+		GitHubToken:         getEnvFirst(EnvGitHubToken, "GITHUB_TOKEN"),
+		ThinkOpen:           getEnvString(EnvThinkOpen, DefaultThinkOpen),
+		ThinkClose:          getEnvString(EnvThinkClose, DefaultThinkClose),
+		EnforceStop:         getEnvBool(EnvEnforceStop, false),
+		ExposeWebSearch:     getEnvBool(EnvExposeWebSearch, false),
+		InterimUsage:        getEnvBool(EnvInterimUsage, false),
+		SystemPrompt:        config.Getenv(EnvSystemPrompt),
+		Stateful:            getEnvBool(EnvStateful, false),
+
+		RequireAllInstructions: getEnvBool(EnvRequireAllInstructions, true),
+
+		UpstreamTimeout: config.Load().ProviderUpstreamTimeout(EnvTimeout),
+		UpstreamRetries: max(config.Load().UpstreamRetries, 0),
+	}
+}
+
+// Validate checks enumerated settings and returns an error listing the allowed values.
+func (c *Config) Validate() error {
+	checks := []struct {
+		name    string
+		value   string
+		allowed []string
+	}{
+		{"reasoning effort", c.ReasoningEffort, effortLevels},
+		{"reasoning summary", c.ReasoningSummary, ValidReasoningSummaries},
+		{"reasoning compat", c.ReasoningCompat, ValidReasoningCompats},
+		{"text verbosity", c.TextVerbosity, ValidTextVerbosities},
+	}
+	for _, check := range checks {
+		if !slices.Contains(check.allowed, check.value) {
+			return fmt.Errorf("invalid %s %q: must be one of: %s", check.name, check.value, strings.Join(check.allowed, ", "))
+		}
 	}
+
+	// Empty means no floor
+	if c.MinReasoningEffort != "" && !slices.Contains(effortLevels, c.MinReasoningEffort) {
+		return fmt.Errorf("invalid %s %q: must be one of: %s", EnvMinReasoningEffort, c.MinReasoningEffort, strings.Join(effortLevels, ", "))
+	}
+	return nil
+}
+
+// SystemPromptText returns the prompt appended to the instructions. If
+// SystemPrompt names a readable file, its contents are used (re-read on every
+// call, like instruction overrides); otherwise the value itself is the prompt.
+func (c *Config) SystemPromptText() string {
+	if c.SystemPrompt == "" {
+		return ""
+	}
+	if data, err := os.ReadFile(c.SystemPrompt); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return strings.TrimSpace(c.SystemPrompt)
 }
 
 // EnvVarDocs returns documentation for environment variables.
@@ -77,6 +175,19 @@ func LoadConfig() *Config {
 func EnvVarDocs() []EnvVarDoc {
 	return []EnvVarDoc{
 		{Name: EnvInstructionsRefresh, Description: "Instructions refresh interval in minutes", Default: strconv.Itoa(DefaultInstructionsRefresh)},
+		{Name: EnvMinReasoningEffort, Description: "Minimum reasoning effort floor (none, low, medium, high, xhigh)", Default: "none"},
+		{Name: EnvInstructionsDir, Description: "Directory with local instruction overrides", Default: "none"},
+		{Name: EnvOffline, Description: "Never fetch instructions from GitHub (use disk cache)", Default: "false"},
+		{Name: EnvGitHubToken, Description: "GitHub token for instruction fetches (falls back to GITHUB_TOKEN)", Default: "none"},
+		{Name: EnvThinkOpen, Description: "Opening delimiter for think-tags reasoning compat", Default: DefaultThinkOpen},
+		{Name: EnvThinkClose, Description: "Closing delimiter for think-tags reasoning compat", Default: DefaultThinkClose},
+		{Name: EnvEnforceStop, Description: "Truncate output at stop sequences locally", Default: "false"},
+		{Name: EnvExposeWebSearch, Description: "Return built-in web search sources as web_search_results", Default: "false"},
+		{Name: EnvInterimUsage, Description: "Stream estimated usage chunks at reasoning boundaries (needs include_usage)", Default: "false"},
+		{Name: EnvSystemPrompt, Description: "Text, or path to a file, appended to the instructions", Default: "none"},
+		{Name: EnvStateful, Description: "Send store=true and keep item ids/references (server-side state)", Default: "false"},
+		{Name: EnvRequireAllInstructions, Description: "Refuse to start unless every model's instructions load", Default: "true"},
+		{Name: EnvTimeout, Description: "Upstream timeout in seconds (0 = no deadline)", Default: "OPENCOMPAT_UPSTREAM_TIMEOUT"},
 	}
 }
 
@@ -106,7 +217,7 @@ func EnsureCacheDir() error {
 }
 
 func getEnvInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
+	if val := config.Getenv(key); val != "" {
 		if i, err := strconv.Atoi(val); err == nil {
 			return i
 		}
@@ -114,6 +225,32 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvString(key, defaultVal string) string {
+	if val := config.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+// getEnvFirst returns the value of the first non-empty environment variable.
+func getEnvFirst(keys ...string) string {
+	for _, key := range keys {
+		if val := config.Getenv(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := config.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 // GetOAuthConfig returns the OAuth configuration for ChatGPT.
 // Returns a fresh copy each time to prevent mutation of shared state.
 func GetOAuthConfig() *auth.OAuthConfig {
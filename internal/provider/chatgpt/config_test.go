@@ -0,0 +1,30 @@
+package chatgpt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+func TestValidateConfigsRejectsInvalidEffortFloor(t *testing.T) {
+	t.Setenv(EnvMinReasoningEffort, "hgh")
+
+	// Registration alone is enough: serve validates before checking logins
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+	err := registry.ValidateConfigs()
+	if err == nil {
+		t.Fatal("ValidateConfigs() accepted an invalid effort floor")
+	}
+	for _, want := range []string{EnvMinReasoningEffort, `"hgh"`, strings.Join(effortLevels, ", ")} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+
+	t.Setenv(EnvMinReasoningEffort, "high")
+	if err := registry.ValidateConfigs(); err != nil {
+		t.Errorf("ValidateConfigs() with a valid floor error = %v", err)
+	}
+}
@@ -2,27 +2,52 @@ package chatgpt
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// Instruction fetch settings
+const (
+	instructionsFetchTimeout = 15 * time.Second
+	instructionsFetchRetries = 2           // retries after the first attempt
+	instructionsRetryBackoff = time.Second // doubled after each retry
+	instructionsPrefetchJobs = 4           // concurrent fetches during prefetch
 )
 
 // InstructionsCache manages caching of Codex instructions from GitHub.
 type InstructionsCache struct {
+	httpClient      *http.Client
 	mu              sync.RWMutex
 	cache           map[string]*cacheEntry
-	version         string
 	refreshInterval time.Duration
+	overrideDir     string         // local override directory (empty = disabled)
+	offline         bool           // never fetch from GitHub; rely on disk cache only
+	githubToken     string         // optional token for authenticated GitHub requests
+	saves           sync.WaitGroup // pending asynchronous disk cache writes
+
+	// ctx bounds fetches made without a caller context (prefetch, lazy loads);
+	// Close cancels it so shutdown does not wait out retries.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type cacheEntry struct {
 	content   string
+	etag      string
+	version   string // release tag the content was fetched from ("" for local overrides)
 	fetchedAt time.Time
 }
 
@@ -30,16 +55,34 @@ type cacheMeta struct {
 	Version   string    `json:"version"`
 	FetchedAt time.Time `json:"fetched_at"`
 	ETag      string    `json:"etag,omitempty"`
+	SHA256    string    `json:"sha256,omitempty"` // hex digest of the content file (absent in older caches)
+}
+
+// fetchResult is the outcome of a conditional GitHub fetch.
+type fetchResult struct {
+	content     string
+	etag        string
+	version     string // release tag (or "main") the request targeted
+	notModified bool   // GitHub returned 304; content is the cached copy
 }
 
 // NewInstructionsCache creates a new instructions cache.
 func NewInstructionsCache() *InstructionsCache {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &InstructionsCache{
+		httpClient:      &http.Client{Timeout: instructionsFetchTimeout},
 		cache:           make(map[string]*cacheEntry),
 		refreshInterval: time.Duration(DefaultInstructionsRefresh) * time.Minute,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 }
 
+// Close cancels in-flight fetches, including prefetch and retry backoff.
+func (c *InstructionsCache) Close() {
+	c.cancel()
+}
+
 // SetRefreshInterval sets the memory cache refresh interval.
 func (c *InstructionsCache) SetRefreshInterval(interval time.Duration) {
 	c.mu.Lock()
@@ -47,29 +90,93 @@ func (c *InstructionsCache) SetRefreshInterval(interval time.Duration) {
 	c.mu.Unlock()
 }
 
-// Prefetch fetches all prompt files on startup.
+// SetOverrideDir sets the directory containing local instruction overrides.
+// A file named after the prompt file replaces the upstream instructions;
+// a "{promptFile}.append" file is appended to them.
+func (c *InstructionsCache) SetOverrideDir(dir string) {
+	c.mu.Lock()
+	c.overrideDir = dir
+	c.mu.Unlock()
+}
+
+// SetOffline enables offline mode, in which instructions are loaded only from
+// the override directory or disk cache and GitHub is never contacted.
+func (c *InstructionsCache) SetOffline(offline bool) {
+	c.mu.Lock()
+	c.offline = offline
+	c.mu.Unlock()
+}
+
+// SetGitHubToken sets a token used to authenticate GitHub requests,
+// avoiding anonymous rate limits. An empty token keeps requests anonymous.
+func (c *InstructionsCache) SetGitHubToken(token string) {
+	c.mu.Lock()
+	c.githubToken = token
+	c.mu.Unlock()
+}
+
+// isOffline reports whether offline mode is enabled.
+func (c *InstructionsCache) isOffline() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.offline
+}
+
+// readOverride reads a file from the override directory.
+// Returns false if overrides are disabled or the file doesn't exist.
+func (c *InstructionsCache) readOverride(name string) (string, bool) {
+	c.mu.RLock()
+	dir := c.overrideDir
+	c.mu.RUnlock()
+
+	if dir == "" {
+		return "", false
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("failed to read instruction override",
+				"file", name,
+				"error", err,
+			)
+		}
+		return "", false
+	}
+	return string(data), true
+}
+
+// Prefetch fetches all prompt files on startup, a few at a time.
 // Returns error if any file cannot be fetched AND has no valid disk cache.
 func (c *InstructionsCache) Prefetch() error {
 	promptFiles := GetAllPromptFiles()
-	var errs []string
+	results := make([]error, len(promptFiles))
 
 	slog.Debug("prefetching instruction files", "count", len(promptFiles))
 
-	for _, promptFile := range promptFiles {
-		content, err := c.prefetchOne(promptFile)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", promptFile, err))
-			continue
-		}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, instructionsPrefetchJobs)
+	for i, promptFile := range promptFiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-		c.mu.Lock()
-		c.cache[promptFile] = &cacheEntry{
-			content:   content,
-			fetchedAt: time.Now(),
-		}
-		c.mu.Unlock()
+			results[i] = c.prefetchOne(c.ctx, promptFile)
+			if results[i] == nil {
+				slog.Debug("loaded instruction file", "file", promptFile)
+			}
+		}()
+	}
+	wg.Wait()
 
-		slog.Debug("loaded instruction file", "file", promptFile)
+	// Collect errors in prompt file order
+	var errs []string
+	for i, err := range results {
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", promptFiles[i], err))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -80,21 +187,30 @@ func (c *InstructionsCache) Prefetch() error {
 	return nil
 }
 
-// prefetchOne fetches a single prompt file, trying GitHub first, then disk cache.
-func (c *InstructionsCache) prefetchOne(promptFile string) (string, error) {
+// prefetchOne loads a single prompt file into the memory cache, trying GitHub
+// first, then disk cache. Fully overridden prompt files are loaded locally
+// without touching the network.
+func (c *InstructionsCache) prefetchOne(ctx context.Context, promptFile string) error {
+	if content, ok := c.readOverride(promptFile); ok {
+		slog.Debug("using local instruction override", "file", promptFile)
+		c.setEntry(promptFile, content, "", "")
+		return nil
+	}
+
+	if c.isOffline() {
+		content, meta, err := c.loadFromDiskWithExpired(promptFile)
+		if err != nil {
+			return fmt.Errorf("no disk cache available in offline mode (run once with network access first): %w", err)
+		}
+		c.setEntry(promptFile, content, meta.ETag, meta.Version)
+		return nil
+	}
+
 	// Try GitHub first
-	content, err := c.fetchFromGitHub(promptFile)
+	res, err := c.fetchFromGitHub(ctx, promptFile)
 	if err == nil {
-		// Save to disk cache (async)
-		go func(pf, content string) {
-			if err := c.saveToDisk(pf, content); err != nil {
-				slog.Warn("failed to save instruction to disk cache",
-					"file", pf,
-					"error", err,
-				)
-			}
-		}(promptFile, content)
-		return content, nil
+		c.store(promptFile, res)
+		return nil
 	}
 
 	slog.Warn("github fetch failed, trying disk cache",
@@ -103,18 +219,25 @@ func (c *InstructionsCache) prefetchOne(promptFile string) (string, error) {
 	)
 
 	// Fallback to disk cache (even if expired)
-	content, diskErr := c.loadFromDiskWithExpired(promptFile)
+	content, meta, diskErr := c.loadFromDiskWithExpired(promptFile)
 	if diskErr == nil {
-		return content, nil
+		c.setEntry(promptFile, content, meta.ETag, meta.Version)
+		return nil
 	}
 
-	return "", fmt.Errorf("github: %w, disk cache: %v", err, diskErr)
+	return fmt.Errorf("github: %w, disk cache: %v", err, diskErr)
 }
 
 // StartBackgroundRefresh starts a goroutine that periodically refreshes all instructions.
+// In offline mode this is a no-op.
 func (c *InstructionsCache) StartBackgroundRefresh(ctx context.Context, interval time.Duration) {
 	c.SetRefreshInterval(interval)
 
+	if c.isOffline() {
+		slog.Debug("offline mode, background instructions refresh disabled")
+		return
+	}
+
 	go func() {
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
@@ -125,7 +248,7 @@ func (c *InstructionsCache) StartBackgroundRefresh(ctx context.Context, interval
 				slog.Debug("background instructions refresh stopped")
 				return
 			case <-ticker.C:
-				c.refreshAll()
+				c.refreshAll(ctx)
 			}
 		}
 	}()
@@ -134,13 +257,18 @@ func (c *InstructionsCache) StartBackgroundRefresh(ctx context.Context, interval
 }
 
 // refreshAll refreshes all prompt files in the background.
-func (c *InstructionsCache) refreshAll() {
+func (c *InstructionsCache) refreshAll(ctx context.Context) {
 	promptFiles := GetAllPromptFiles()
 	slog.Debug("background refresh started", "count", len(promptFiles))
 
 	successCount := 0
 	for _, promptFile := range promptFiles {
-		content, err := c.fetchFromGitHub(promptFile)
+		if _, ok := c.readOverride(promptFile); ok {
+			successCount++
+			continue
+		}
+
+		res, err := c.fetchFromGitHub(ctx, promptFile)
 		if err != nil {
 			slog.Warn("failed to refresh instruction file",
 				"file", promptFile,
@@ -149,22 +277,7 @@ func (c *InstructionsCache) refreshAll() {
 			continue
 		}
 
-		c.mu.Lock()
-		c.cache[promptFile] = &cacheEntry{
-			content:   content,
-			fetchedAt: time.Now(),
-		}
-		c.mu.Unlock()
-
-		// Save to disk cache (async)
-		go func(pf, content string) {
-			if err := c.saveToDisk(pf, content); err != nil {
-				slog.Warn("failed to save instruction to disk cache",
-					"file", pf,
-					"error", err,
-				)
-			}
-		}(promptFile, content)
+		c.store(promptFile, res)
 		successCount++
 	}
 
@@ -177,6 +290,10 @@ func (c *InstructionsCache) refreshAll() {
 // RefreshAll forces a refresh of all instruction files.
 // Returns error if any file cannot be fetched.
 func (c *InstructionsCache) RefreshAll(ctx context.Context) error {
+	if c.isOffline() {
+		return errors.New("cannot refresh instructions in offline mode")
+	}
+
 	promptFiles := GetAllPromptFiles()
 	slog.Debug("force refreshing instruction files", "count", len(promptFiles))
 
@@ -189,28 +306,17 @@ func (c *InstructionsCache) RefreshAll(ctx context.Context) error {
 		default:
 		}
 
-		content, err := c.fetchFromGitHub(promptFile)
-		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s: %v", promptFile, err))
+		if _, ok := c.readOverride(promptFile); ok {
 			continue
 		}
 
-		c.mu.Lock()
-		c.cache[promptFile] = &cacheEntry{
-			content:   content,
-			fetchedAt: time.Now(),
+		res, err := c.fetchFromGitHub(ctx, promptFile)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", promptFile, err))
+			continue
 		}
-		c.mu.Unlock()
 
-		// Save to disk cache (async)
-		go func(pf, content string) {
-			if err := c.saveToDisk(pf, content); err != nil {
-				slog.Warn("failed to save instruction to disk cache",
-					"file", pf,
-					"error", err,
-				)
-			}
-		}(promptFile, content)
+		c.store(promptFile, res)
 	}
 
 	if len(errs) > 0 {
@@ -221,18 +327,105 @@ func (c *InstructionsCache) RefreshAll(ctx context.Context) error {
 	return nil
 }
 
-// Get retrieves instructions for a model from cache.
-// After prefetch, this should always return from memory cache.
+// Loaded returns true if every prompt file is present in the memory cache.
+func (c *InstructionsCache) Loaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, promptFile := range GetAllPromptFiles() {
+		if _, ok := c.cache[promptFile]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Has returns true if the prompt file is present in the memory cache.
+func (c *InstructionsCache) Has(promptFile string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.cache[promptFile]
+	return ok
+}
+
+// Version returns the release tag of the most recently fetched upstream
+// prompt file in memory, or "" if none has a known release (e.g. overrides only).
+func (c *InstructionsCache) Version() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var version string
+	var fetchedAt time.Time
+	for _, entry := range c.cache {
+		if entry.version != "" && entry.fetchedAt.After(fetchedAt) {
+			version, fetchedAt = entry.version, entry.fetchedAt
+		}
+	}
+	return version
+}
+
+// Get retrieves instructions for a model.
+// Local overrides take precedence: a full override skips the network entirely,
+// and an ".append" file is appended to the upstream instructions.
 func (c *InstructionsCache) Get(modelID string) (string, error) {
 	promptFile := GetPromptFile(modelID)
 
+	if content, ok := c.readOverride(promptFile); ok {
+		return content, nil
+	}
+
+	content, err := c.getUpstream(promptFile)
+	if err != nil {
+		return "", err
+	}
+
+	if extra, ok := c.readOverride(promptFile + ".append"); ok {
+		content = strings.TrimRight(content, "\n") + "\n\n" + extra
+	}
+	return content, nil
+}
+
+// Describe returns the resolved instructions for a model (as Get does) along
+// with where they came from and the cached release version.
+// Source is "override", "upstream" or "upstream+append".
+func (c *InstructionsCache) Describe(modelID string) (*provider.InstructionsInfo, error) {
+	content, err := c.Get(modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	promptFile := GetPromptFile(modelID)
+	info := &provider.InstructionsInfo{
+		Model:      modelID,
+		PromptFile: promptFile,
+		Source:     "upstream",
+		Content:    content,
+	}
+	if _, ok := c.readOverride(promptFile); ok {
+		info.Source = "override"
+		return info, nil
+	}
+	if _, ok := c.readOverride(promptFile + ".append"); ok {
+		info.Source = "upstream+append"
+	}
+
+	c.mu.RLock()
+	if entry, ok := c.cache[promptFile]; ok {
+		info.Version = entry.version
+		info.FetchedAt = entry.fetchedAt
+	}
+	c.mu.RUnlock()
+	return info, nil
+}
+
+// getUpstream retrieves upstream instructions for a prompt file from cache.
+// After prefetch, this should always return from memory cache.
+func (c *InstructionsCache) getUpstream(promptFile string) (string, error) {
 	// Check memory cache first
 	c.mu.RLock()
 	entry, ok := c.cache[promptFile]
 	refreshInterval := c.refreshInterval
 	c.mu.RUnlock()
 
-	if ok && time.Since(entry.fetchedAt) < refreshInterval {
+	if ok && (time.Since(entry.fetchedAt) < refreshInterval || c.isOffline()) {
 		return entry.content, nil
 	}
 
@@ -241,7 +434,7 @@ func (c *InstructionsCache) Get(modelID string) (string, error) {
 	if ok {
 		// We have stale data, try to refresh in background
 		go func() {
-			content, err := c.fetchFromGitHub(promptFile)
+			res, err := c.fetchFromGitHub(c.ctx, promptFile)
 			if err != nil {
 				slog.Warn("failed to refresh instructions",
 					"file", promptFile,
@@ -249,20 +442,7 @@ func (c *InstructionsCache) Get(modelID string) (string, error) {
 				)
 				return
 			}
-			c.mu.Lock()
-			c.cache[promptFile] = &cacheEntry{
-				content:   content,
-				fetchedAt: time.Now(),
-			}
-			c.mu.Unlock()
-			go func(pf, content string) {
-				if err := c.saveToDisk(pf, content); err != nil {
-					slog.Warn("failed to save instruction to disk cache",
-						"file", pf,
-						"error", err,
-					)
-				}
-			}(promptFile, content)
+			c.store(promptFile, res)
 		}()
 		// Return stale data for now
 		return entry.content, nil
@@ -270,44 +450,64 @@ func (c *InstructionsCache) Get(modelID string) (string, error) {
 
 	// No cache at all - this should only happen if prefetch wasn't called
 	// Try to load from disk
-	content, err := c.loadFromDiskWithExpired(promptFile)
+	content, meta, err := c.loadFromDiskWithExpired(promptFile)
 	if err == nil && content != "" {
-		c.mu.Lock()
-		c.cache[promptFile] = &cacheEntry{
-			content:   content,
-			fetchedAt: time.Now(),
-		}
-		c.mu.Unlock()
+		c.setEntry(promptFile, content, meta.ETag, meta.Version)
 		return content, nil
 	}
 
+	if c.isOffline() {
+		return "", fmt.Errorf("instructions %s not cached and offline mode is enabled", promptFile)
+	}
+
 	// Last resort: fetch from GitHub
-	content, err = c.fetchFromGitHub(promptFile)
+	res, err := c.fetchFromGitHub(c.ctx, promptFile)
 	if err != nil {
 		return "", err
 	}
 
+	c.store(promptFile, res)
+	return res.content, nil
+}
+
+// setEntry stores content in the memory cache.
+func (c *InstructionsCache) setEntry(promptFile, content, etag, version string) {
 	c.mu.Lock()
 	c.cache[promptFile] = &cacheEntry{
 		content:   content,
+		etag:      etag,
+		version:   version,
 		fetchedAt: time.Now(),
 	}
 	c.mu.Unlock()
+}
 
-	go func(pf, content string) {
-		if err := c.saveToDisk(pf, content); err != nil {
+// store updates the memory cache with a fetch result and persists it to disk (async).
+// Unmodified results only refresh the fetch time; the cached content is kept as-is.
+func (c *InstructionsCache) store(promptFile string, res *fetchResult) {
+	c.setEntry(promptFile, res.content, res.etag, res.version)
+
+	c.saves.Add(1)
+	go func() {
+		defer c.saves.Done()
+		var err error
+		if res.notModified {
+			err = c.saveMeta(promptFile, res.content, res.etag, res.version)
+		} else {
+			err = c.saveToDisk(promptFile, res.content, res.etag, res.version)
+		}
+		if err != nil {
 			slog.Warn("failed to save instruction to disk cache",
-				"file", pf,
+				"file", promptFile,
 				"error", err,
 			)
 		}
-	}(promptFile, content)
-	return content, nil
+	}()
 }
 
 // loadFromDiskWithExpired loads from disk cache, returning content even if expired.
-// Returns content and logs a warning if cache is expired.
-func (c *InstructionsCache) loadFromDiskWithExpired(promptFile string) (string, error) {
+// Returns content with its metadata and logs a warning if cache is expired.
+func (c *InstructionsCache) loadFromDiskWithExpired(promptFile string) (string, *cacheMeta, error) {
 	cacheDir := CacheDir()
 	contentPath := filepath.Join(cacheDir, promptFile)
 	metaPath := filepath.Join(cacheDir, promptFile+".meta.json")
@@ -315,18 +515,23 @@ func (c *InstructionsCache) loadFromDiskWithExpired(promptFile string) (string,
 	// Check metadata
 	metaData, err := os.ReadFile(metaPath)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	var meta cacheMeta
 	if err := json.Unmarshal(metaData, &meta); err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	// Read content
 	content, err := os.ReadFile(contentPath)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+
+	// A mismatch means a partial write or corrupted download; treat as missing so it is re-fetched
+	if meta.SHA256 != "" && meta.SHA256 != contentChecksum(string(content)) {
+		return "", nil, fmt.Errorf("disk cache for %s failed checksum verification", promptFile)
 	}
 
 	// Check if cache is expired (7 days for disk cache)
@@ -338,27 +543,38 @@ func (c *InstructionsCache) loadFromDiskWithExpired(promptFile string) (string,
 		)
 	}
 
-	return string(content), nil
+	return string(content), &meta, nil
 }
 
-func (c *InstructionsCache) saveToDisk(promptFile, content string) error {
+func (c *InstructionsCache) saveToDisk(promptFile, content, etag, version string) error {
 	if err := EnsureCacheDir(); err != nil {
 		return err
 	}
 
-	cacheDir := CacheDir()
-	contentPath := filepath.Join(cacheDir, promptFile)
-	metaPath := filepath.Join(cacheDir, promptFile+".meta.json")
+	contentPath := filepath.Join(CacheDir(), promptFile)
 
 	// Write content
 	if err := os.WriteFile(contentPath, []byte(content), 0644); err != nil {
 		return err
 	}
 
-	// Write metadata
+	return c.saveMeta(promptFile, content, etag, version)
+}
+
+// saveMeta writes the disk cache metadata for a prompt file, stamped with the
+// current time and the checksum of content.
+func (c *InstructionsCache) saveMeta(promptFile, content, etag, version string) error {
+	if err := EnsureCacheDir(); err != nil {
+		return err
+	}
+
+	metaPath := filepath.Join(CacheDir(), promptFile+".meta.json")
+
 	meta := cacheMeta{
-		Version:   c.version,
+		Version:   version,
 		FetchedAt: time.Now(),
+		ETag:      etag,
+		SHA256:    contentChecksum(content),
 	}
 	metaData, err := json.Marshal(meta)
 	if err != nil {
@@ -368,47 +584,115 @@ func (c *InstructionsCache) saveToDisk(promptFile, content string) error {
 	return os.WriteFile(metaPath, metaData, 0644)
 }
 
-func (c *InstructionsCache) fetchFromGitHub(promptFile string) (string, error) {
+// contentChecksum returns the hex SHA-256 of instruction content.
+func contentChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// CachedVersion reports the instructions release recorded in the disk cache.
+// The disk cache is shared by the server and CLI commands, so it is the source
+// of truth for which release is in use. It returns the version and fetch time
+// of the most recently fetched prompt file; ok is false if nothing is cached.
+func CachedVersion() (version string, fetchedAt time.Time, ok bool) {
+	for _, promptFile := range GetAllPromptFiles() {
+		data, err := os.ReadFile(filepath.Join(CacheDir(), promptFile+".meta.json"))
+		if err != nil {
+			continue
+		}
+		var meta cacheMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Version == "" {
+			continue
+		}
+		if !ok || meta.FetchedAt.After(fetchedAt) {
+			version, fetchedAt, ok = meta.Version, meta.FetchedAt, true
+		}
+	}
+	return version, fetchedAt, ok
+}
+
+// cachedCopy returns the cached content and ETag for a prompt file,
+// checking memory first and then the disk cache.
+func (c *InstructionsCache) cachedCopy(promptFile string) (content, etag string) {
+	c.mu.RLock()
+	entry, ok := c.cache[promptFile]
+	c.mu.RUnlock()
+	if ok {
+		return entry.content, entry.etag
+	}
+
+	content, meta, err := c.loadFromDiskWithExpired(promptFile)
+	if err != nil {
+		return "", ""
+	}
+	return content, meta.ETag
+}
+
+// fetchFromGitHub fetches a prompt file from GitHub.
+// When a cached copy has an ETag, the request is conditional and a 304
+// response returns the cached content without downloading it again.
+func (c *InstructionsCache) fetchFromGitHub(ctx context.Context, promptFile string) (*fetchResult, error) {
 	// First, get the latest release tag
-	tag, err := c.getLatestReleaseTag()
+	tag, err := c.getLatestReleaseTag(ctx)
 	if err != nil {
 		// Fallback to main branch if release fetch fails
 		tag = "main"
 	}
 
-	c.version = tag
-
 	// Construct raw GitHub URL
 	// Prompts are located at codex-rs/core/{promptFile}
 	url := fmt.Sprintf("%s/%s/codex-rs/core/%s",
 		GitHubRawBaseURL, tag, promptFile)
 
-	resp, err := http.Get(url)
+	cached, etag := c.cachedCopy(promptFile)
+
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if cached != "" && etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		return req, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch instructions: %w", err)
+		return nil, fmt.Errorf("failed to fetch instructions: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotModified && cached != "" {
+		slog.Debug("instruction file not modified", "file", promptFile)
+		return &fetchResult{content: cached, etag: etag, version: tag, notModified: true}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to fetch instructions: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch instructions: status %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read instructions: %w", err)
+		return nil, fmt.Errorf("failed to read instructions: %w", err)
 	}
 
-	return string(body), nil
+	return &fetchResult{content: string(body), etag: resp.Header.Get("ETag"), version: tag}, nil
 }
 
-func (c *InstructionsCache) getLatestReleaseTag() (string, error) {
-	req, err := http.NewRequest("GET", GitHubReleasesAPI, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Accept", "application/vnd.github+json")
+// LatestReleaseTag returns the latest Codex release tag from GitHub.
+// Used to check that instructions can be fetched.
+func (c *InstructionsCache) LatestReleaseTag(ctx context.Context) (string, error) {
+	return c.getLatestReleaseTag(ctx)
+}
 
-	resp, err := http.DefaultClient.Do(req)
+func (c *InstructionsCache) getLatestReleaseTag(ctx context.Context) (string, error) {
+	resp, err := c.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", GitHubReleasesAPI, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		return req, nil
+	})
 	if err != nil {
 		return "", err
 	}
@@ -432,3 +716,51 @@ func (c *InstructionsCache) getLatestReleaseTag() (string, error) {
 
 	return release.TagName, nil
 }
+
+// doWithRetry sends a GitHub request (authenticated when a token is set), retrying with exponential backoff on
+// network errors, 429 and 5xx responses. newReq is called for every attempt.
+// The backoff wait ends early with ctx.Err() when ctx is cancelled.
+func (c *InstructionsCache) doWithRetry(ctx context.Context, newReq func() (*http.Request, error)) (*http.Response, error) {
+	backoff := instructionsRetryBackoff
+
+	c.mu.RLock()
+	token := c.githubToken
+	c.mu.RUnlock()
+
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		retryable := err != nil ||
+			resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode >= http.StatusInternalServerError
+		if !retryable || attempt >= instructionsFetchRetries {
+			return resp, err
+		}
+
+		if err == nil {
+			_ = resp.Body.Close()
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+		slog.Debug("retrying github request",
+			"url", req.URL.String(),
+			"attempt", attempt+1,
+			"error", err,
+		)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
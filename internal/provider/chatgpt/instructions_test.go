@@ -0,0 +1,170 @@
+package chatgpt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMockGitHub serves the latest release tag and the content of every prompt
+// file except those listed in failing, which return 404.
+func newMockGitHub(t *testing.T, failing ...string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/openai/codex/releases/latest" {
+			_, _ = w.Write([]byte(`{"tag_name":"rust-v1.0.0"}`))
+			return
+		}
+		name := path.Base(r.URL.Path)
+		if !strings.HasPrefix(r.URL.Path, "/openai/codex/rust-v1.0.0/codex-rs/core/") {
+			http.NotFound(w, r)
+			return
+		}
+		fetches.Add(1)
+		for _, f := range failing {
+			if f == name {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		_, _ = w.Write([]byte("instructions for " + name))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fetches
+}
+
+// newTestInstructionsCache returns a cache whose GitHub requests go to srv and
+// whose disk cache lives in a temporary directory.
+func newTestInstructionsCache(t *testing.T, srv *httptest.Server) *InstructionsCache {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	target, _ := url.Parse(srv.URL)
+	c := NewInstructionsCache()
+	c.httpClient = &http.Client{Transport: redirectTransport{target: target}}
+	// Finish disk writes before XDG_CACHE_HOME is restored for the next test
+	t.Cleanup(c.saves.Wait)
+	return c
+}
+
+func TestPrefetchLoadsAllFiles(t *testing.T) {
+	srv, fetches := newMockGitHub(t)
+	c := newTestInstructionsCache(t, srv)
+
+	if err := c.Prefetch(); err != nil {
+		t.Fatalf("Prefetch() error = %v", err)
+	}
+
+	files := GetAllPromptFiles()
+	if got := int(fetches.Load()); got != len(files) {
+		t.Errorf("fetched %d files, want %d", got, len(files))
+	}
+	for _, f := range files {
+		if !c.Has(f) {
+			t.Errorf("%s not loaded", f)
+		}
+	}
+	if !c.Loaded() {
+		t.Error("Loaded() = false after a complete prefetch")
+	}
+	if got := c.Version(); got != "rust-v1.0.0" {
+		t.Errorf("Version() = %q, want rust-v1.0.0", got)
+	}
+
+	content, err := c.Get("gpt-5.2")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := "instructions for " + GetPromptFile("gpt-5.2"); content != want {
+		t.Errorf("Get() = %q, want %q", content, want)
+	}
+}
+
+func TestPrefetchAggregatesFailures(t *testing.T) {
+	failing := GetPromptFile("gpt-5.2-codex")
+	srv, _ := newMockGitHub(t, failing)
+	c := newTestInstructionsCache(t, srv)
+
+	err := c.Prefetch()
+	if err == nil {
+		t.Fatal("Prefetch() succeeded with a missing file")
+	}
+	if !strings.Contains(err.Error(), failing) {
+		t.Errorf("error %q does not name %s", err, failing)
+	}
+
+	for _, f := range GetAllPromptFiles() {
+		if got, want := c.Has(f), f != failing; got != want {
+			t.Errorf("Has(%s) = %v, want %v", f, got, want)
+		}
+	}
+}
+
+func TestDiskCacheIgnoredWhenCorrupted(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	promptFile := GetPromptFile("gpt-5.2")
+
+	c := NewInstructionsCache()
+	if err := c.saveToDisk(promptFile, "original instructions", `"etag"`, "rust-v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _, err := c.loadFromDiskWithExpired(promptFile); err != nil || content != "original instructions" {
+		t.Fatalf("loadFromDiskWithExpired() = (%q, %v) before corruption", content, err)
+	}
+
+	// Truncate the content file as a partial write would
+	if err := os.WriteFile(filepath.Join(CacheDir(), promptFile), []byte("original instr"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := c.loadFromDiskWithExpired(promptFile); err == nil {
+		t.Fatal("loadFromDiskWithExpired() accepted a corrupted cache file")
+	}
+
+	// Offline prefetch has nothing else to fall back on, so the file stays unloaded
+	offline := NewInstructionsCache()
+	offline.SetOffline(true)
+	if err := offline.Prefetch(); err == nil || !strings.Contains(err.Error(), promptFile) {
+		t.Errorf("offline Prefetch() error = %v, want failure for %s", err, promptFile)
+	}
+	if offline.Has(promptFile) {
+		t.Errorf("%s loaded from a corrupted disk cache", promptFile)
+	}
+
+	// Nor is the corrupted copy offered for a conditional request
+	if content, etag := offline.cachedCopy(promptFile); content != "" || etag != "" {
+		t.Errorf("cachedCopy() = (%q, %q), want empty", content, etag)
+	}
+}
+
+func TestCloseInterruptsRetryBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	c := newTestInstructionsCache(t, srv)
+
+	time.AfterFunc(50*time.Millisecond, c.Close)
+	start := time.Now()
+	_, err := c.LatestReleaseTag(c.ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("LatestReleaseTag() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed >= instructionsRetryBackoff {
+		t.Errorf("LatestReleaseTag() returned after %v, want before the first backoff ends", elapsed)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
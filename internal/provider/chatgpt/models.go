@@ -1,5 +1,7 @@
 package chatgpt
 
+import "strings"
+
 // ModelConfig contains configuration for a specific model.
 type ModelConfig struct {
 	PromptFile    string
@@ -7,6 +9,8 @@ type ModelConfig struct {
 	SupportsXHigh bool // Supports "xhigh" reasoning effort?
 	DefaultEffort string
 	MinEffort     string // Minimum allowed effort
+	ContextWindow int    // Total tokens (input plus output)
+	MaxOutput     int    // Maximum output tokens, including reasoning
 }
 
 // modelConfigs maps model IDs to their configurations.
@@ -17,6 +21,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: true,
 		DefaultEffort: "medium",
 		MinEffort:     "low",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5.1-codex-max": {
 		PromptFile:    "gpt-5.1-codex-max_prompt.md",
@@ -24,6 +30,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: true,
 		DefaultEffort: "high",
 		MinEffort:     "low",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5.1-codex": {
 		PromptFile:    "gpt_5_codex_prompt.md",
@@ -31,6 +39,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: false,
 		DefaultEffort: "medium",
 		MinEffort:     "low",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5-codex": {
 		PromptFile:    "gpt_5_codex_prompt.md",
@@ -38,6 +48,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: false,
 		DefaultEffort: "medium",
 		MinEffort:     "low",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5.1-codex-mini": {
 		PromptFile:    "gpt_5_codex_prompt.md",
@@ -45,6 +57,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: false,
 		DefaultEffort: "medium",
 		MinEffort:     "medium", // Only medium or high
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5.2": {
 		PromptFile:    "gpt_5_2_prompt.md",
@@ -52,6 +66,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: true,
 		DefaultEffort: "medium",
 		MinEffort:     "none",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5.1": {
 		PromptFile:    "gpt_5_1_prompt.md",
@@ -59,6 +75,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: false,
 		DefaultEffort: "medium",
 		MinEffort:     "none",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 	"gpt-5": {
 		PromptFile:    "gpt_5_1_prompt.md",
@@ -66,6 +84,8 @@ var modelConfigs = map[string]ModelConfig{
 		SupportsXHigh: false,
 		DefaultEffort: "medium",
 		MinEffort:     "none",
+		ContextWindow: 400000,
+		MaxOutput:     128000,
 	},
 }
 
@@ -78,6 +98,23 @@ func GetPromptFile(modelID string) string {
 	return "gpt_5_codex_prompt.md"
 }
 
+// GetContextWindow returns the context window for a model, or 0 if unknown.
+func GetContextWindow(modelID string) int {
+	return modelConfigs[modelID].ContextWindow
+}
+
+// effortLevels lists reasoning effort levels in ascending order.
+var effortLevels = []string{"none", "low", "medium", "high", "xhigh"}
+
+// effortIndex maps each effort level to its position in effortLevels.
+var effortIndex = map[string]int{
+	"none":   0,
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+	"xhigh":  4,
+}
+
 // NormalizeReasoningEffort adjusts the reasoning effort based on model capabilities.
 func NormalizeReasoningEffort(modelID, effort string) string {
 	cfg, ok := modelConfigs[modelID]
@@ -85,16 +122,6 @@ func NormalizeReasoningEffort(modelID, effort string) string {
 		return effort
 	}
 
-	// Effort levels in order
-	effortLevels := []string{"none", "low", "medium", "high", "xhigh"}
-	effortIndex := map[string]int{
-		"none":   0,
-		"low":    1,
-		"medium": 2,
-		"high":   3,
-		"xhigh":  4,
-	}
-
 	minIdx, minOk := effortIndex[cfg.MinEffort]
 	reqIdx, reqOk := effortIndex[effort]
 
@@ -125,6 +152,48 @@ func NormalizeReasoningEffort(modelID, effort string) string {
 	return effort
 }
 
+// AllowedEfforts returns the reasoning effort levels a model accepts without clamping.
+func AllowedEfforts(modelID string) []string {
+	cfg, ok := modelConfigs[modelID]
+	if !ok {
+		return effortLevels
+	}
+	var allowed []string
+	for i, level := range effortLevels {
+		if minIdx, ok := effortIndex[cfg.MinEffort]; ok && i < minIdx {
+			continue
+		}
+		if (level == "none" && !cfg.SupportsNone) || (level == "xhigh" && !cfg.SupportsXHigh) {
+			continue
+		}
+		allowed = append(allowed, level)
+	}
+	return allowed
+}
+
+// ApplyEffortFloor raises effort to at least floor, without exceeding what the model supports.
+// It is applied after NormalizeReasoningEffort so per-model clamping still holds.
+// An empty or unknown floor leaves the effort unchanged.
+func ApplyEffortFloor(modelID, effort, floor string) string {
+	floorIdx, ok := effortIndex[floor]
+	if !ok {
+		return effort
+	}
+	reqIdx, ok := effortIndex[effort]
+	if !ok || reqIdx >= floorIdx {
+		return effort
+	}
+
+	effort = effortLevels[floorIdx]
+
+	// Clamp down to the model's maximum supported effort
+	if cfg, ok := modelConfigs[modelID]; ok && effort == "xhigh" && !cfg.SupportsXHigh {
+		effort = "high"
+	}
+
+	return effort
+}
+
 // modelAliases maps user-friendly model names to API model names.
 var modelAliases = map[string]string{
 	// Codex models
@@ -159,18 +228,31 @@ var effortSuffixes = map[string]bool{
 
 // ParseModelWithEffort parses a model name that may include an effort suffix.
 // For example, "gpt-5-high" returns ("gpt-5", "high").
+// A suffix is only stripped when the remaining base is a known model or alias,
+// so a model whose name happens to end in "-high" is never split.
 // If no effort suffix is found, returns the original model and empty string.
 func ParseModelWithEffort(model string) (baseModel string, effort string) {
-	// Try to find effort suffix
+	if isKnownModel(model) {
+		return model, ""
+	}
 	for suffix := range effortSuffixes {
-		suffixWithDash := "-" + suffix
-		if len(model) > len(suffixWithDash) && model[len(model)-len(suffixWithDash):] == suffixWithDash {
-			return model[:len(model)-len(suffixWithDash)], suffix
+		base, ok := strings.CutSuffix(model, "-"+suffix)
+		if ok && base != "" && isKnownModel(base) {
+			return base, suffix
 		}
 	}
 	return model, ""
 }
 
+// isKnownModel reports whether name is a configured model ID or alias.
+func isKnownModel(name string) bool {
+	if _, ok := modelConfigs[name]; ok {
+		return true
+	}
+	_, ok := modelAliases[name]
+	return ok
+}
+
 // NormalizeModelNameWithEffort normalizes a model name and extracts any effort suffix.
 // Returns the canonical model name and the extracted effort (empty if none).
 func NormalizeModelNameWithEffort(model string) (normalizedModel string, effort string) {
@@ -0,0 +1,75 @@
+package chatgpt
+
+import "testing"
+
+func TestNormalizeModelNameWithEffort(t *testing.T) {
+	tests := []struct {
+		model      string
+		wantModel  string
+		wantEffort string
+	}{
+		{"codex-max", "gpt-5.1-codex-max", ""},
+		{"codex-max-high", "gpt-5.1-codex-max", "high"},
+		{"gpt-5.1-codex-max", "gpt-5.1-codex-max", ""},
+		{"gpt-5.1-codex-max-xhigh", "gpt-5.1-codex-max", "xhigh"},
+		{"gpt-5.1-codex-mini", "gpt-5.1-codex-mini", ""},
+		{"gpt-5-high", "gpt-5.1", "high"},
+		{"gpt-5.2-none", "gpt-5.2", "none"},
+		{"chatgpt/gpt-5.2-codex-low", "gpt-5.2-codex", "low"},
+		{"codex-latest", "gpt-5.2-codex", ""},
+		{"something-high", "something-high", ""},
+		{"custom-model-medium", "custom-model-medium", ""},
+		{"-high", "-high", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			gotModel, gotEffort := NormalizeModelNameWithEffort(tt.model)
+			if gotModel != tt.wantModel || gotEffort != tt.wantEffort {
+				t.Errorf("NormalizeModelNameWithEffort(%q) = (%q, %q), want (%q, %q)",
+					tt.model, gotModel, gotEffort, tt.wantModel, tt.wantEffort)
+			}
+		})
+	}
+}
+
+func TestParseModelWithEffortKeepsUnknownNames(t *testing.T) {
+	for _, model := range []string{"something-high", "my-finetune-low", "codex-maximum"} {
+		base, effort := ParseModelWithEffort(model)
+		if base != model || effort != "" {
+			t.Errorf("ParseModelWithEffort(%q) = (%q, %q), want (%q, \"\")", model, base, effort, model)
+		}
+	}
+}
+
+func TestApplyEffortFloorWithModelClamps(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		effort string
+		floor  string
+		want   string
+	}{
+		{"no floor", "gpt-5.2", "none", "", "none"},
+		{"unknown floor ignored", "gpt-5.2", "low", "extreme", "low"},
+		{"raises below floor", "gpt-5.2", "none", "medium", "medium"},
+		{"keeps effort above floor", "gpt-5.2", "high", "low", "high"},
+		{"xhigh floor on xhigh model", "gpt-5.2", "low", "xhigh", "xhigh"},
+		{"xhigh floor clamped to model max", "gpt-5.1", "low", "xhigh", "high"},
+		{"xhigh floor clamped on mini", "gpt-5.1-codex-mini", "medium", "xhigh", "high"},
+		{"floor below model minimum keeps model minimum", "gpt-5.1-codex-mini", "low", "low", "medium"},
+		{"none floor on model without none", "gpt-5.1-codex", "none", "none", "low"},
+		{"unknown model gets floor", "custom", "low", "high", "high"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Mirrors buildRequest: per-model clamping first, then the global floor
+			got := ApplyEffortFloor(tt.model, NormalizeReasoningEffort(tt.model, tt.effort), tt.floor)
+			if got != tt.want {
+				t.Errorf("effort for %s (requested %q, floor %q) = %q, want %q",
+					tt.model, tt.effort, tt.floor, got, tt.want)
+			}
+		})
+	}
+}
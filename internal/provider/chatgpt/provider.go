@@ -4,14 +4,19 @@ package chatgpt
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/logging"
 	"github.com/edgard/opencompat/internal/provider"
 	"github.com/edgard/opencompat/internal/sse"
+	"github.com/edgard/opencompat/internal/tokens"
 )
 
 const ProviderID = "chatgpt"
@@ -25,6 +30,9 @@ func init() {
 			OAuthCfg:   GetOAuthConfig(),
 			EnvVars:    convertEnvVarDocs(EnvVarDocs()),
 			Factory:    New,
+			ValidateConfig: func() error {
+				return LoadConfig().Validate()
+			},
 		})
 	})
 }
@@ -51,6 +59,9 @@ type Provider struct {
 // New creates a new ChatGPT provider.
 func New(store *auth.Store) (provider.Provider, error) {
 	cfg := LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return &Provider{
 		client: NewClient(store, cfg),
 		cfg:    cfg,
@@ -77,6 +88,15 @@ func (p *Provider) Models() []api.Model {
 	}
 }
 
+// ModelAliases returns the accepted model aliases.
+func (p *Provider) ModelAliases() []string {
+	aliases := make([]string, 0, len(modelAliases))
+	for alias := range modelAliases {
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
 // SupportsModel checks if a model ID is supported, including effort suffixes.
 func (p *Provider) SupportsModel(modelID string) bool {
 	// Normalize model name (handles aliases and effort suffixes)
@@ -91,13 +111,48 @@ func (p *Provider) SupportsModel(modelID string) bool {
 	return false
 }
 
-// ChatCompletion sends a chat completion request.
-func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
+// NormalizeModel returns the canonical model for an alias or effort-suffixed name.
+func (p *Provider) NormalizeModel(modelID string) string {
+	normalizedModel, _ := NormalizeModelNameWithEffort(modelID)
+	return normalizedModel
+}
+
+// ValidateEffort returns an error if the effective reasoning effort (an effort
+// suffix on modelID takes precedence over effort) would be clamped for the model.
+func (p *Provider) ValidateEffort(modelID, effort string) error {
+	normalizedModel, suffixEffort := NormalizeModelNameWithEffort(modelID)
+	if suffixEffort != "" {
+		effort = suffixEffort
+	}
+	if effort == "" {
+		return nil
+	}
+	allowed := AllowedEfforts(normalizedModel)
+	if !slices.Contains(allowed, effort) {
+		return fmt.Errorf("reasoning effort '%s' is not supported by %s. Must be one of: %s",
+			effort, normalizedModel, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// ContextWindow returns the context window for a model, including aliases and effort suffixes.
+func (p *Provider) ContextWindow(modelID string) int {
+	normalizedModel, _ := NormalizeModelNameWithEffort(modelID)
+	return GetContextWindow(normalizedModel)
+}
+
+// buildRequest resolves instructions and per-request overrides and transforms
+// req into a Responses API request. It returns the effective config alongside.
+func (p *Provider) buildRequest(req *provider.ChatCompletionRequest) (*ResponsesRequest, *Config, error) {
 	// Get instructions for the model
 	normalizedModel, _ := NormalizeModelNameWithEffort(req.Model)
 	instructions, err := p.client.GetInstructions(normalizedModel)
 	if err != nil {
-		return nil, err
+		return nil, nil, &api.UpstreamError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("Instructions for model %s are unavailable: %v", normalizedModel, err),
+			Code:       "instructions_unavailable",
+		}
 	}
 
 	// Convert provider request to API request
@@ -125,29 +180,117 @@ func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatComplet
 
 	// Transform to ChatGPT Responses API request
 	chatgptReq, err := TransformRequest(apiReq, instructions, &effectiveCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Key the prompt cache (and session/conversation headers) by the client's conversation
+	if req.ConversationID != "" {
+		chatgptReq.PromptCacheKey = conversationCacheKey(req.ConversationID, chatgptReq.Model)
+	}
+	return chatgptReq, &effectiveCfg, nil
+}
+
+// DescribeInstructions returns the instructions for a model, including aliases and effort suffixes.
+func (p *Provider) DescribeInstructions(modelID string) (*provider.InstructionsInfo, error) {
+	normalizedModel, _ := NormalizeModelNameWithEffort(modelID)
+	return p.client.DescribeInstructions(normalizedModel)
+}
+
+// PreviewRequest returns the Responses API request that ChatCompletion would send.
+func (p *Provider) PreviewRequest(req *provider.ChatCompletionRequest) (any, error) {
+	chatgptReq, _, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return chatgptReq, nil
+}
+
+// ChatCompletion sends a chat completion request.
+func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
+	chatgptReq, effectiveCfg, err := p.buildRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
+	requestID := logging.RequestID(ctx)
+	if logging.DebugBodies() {
+		if body, err := json.Marshal(chatgptReq); err == nil {
+			slog.Debug("upstream request body",
+				"request_id", requestID,
+				"provider", ProviderID,
+				"body", logging.Redact(string(body)),
+			)
+		}
+	}
+
 	// Send request
 	resp, err := p.client.SendRequest(ctx, chatgptReq)
 	if err != nil {
 		return nil, err
 	}
 
+	// Estimate prompt size from the transformed input in case upstream omits usage
+	state := NewStreamState()
+	state.PromptTokens = tokens.Estimate(chatgptReq.Model, chatgptReq.Instructions) +
+		tokens.EstimateMessages(chatgptReq.Model, req.Messages, req.Tools)
+	state.SystemFingerprint = systemFingerprint(chatgptReq)
+	state.ThinkOpen = effectiveCfg.ThinkOpen
+	state.ThinkClose = effectiveCfg.ThinkClose
+	if effectiveCfg.EnforceStop {
+		state.StopSequences = parseStopSequences(req.Stop)
+	}
+	state.ExposeWebSearch = effectiveCfg.ExposeWebSearch
+	state.InterimUsage = effectiveCfg.InterimUsage && req.Stream &&
+		req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+
 	return &Stream{
 		resp:            resp,
 		reader:          sse.NewReader(resp.Body),
-		state:           NewStreamState(),
+		state:           state,
 		reasoningCompat: effectiveCfg.ReasoningCompat,
 		stream:          req.Stream,
 		includeUsage:    req.StreamOptions != nil && req.StreamOptions.IncludeUsage,
+		requestID:       requestID,
 	}, nil
 }
 
 // Init performs initialization (e.g., prefetching instructions).
+// With OPENCOMPAT_REQUIRE_ALL_INSTRUCTIONS=false, a partial prefetch failure is
+// logged and tolerated as long as some models have instructions.
 func (p *Provider) Init() error {
-	return p.client.PrefetchInstructions()
+	err := p.client.PrefetchInstructions()
+	p.checkModelConsistency()
+	if err != nil && !p.cfg.RequireAllInstructions {
+		if degraded := p.degradedModels(); len(degraded) < len(p.Models()) {
+			slog.Warn("starting without instructions for some models; their requests fail until the instructions are fetched",
+				"models", degraded,
+				"error", err,
+			)
+			return nil
+		}
+	}
+	return err
+}
+
+// Ready returns true once all instruction files are loaded, or with
+// OPENCOMPAT_REQUIRE_ALL_INSTRUCTIONS=false once any model can be served.
+func (p *Provider) Ready() bool {
+	if p.client.InstructionsLoaded() {
+		return true
+	}
+	return !p.cfg.RequireAllInstructions && len(p.degradedModels()) < len(p.Models())
+}
+
+// degradedModels returns the listed models whose prompt file is not loaded.
+func (p *Provider) degradedModels() []string {
+	var degraded []string
+	for _, m := range p.Models() {
+		if !p.client.HasInstructions(GetPromptFile(m.ID)) {
+			degraded = append(degraded, m.ID)
+		}
+	}
+	return degraded
 }
 
 // Start begins background tasks.
@@ -160,10 +303,50 @@ func (p *Provider) Close() {
 	p.client.Close()
 }
 
+// Health refreshes and validates the access token.
+func (p *Provider) Health(ctx context.Context) error {
+	return p.client.CheckAuth()
+}
+
+// InstructionsVersion returns the Codex release the served instructions came from.
+func (p *Provider) InstructionsVersion() string {
+	return p.client.InstructionsVersion()
+}
+
 // RefreshModels forces a refresh of instruction files.
 // For ChatGPT, this refreshes instructions rather than models (which are static).
 func (p *Provider) RefreshModels(ctx context.Context) error {
-	return p.client.RefreshInstructions(ctx)
+	err := p.client.RefreshInstructions(ctx)
+	p.checkModelConsistency()
+	return err
+}
+
+// checkModelConsistency warns when the static model list has drifted from
+// modelConfigs or the instructions cache. A listed model without a config
+// silently falls back to the default prompt file, which is rarely what its
+// release expects.
+func (p *Provider) checkModelConsistency() {
+	listed := make(map[string]bool)
+	for _, m := range p.Models() {
+		listed[m.ID] = true
+		cfg, ok := modelConfigs[m.ID]
+		if !ok {
+			slog.Warn("chatgpt model has no model config; using the default prompt file", "model", m.ID, "prompt_file", GetPromptFile(m.ID))
+			continue
+		}
+		if cfg.PromptFile == "" {
+			slog.Warn("chatgpt model config has no prompt file", "model", m.ID)
+			continue
+		}
+		if !p.client.HasInstructions(cfg.PromptFile) {
+			slog.Warn("chatgpt model prompt file is not loaded", "model", m.ID, "prompt_file", cfg.PromptFile)
+		}
+	}
+	for id := range modelConfigs {
+		if !listed[id] {
+			slog.Warn("chatgpt model config is not in the model list", "model", id)
+		}
+	}
 }
 
 // Stream implements the provider.Stream interface for ChatGPT responses.
@@ -179,6 +362,8 @@ type Stream struct {
 	err             error
 	sentUsage       bool
 	pendingChunks   []*api.ChatCompletionChunk // Buffer for multiple chunks from single event
+	requestID       string                     // For debug body logging
+	debugBytes      int                        // Event bytes logged so far (bounded by logging.MaxDebugStreamBytes)
 }
 
 // Next returns the next chunk.
@@ -210,6 +395,7 @@ func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
 		if err != nil {
 			if err == io.EOF {
 				s.done = true
+				s.state.EnsureUsage()
 				// Build final response for non-streaming
 				s.response = s.state.BuildNonStreamingResponse()
 
@@ -227,6 +413,10 @@ func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
 			return nil, err
 		}
 
+		if logging.DebugBodies() {
+			s.logEvent(event)
+		}
+
 		chunks, err := s.state.ProcessEvent(event)
 		if err != nil {
 			s.err = err
@@ -244,6 +434,23 @@ func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
 	}
 }
 
+// logEvent logs an upstream SSE event until the per-stream debug budget is spent.
+func (s *Stream) logEvent(event *sse.Event) {
+	if s.debugBytes >= logging.MaxDebugStreamBytes {
+		return
+	}
+	s.debugBytes += len(event.Data)
+	slog.Debug("upstream event",
+		"request_id", s.requestID,
+		"provider", ProviderID,
+		"event", event.Event,
+		"data", logging.Redact(string(event.Data)),
+	)
+	if s.debugBytes >= logging.MaxDebugStreamBytes {
+		slog.Debug("upstream event logging truncated", "request_id", s.requestID, "limit_bytes", logging.MaxDebugStreamBytes)
+	}
+}
+
 // Response returns the accumulated non-streaming response.
 func (s *Stream) Response() *api.ChatCompletionResponse {
 	return s.response
@@ -255,8 +462,13 @@ func (s *Stream) Err() error {
 	if s.err != nil {
 		return s.err
 	}
-	if errMsg := s.state.GetError(); errMsg != "" {
-		return errors.New("upstream error: " + errMsg)
+	if upstreamErr := s.state.GetError(); upstreamErr != nil {
+		return &api.UpstreamError{
+			StatusCode: http.StatusBadGateway,
+			Message:    upstreamErr.Message,
+			Type:       upstreamErr.Type,
+			Code:       upstreamErr.Code,
+		}
 	}
 	return nil
 }
@@ -291,9 +503,5 @@ func parseUpstreamError(body []byte) string {
 		}
 	}
 
-	bodyStr := string(body)
-	if len(bodyStr) > 500 {
-		bodyStr = bodyStr[:500] + "..."
-	}
-	return bodyStr
+	return logging.Truncate(string(body), 500)
 }
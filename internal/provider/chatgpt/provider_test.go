@@ -0,0 +1,72 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/sse"
+)
+
+// newTestStream returns a Stream reading the given SSE body from a 200 response.
+func newTestStream(body io.Reader, stream bool) *Stream {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       io.NopCloser(body),
+	}
+	return &Stream{
+		resp:            resp,
+		reader:          sse.NewReader(resp.Body),
+		state:           NewStreamState(),
+		reasoningCompat: "none",
+		stream:          stream,
+	}
+}
+
+func TestStreamResponseFailedCodeReachesClient(t *testing.T) {
+	body := "event: response.created\n" +
+		`data: {"type":"response.created","response":{"id":"resp_1","created_at":1}}` + "\n\n" +
+		"event: response.failed\n" +
+		`data: {"type":"response.failed","response":{"id":"resp_1","error":{"type":"invalid_request_error","code":"context_length_exceeded","message":"input is too long"}}}` + "\n\n"
+
+	s := newTestStream(strings.NewReader(body), false)
+	for {
+		if _, err := s.Next(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Next() error = %v", err)
+			}
+			break
+		}
+	}
+
+	var upstreamErr *api.UpstreamError
+	if !errors.As(s.Err(), &upstreamErr) {
+		t.Fatalf("Err() = %v, want *api.UpstreamError", s.Err())
+	}
+
+	rec := httptest.NewRecorder()
+	api.WriteUpstreamError(rec, upstreamErr)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	var resp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if resp.Error.Code == nil || *resp.Error.Code != "context_length_exceeded" {
+		t.Errorf("error code = %v, want context_length_exceeded", resp.Error.Code)
+	}
+	if resp.Error.Type != "invalid_request_error" {
+		t.Errorf("error type = %q, want invalid_request_error", resp.Error.Type)
+	}
+	if resp.Error.Message != "input is too long" {
+		t.Errorf("error message = %q, want %q", resp.Error.Message, "input is too long")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/sse"
+	"github.com/edgard/opencompat/internal/tokens"
 )
 
 // intPtr returns a pointer to an int value.
@@ -33,8 +34,11 @@ func TransformRequest(req *api.ChatCompletionRequest, instructions string, cfg *
 		return nil, err
 	}
 
-	// Strip IDs from input items for stateless operation
-	input = stripInputIDs(input)
+	// Strip IDs from input items for stateless operation; stateful mode keeps
+	// them so the backend can resolve item references from stored state
+	if !cfg.Stateful {
+		input = stripInputIDs(input)
+	}
 
 	// Transform tools
 	var tools []ToolDef
@@ -51,6 +55,13 @@ func TransformRequest(req *api.ChatCompletionRequest, instructions string, cfg *
 		effort = modelEffort
 	}
 	effort = NormalizeReasoningEffort(model, effort)
+	effort = ApplyEffortFloor(model, effort, cfg.MinReasoningEffort)
+
+	// The user system prompt goes after the resolved (possibly overridden) instructions,
+	// before hashing so editing it invalidates the prompt cache
+	if extra := cfg.SystemPromptText(); extra != "" {
+		instructions = strings.TrimRight(instructions, "\n") + "\n\n" + extra
+	}
 
 	// Generate prompt cache key
 	cacheKey := generateCacheKey(instructions, model)
@@ -64,9 +75,9 @@ func TransformRequest(req *api.ChatCompletionRequest, instructions string, cfg *
 		Instructions:      instructions,
 		Input:             input,
 		Tools:             tools,
-		ToolChoice:        req.ToolChoice,
+		ToolChoice:        transformToolChoice(req.ToolChoice),
 		ParallelToolCalls: req.ParallelToolCalls,
-		Store:             false,
+		Store:             cfg.Stateful,
 		Stream:            true, // Always stream, we'll buffer for non-streaming
 		Reasoning: &ReasoningConfig{
 			Effort:  effort,
@@ -78,6 +89,9 @@ func TransformRequest(req *api.ChatCompletionRequest, instructions string, cfg *
 		Include:        []string{"reasoning.encrypted_content"},
 		PromptCacheKey: cacheKey,
 	}
+	if cfg.ExposeWebSearch {
+		respReq.Include = append(respReq.Include, "web_search_call.action.sources")
+	}
 
 	// Pass through supported sampling parameters
 	if req.Temperature != nil {
@@ -141,8 +155,8 @@ func logUnsupportedParams(req *api.ChatCompletionRequest) {
 }
 
 // stripInputIDs removes IDs from input items for stateless operation.
-// The ChatGPT backend requires store=false for stateless operation, which
-// means we can't reference previous items by ID.
+// With store=false (the default) nothing is kept server-side, so previous
+// items can't be referenced by ID. Skipped when OPENCOMPAT_STATEFUL is set.
 func stripInputIDs(input []InputItem) []InputItem {
 	result := make([]InputItem, 0, len(input))
 	for _, item := range input {
@@ -171,13 +185,13 @@ func stripInputIDs(input []InputItem) []InputItem {
 func transformMessages(messages []api.Message) ([]InputItem, error) {
 	var input []InputItem
 
-	// First pass: extract system messages and convert to user message
+	// First pass: extract system (and developer) messages and convert to user message
 	// The ChatGPT Responses API doesn't support system messages directly,
 	// so we convert them to a user message at the start of the conversation
 	var systemContent string
 	var nonSystemMessages []api.Message
 	for _, msg := range messages {
-		if msg.Role == "system" {
+		if msg.Role == "system" || msg.Role == "developer" {
 			content := msg.GetContentString()
 			if content != "" {
 				if systemContent != "" {
@@ -343,6 +357,26 @@ func transformTools(tools []api.Tool) []ToolDef {
 	return result
 }
 
+// transformToolChoice converts a Chat Completions named-function choice
+// ({"type":"function","function":{"name":...}}) to the flat Responses API
+// shape ({"type":"function","name":...}). Other values pass through unchanged.
+func transformToolChoice(choice json.RawMessage) json.RawMessage {
+	var named struct {
+		Type     string `json:"type"`
+		Function *struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(choice, &named); err != nil || named.Type != "function" || named.Function == nil {
+		return choice
+	}
+	flat, err := json.Marshal(map[string]string{"type": "function", "name": named.Function.Name})
+	if err != nil {
+		return choice
+	}
+	return flat
+}
+
 func generateCacheKey(instructions, model string) string {
 	h := sha256.New()
 	h.Write([]byte(instructions))
@@ -350,6 +384,39 @@ func generateCacheKey(instructions, model string) string {
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
 
+// conversationCacheKey derives the prompt cache key for a client-supplied
+// conversation id. Unlike generateCacheKey it ignores the instructions, so
+// turns of one conversation keep the same key across instruction refreshes,
+// while different conversations on the same model get different keys.
+func conversationCacheKey(conversationID, model string) string {
+	h := sha256.New()
+	h.Write([]byte(conversationID))
+	h.Write([]byte{0})
+	h.Write([]byte(model))
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// systemFingerprint derives a stable fingerprint from the backend configuration
+// of a request: model, instructions and reasoning/verbosity settings. Identical
+// configurations produce the same value, so clients can detect config changes.
+func systemFingerprint(req *ResponsesRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Instructions))
+	if req.Reasoning != nil {
+		h.Write([]byte{0})
+		h.Write([]byte(req.Reasoning.Effort))
+		h.Write([]byte{0})
+		h.Write([]byte(req.Reasoning.Summary))
+	}
+	if req.Text != nil {
+		h.Write([]byte{0})
+		h.Write([]byte(req.Text.Verbosity))
+	}
+	return "fp_" + hex.EncodeToString(h.Sum(nil))[:10]
+}
+
 // StreamState tracks state during SSE streaming.
 type StreamState struct {
 	ResponseID            string
@@ -359,21 +426,39 @@ type StreamState struct {
 	Refusal               string // Model refusal message
 	ReasoningSummary      string
 	ReasoningFull         string
-	ToolCalls             map[int]*api.ToolCall // indexed by output_index
-	NextToolIndex         int                   // Next available tool call index
+	ToolCalls             map[int]*api.ToolCall // indexed by client-facing tool call index
+	ToolIndexByOutput     map[int]int           // output_index -> client-facing tool call index
+	NextToolIndex         int                   // Next client-facing tool call index to allocate
+	ClientToolCalls       int                   // Tool calls the client must execute (excludes built-ins like web_search)
 	FinishReason          string
 	IncompleteReason      string // "max_output_tokens", "content_filter", etc.
 	Usage                 *api.Usage
-	ReasoningCompat       string // "none", "think-tags", "o3", "legacy"
+	PromptTokens          int    // Estimated prompt tokens, used when upstream reports no usage
+	SystemFingerprint     string // Stable hash of the request's backend configuration
+	ReasoningCompat       string // "none", "think-tags", "o3", "legacy", "reasoning-content"
+	ThinkOpen             string // think-tags opening delimiter
+	ThinkClose            string // think-tags closing delimiter
 	ThinkTagOpen          bool
 	ThinkTagClosed        bool
 	SawOutput             bool
 	SentStopChunk         bool
+	StopSequences         []string // enforced locally when set (OPENCOMPAT_ENFORCE_STOP)
+	StopHit               bool     // a stop sequence was found; later content is dropped
+	EmittedContent        int      // bytes of CurrentContent already streamed
+	ExposeWebSearch       bool     // report web search sources (OPENCOMPAT_EXPOSE_WEB_SEARCH)
+	InterimUsage          bool     // emit estimated usage at reasoning boundaries (OPENCOMPAT_INTERIM_USAGE)
+	WebSearchResults      []api.WebSearchResult
 	PendingSummaryNewline bool
-	ErrorMessage          string
+	Error                 *ErrorData // Upstream error from response.failed or error events
 	// Web search state tracking (like ChatMock's ws_state/ws_index)
 	WebSearchState map[string]*WebSearchAccum // call_id -> accumulated params
-	WebSearchIndex map[string]int             // call_id -> output_index
+	WebSearchIndex map[string]int             // call_id -> client-facing tool call index
+}
+
+// isClientToolCall reports whether an output item type is a function call the
+// client must execute, as opposed to a built-in call executed upstream.
+func isClientToolCall(itemType string) bool {
+	return itemType == "function_call"
 }
 
 // WebSearchAccum accumulates web search parameters across streaming events.
@@ -387,11 +472,108 @@ type WebSearchAccum struct {
 // NewStreamState creates a new stream state.
 func NewStreamState() *StreamState {
 	return &StreamState{
-		ToolCalls:       make(map[int]*api.ToolCall),
-		WebSearchState:  make(map[string]*WebSearchAccum),
-		WebSearchIndex:  make(map[string]int),
-		ReasoningCompat: "none", // Default to none
+		ToolCalls:         make(map[int]*api.ToolCall),
+		ToolIndexByOutput: make(map[int]int),
+		WebSearchState:    make(map[string]*WebSearchAccum),
+		WebSearchIndex:    make(map[string]int),
+		ReasoningCompat:   "none", // Default to none
+		ThinkOpen:         DefaultThinkOpen,
+		ThinkClose:        DefaultThinkClose,
+	}
+}
+
+// allocToolIndex returns the next client-facing tool call index. Indexes are
+// unique and increase in the order tool calls are first seen, whichever event
+// (output_item.added or a web search event) introduces them.
+func (s *StreamState) allocToolIndex() int {
+	idx := s.NextToolIndex
+	s.NextToolIndex++
+	return idx
+}
+
+// applyStopSequences checks CurrentContent for a stop sequence and returns the
+// content that is now safe to stream. On a match, CurrentContent is truncated
+// at the first occurrence and StopHit is set. Otherwise any trailing text that
+// could be the start of a stop sequence is held back until more arrives.
+func (s *StreamState) applyStopSequences() string {
+	end := -1
+	for _, stop := range s.StopSequences {
+		if idx := strings.Index(s.CurrentContent, stop); idx != -1 && (end == -1 || idx < end) {
+			end = idx
+		}
+	}
+	if end != -1 {
+		s.CurrentContent = s.CurrentContent[:end]
+		s.StopHit = true
+	} else {
+		end = len(s.CurrentContent) - stopPrefixLen(s.CurrentContent, s.StopSequences)
+	}
+
+	end = max(end, s.EmittedContent)
+	out := s.CurrentContent[s.EmittedContent:end]
+	s.EmittedContent = end
+	return out
+}
+
+// stopPrefixLen returns the length of the longest suffix of text that is a
+// proper prefix of one of the stop sequences.
+func stopPrefixLen(text string, stops []string) int {
+	longest := 0
+	for _, stop := range stops {
+		for n := min(len(stop)-1, len(text)); n > longest; n-- {
+			if strings.HasSuffix(text, stop[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// flushHeldContent emits text held back by applyStopSequences once the output
+// is complete and no stop sequence matched.
+func (s *StreamState) flushHeldContent() []*api.ChatCompletionChunk {
+	if s.StopHit || s.EmittedContent >= len(s.CurrentContent) || len(s.StopSequences) == 0 {
+		return nil
 	}
+	content := s.CurrentContent[s.EmittedContent:]
+	s.EmittedContent = len(s.CurrentContent)
+	return []*api.ChatCompletionChunk{{
+		ID:      s.ResponseID,
+		Object:  "chat.completion.chunk",
+		Created: s.Created,
+		Model:   s.Model,
+		Choices: []api.Choice{{
+			Index: 0,
+			Delta: &api.Delta{Content: content},
+		}},
+	}}
+}
+
+// parseStopSequences decodes a stop parameter (string or array of strings),
+// ignoring empty entries.
+func parseStopSequences(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		if one == "" {
+			return nil
+		}
+		return []string{one}
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil
+	}
+	stops := many[:0]
+	for _, stop := range many {
+		if stop != "" {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
 }
 
 // SetReasoningCompat sets the reasoning compatibility mode.
@@ -450,6 +632,23 @@ func (s *StreamState) mergeWebSearchParams(callID string, item *WebSearchCallIte
 	}
 }
 
+// webSearchResult extracts the sources from a completed web_search_call action.
+// It reports false when the action carries no sources.
+func webSearchResult(callID string, action json.RawMessage) (api.WebSearchResult, bool) {
+	var a WebSearchAction
+	if len(action) == 0 || json.Unmarshal(action, &a) != nil || len(a.Sources) == 0 {
+		return api.WebSearchResult{}, false
+	}
+	result := api.WebSearchResult{ToolCallID: callID, Query: a.Query}
+	for _, src := range a.Sources {
+		if src.URL == "" {
+			continue
+		}
+		result.Sources = append(result.Sources, api.WebSearchSource{URL: src.URL, Title: src.Title})
+	}
+	return result, len(result.Sources) > 0
+}
+
 // serializeWebSearchArgs serializes accumulated web search params to JSON string.
 func (s *StreamState) serializeWebSearchArgs(callID string) string {
 	accum := s.WebSearchState[callID]
@@ -505,7 +704,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				Model:   s.Model,
 				Choices: []api.Choice{{
 					Index: 0,
-					Delta: &api.Delta{Content: "</think>"},
+					Delta: &api.Delta{Content: s.ThinkClose},
 				}},
 			})
 			s.ThinkTagOpen = false
@@ -513,8 +712,19 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		}
 
 		s.SawOutput = true
+		if s.StopHit {
+			return chunks, nil
+		}
 		s.CurrentContent += data.Delta
 
+		content := data.Delta
+		if len(s.StopSequences) > 0 {
+			content = s.applyStopSequences()
+		}
+		if content == "" {
+			return chunks, nil
+		}
+
 		chunks = append(chunks, &api.ChatCompletionChunk{
 			ID:      s.ResponseID,
 			Object:  "chat.completion.chunk",
@@ -522,7 +732,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			Model:   s.Model,
 			Choices: []api.Choice{{
 				Index: 0,
-				Delta: &api.Delta{Content: data.Delta},
+				Delta: &api.Delta{Content: content},
 			}},
 		})
 
@@ -530,9 +740,10 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 
 	case EventResponseOutputTextDone:
 		// Text completion marker - send stop if not already sent
+		chunks := s.flushHeldContent()
 		if !s.SentStopChunk {
 			s.SentStopChunk = true
-			return []*api.ChatCompletionChunk{{
+			chunks = append(chunks, &api.ChatCompletionChunk{
 				ID:      s.ResponseID,
 				Object:  "chat.completion.chunk",
 				Created: s.Created,
@@ -542,13 +753,13 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 					Delta:        &api.Delta{},
 					FinishReason: stringPtr("stop"),
 				}},
-			}}, nil
+			})
 		}
-		return nil, nil
+		return chunks, nil
 
 	case EventResponseReasoningSummaryPartAdded:
 		// New reasoning paragraph marker
-		if s.ReasoningCompat == "think-tags" || s.ReasoningCompat == "o3" {
+		if s.ReasoningCompat == "think-tags" || s.ReasoningCompat == "o3" || s.ReasoningCompat == "reasoning-content" {
 			if s.ReasoningSummary != "" || s.ReasoningFull != "" {
 				s.PendingSummaryNewline = true
 			}
@@ -586,7 +797,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 					Model:   s.Model,
 					Choices: []api.Choice{{
 						Index: 0,
-						Delta: &api.Delta{Content: "<think>"},
+						Delta: &api.Delta{Content: s.ThinkOpen},
 					}},
 				})
 				s.ThinkTagOpen = true
@@ -662,6 +873,24 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			})
 			return chunks, nil
 
+		case "reasoning-content":
+			// Emit as a flat reasoning_content string (DeepSeek style)
+			text := data.Delta
+			if s.PendingSummaryNewline {
+				text = "\n" + text
+				s.PendingSummaryNewline = false
+			}
+			return []*api.ChatCompletionChunk{{
+				ID:      s.ResponseID,
+				Object:  "chat.completion.chunk",
+				Created: s.Created,
+				Model:   s.Model,
+				Choices: []api.Choice{{
+					Index: 0,
+					Delta: &api.Delta{ReasoningContent: text},
+				}},
+			}}, nil
+
 		case "legacy":
 			// Emit as separate fields - only for summary events
 			// Skip non-summary reasoning events to avoid empty deltas
@@ -688,12 +917,12 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			return nil, err
 		}
 
-		// Get or create tool call
-		tc, ok := s.ToolCalls[data.OutputIndex]
+		// Tool call should have been created in output_item.added
+		toolIndex, ok := s.ToolIndexByOutput[data.OutputIndex]
 		if !ok {
-			// Tool call should have been created in output_item.added
 			return nil, nil
 		}
+		tc := s.ToolCalls[toolIndex]
 		tc.Function.Arguments += data.Delta
 
 		return []*api.ChatCompletionChunk{{
@@ -705,7 +934,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				Index: 0,
 				Delta: &api.Delta{
 					ToolCalls: []api.ToolCall{{
-						Index:    intPtr(data.OutputIndex),
+						Index:    intPtr(toolIndex),
 						Function: api.FunctionCall{Arguments: data.Delta},
 					}},
 				},
@@ -732,23 +961,30 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				name = strings.TrimSuffix(data.Item.Type, "_call")
 			}
 
-			tc := &api.ToolCall{
+			// A web search may already have been announced by its search events
+			if data.Item.Type == "web_search_call" {
+				if toolIndex, ok := s.WebSearchIndex[callID]; ok {
+					s.ToolIndexByOutput[data.OutputIndex] = toolIndex
+					return nil, nil
+				}
+			}
+
+			toolIndex := s.allocToolIndex()
+			s.ToolIndexByOutput[data.OutputIndex] = toolIndex
+			s.ToolCalls[toolIndex] = &api.ToolCall{
 				ID:   callID,
 				Type: "function",
 				Function: api.FunctionCall{
 					Name: name,
 				},
 			}
-			s.ToolCalls[data.OutputIndex] = tc
-
-			// Update NextToolIndex to be beyond this index to avoid conflicts
-			if data.OutputIndex >= s.NextToolIndex {
-				s.NextToolIndex = data.OutputIndex + 1
+			if isClientToolCall(data.Item.Type) {
+				s.ClientToolCalls++
 			}
 
 			// Track for web search state accumulation
 			if data.Item.Type == "web_search_call" {
-				s.WebSearchIndex[callID] = data.OutputIndex
+				s.WebSearchIndex[callID] = toolIndex
 				s.WebSearchState[callID] = &WebSearchAccum{}
 			}
 
@@ -762,7 +998,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 					Index: 0,
 					Delta: &api.Delta{
 						ToolCalls: []api.ToolCall{{
-							Index: intPtr(data.OutputIndex),
+							Index: intPtr(toolIndex),
 							ID:    callID,
 							Type:  "function",
 							Function: api.FunctionCall{
@@ -794,8 +1030,8 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			// For function_call, arguments were already streamed via delta events
 			// Just update final state, don't emit (would cause duplicate content)
 			if data.Item.Type == "function_call" {
-				if tc, exists := s.ToolCalls[data.OutputIndex]; exists && data.Item.Arguments != "" {
-					tc.Function.Arguments = data.Item.Arguments
+				if toolIndex, ok := s.ToolIndexByOutput[data.OutputIndex]; ok && data.Item.Arguments != "" {
+					s.ToolCalls[toolIndex].Function.Arguments = data.Item.Arguments
 				}
 				return nil, nil
 			}
@@ -813,15 +1049,17 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				argsJSON = s.serializeWebSearchArgs(callID)
 			}
 
-			// Find output index
-			outputIndex := -1
+			// Find the client-facing index
+			toolIndex := -1
 			if idx, ok := s.WebSearchIndex[callID]; ok {
-				outputIndex = idx
+				toolIndex = idx
+			} else if idx, ok := s.ToolIndexByOutput[data.OutputIndex]; ok {
+				toolIndex = idx
 			} else {
 				// Search in ToolCalls
 				for idx, tc := range s.ToolCalls {
 					if tc.ID == callID {
-						outputIndex = idx
+						toolIndex = idx
 						break
 					}
 				}
@@ -829,15 +1067,15 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 
 			// If we found the tool call, update and emit arguments
 			// Use empty object if no arguments available (OpenAI API expects arguments field)
-			if outputIndex >= 0 {
+			if toolIndex >= 0 {
 				if argsJSON == "" {
 					argsJSON = "{}"
 				}
-				if tc, exists := s.ToolCalls[outputIndex]; exists {
+				if tc, exists := s.ToolCalls[toolIndex]; exists {
 					tc.Function.Arguments = argsJSON
 				}
 
-				return []*api.ChatCompletionChunk{{
+				chunks := []*api.ChatCompletionChunk{{
 					ID:      s.ResponseID,
 					Object:  "chat.completion.chunk",
 					Created: s.Created,
@@ -846,12 +1084,28 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 						Index: 0,
 						Delta: &api.Delta{
 							ToolCalls: []api.ToolCall{{
-								Index:    intPtr(outputIndex),
+								Index:    intPtr(toolIndex),
 								Function: api.FunctionCall{Arguments: argsJSON},
 							}},
 						},
 					}},
-				}}, nil
+				}}
+				if data.Item.Type == "web_search_call" && s.ExposeWebSearch {
+					if result, ok := webSearchResult(callID, data.Item.Action); ok {
+						s.WebSearchResults = append(s.WebSearchResults, result)
+						chunks = append(chunks, &api.ChatCompletionChunk{
+							ID:      s.ResponseID,
+							Object:  "chat.completion.chunk",
+							Created: s.Created,
+							Model:   s.Model,
+							Choices: []api.Choice{{
+								Index: 0,
+								Delta: &api.Delta{WebSearchResults: []api.WebSearchResult{result}},
+							}},
+						})
+					}
+				}
+				return chunks, nil
 			}
 
 			// Tool call not tracked - this indicates the output_item.added event was missed
@@ -883,24 +1137,19 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		// Merge params from this event into accumulated state
 		s.mergeWebSearchParams(callID, data.Item, &data)
 
-		// Get output index (may have been set in output_item.added)
-		outputIndex, ok := s.WebSearchIndex[callID]
+		// Get tool call index (may have been set in output_item.added)
+		toolIndex, ok := s.WebSearchIndex[callID]
 		isFirstChunk := !ok
 		if !ok {
-			// Not yet tracked, assign next available index
-			outputIndex = s.NextToolIndex
-			s.NextToolIndex++
-			s.WebSearchIndex[callID] = outputIndex
-
-			// Create tool call if not exists
-			if _, exists := s.ToolCalls[outputIndex]; !exists {
-				s.ToolCalls[outputIndex] = &api.ToolCall{
-					ID:   callID,
-					Type: "function",
-					Function: api.FunctionCall{
-						Name: "web_search",
-					},
-				}
+			// Not yet tracked, allocate the next index; a later output_item.added reuses it
+			toolIndex = s.allocToolIndex()
+			s.WebSearchIndex[callID] = toolIndex
+			s.ToolCalls[toolIndex] = &api.ToolCall{
+				ID:   callID,
+				Type: "function",
+				Function: api.FunctionCall{
+					Name: "web_search",
+				},
 			}
 		}
 
@@ -908,7 +1157,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		argsJSON := s.serializeWebSearchArgs(callID)
 
 		// Update stored tool call
-		if tc, exists := s.ToolCalls[outputIndex]; exists {
+		if tc, exists := s.ToolCalls[toolIndex]; exists {
 			tc.Function.Arguments = argsJSON
 		}
 
@@ -917,7 +1166,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		var toolCall api.ToolCall
 		if isFirstChunk {
 			toolCall = api.ToolCall{
-				Index: intPtr(outputIndex),
+				Index: intPtr(toolIndex),
 				ID:    callID,
 				Type:  "function",
 				Function: api.FunctionCall{
@@ -927,7 +1176,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			}
 		} else {
 			toolCall = api.ToolCall{
-				Index:    intPtr(outputIndex),
+				Index:    intPtr(toolIndex),
 				Function: api.FunctionCall{Arguments: argsJSON},
 			}
 		}
@@ -967,16 +1216,19 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				Model:   s.Model,
 				Choices: []api.Choice{{
 					Index: 0,
-					Delta: &api.Delta{Content: "</think>"},
+					Delta: &api.Delta{Content: s.ThinkClose},
 				}},
 			})
 			s.ThinkTagOpen = false
 			s.ThinkTagClosed = true
 		}
 
-		// Determine finish reason
+		chunks = append(chunks, s.flushHeldContent()...)
+
+		// Determine finish reason; built-in calls (web_search, mcp, ...) run
+		// server-side and don't hand the turn back to the client
 		finishReason := "stop"
-		if len(s.ToolCalls) > 0 {
+		if s.ClientToolCalls > 0 && !s.StopHit {
 			finishReason = "tool_calls"
 		}
 		s.FinishReason = finishReason
@@ -1011,7 +1263,9 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		}
 		s.FinishReason = "error"
 		if data.Response.Error != nil {
-			s.ErrorMessage = data.Response.Error.Message
+			s.Error = data.Response.Error
+		} else {
+			s.Error = &ErrorData{Message: "response failed"}
 		}
 		return nil, nil
 
@@ -1021,7 +1275,7 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 			return nil, err
 		}
 		s.FinishReason = "error"
-		s.ErrorMessage = data.Message
+		s.Error = &ErrorData{Type: data.Type, Code: data.Code, Message: data.Message}
 		return nil, nil
 
 	case EventResponseInProgress, EventResponseQueued:
@@ -1046,17 +1300,21 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 				Model:   s.Model,
 				Choices: []api.Choice{{
 					Index: 0,
-					Delta: &api.Delta{Content: "</think>"},
+					Delta: &api.Delta{Content: s.ThinkClose},
 				}},
 			})
 			s.ThinkTagOpen = false
 			s.ThinkTagClosed = true
 		}
 
+		chunks = append(chunks, s.flushHeldContent()...)
+
 		// Map incomplete reason to finish reason
 		finishReason := "length" // Default for max_output_tokens
 		if data.Response.IncompleteReason == "content_filter" {
 			finishReason = "content_filter"
+		} else if s.StopHit {
+			finishReason = "stop" // output was cut at a stop sequence before the limit mattered
 		}
 		s.FinishReason = finishReason
 
@@ -1129,7 +1387,13 @@ func (s *StreamState) ProcessEvent(event *sse.Event) ([]*api.ChatCompletionChunk
 		// Content part completion marker - no action needed
 		return nil, nil
 
-	case EventResponseReasoningSummaryPartDone, EventResponseReasoningTextDone,
+	case EventResponseReasoningSummaryPartDone:
+		if s.InterimUsage {
+			return []*api.ChatCompletionChunk{s.interimUsageChunk()}, nil
+		}
+		return nil, nil
+
+	case EventResponseReasoningTextDone,
 		EventResponseReasoningSummaryTextDone, EventResponseFunctionCallArgumentsDone:
 		// These are completion markers for their respective delta events
 		// No additional chunks needed as the content is already streamed
@@ -1158,26 +1422,64 @@ func (s *StreamState) GetUsageChunk() *api.ChatCompletionChunk {
 		return nil
 	}
 
-	// Generate system fingerprint from response ID
-	systemFingerprint := ""
-	if len(s.ResponseID) > 8 {
-		systemFingerprint = "fp_" + s.ResponseID[len(s.ResponseID)-8:]
-	}
-
 	return &api.ChatCompletionChunk{
 		ID:                s.ResponseID,
 		Object:            "chat.completion.chunk",
 		Created:           s.Created,
 		Model:             s.Model,
-		SystemFingerprint: systemFingerprint,
+		SystemFingerprint: s.SystemFingerprint,
 		Choices:           []api.Choice{}, // Empty choices array for usage-only chunk
 		Usage:             s.Usage,
 	}
 }
 
-// GetError returns any error message from the stream.
-func (s *StreamState) GetError() string {
-	return s.ErrorMessage
+// interimUsageChunk returns a usage-only chunk with running estimates. Only
+// the visible reasoning is counted, so reasoning tokens are a lower bound
+// until the final usage arrives.
+func (s *StreamState) interimUsageChunk() *api.ChatCompletionChunk {
+	reasoning := tokens.Estimate(s.Model, s.ReasoningSummary+s.ReasoningFull)
+	completion := reasoning + tokens.Estimate(s.Model, s.CurrentContent)
+	return &api.ChatCompletionChunk{
+		ID:                s.ResponseID,
+		Object:            "chat.completion.chunk",
+		Created:           s.Created,
+		Model:             s.Model,
+		SystemFingerprint: s.SystemFingerprint,
+		Choices:           []api.Choice{},
+		Usage: &api.Usage{
+			PromptTokens:            s.PromptTokens,
+			CompletionTokens:        completion,
+			TotalTokens:             s.PromptTokens + completion,
+			CompletionTokensDetails: &api.CompletionTokenDetails{ReasoningTokens: reasoning},
+			Estimated:               true,
+		},
+	}
+}
+
+// EnsureUsage fills in estimated usage when the upstream reported none
+// (e.g., failed or truncated responses). The result is flagged as estimated.
+func (s *StreamState) EnsureUsage() {
+	if s.Usage != nil {
+		return
+	}
+
+	completion := s.CurrentContent + s.Refusal + s.ReasoningSummary + s.ReasoningFull
+	for _, tc := range s.ToolCalls {
+		completion += tc.Function.Name + tc.Function.Arguments
+	}
+	completionTokens := tokens.Estimate(s.Model, completion)
+
+	s.Usage = &api.Usage{
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      s.PromptTokens + completionTokens,
+		Estimated:        true,
+	}
+}
+
+// GetError returns the upstream error reported by the stream, or nil.
+func (s *StreamState) GetError() *ErrorData {
+	return s.Error
 }
 
 // BuildNonStreamingResponse builds a complete ChatCompletionResponse from state.
@@ -1200,7 +1502,7 @@ func (s *StreamState) BuildNonStreamingResponse() *api.ChatCompletionResponse {
 	switch s.ReasoningCompat {
 	case "think-tags":
 		if reasoningText != "" {
-			content = "<think>" + reasoningText + "</think>" + content
+			content = s.ThinkOpen + reasoningText + s.ThinkClose + content
 		}
 	case "o3":
 		if reasoningText != "" {
@@ -1212,6 +1514,8 @@ func (s *StreamState) BuildNonStreamingResponse() *api.ChatCompletionResponse {
 		if s.ReasoningSummary != "" {
 			msg.ReasoningSummary = s.ReasoningSummary
 		}
+	case "reasoning-content":
+		msg.ReasoningContent = reasoningText
 	}
 	msg.SetContentString(content)
 
@@ -1220,6 +1524,8 @@ func (s *StreamState) BuildNonStreamingResponse() *api.ChatCompletionResponse {
 		msg.Refusal = s.Refusal
 	}
 
+	msg.WebSearchResults = s.WebSearchResults
+
 	// Add tool calls if any (sorted by output index)
 	// Note: For non-streaming responses, tool calls should NOT have Index field
 	if len(s.ToolCalls) > 0 {
@@ -1242,18 +1548,12 @@ func (s *StreamState) BuildNonStreamingResponse() *api.ChatCompletionResponse {
 		}
 	}
 
-	// Generate system fingerprint from response ID
-	systemFingerprint := ""
-	if len(s.ResponseID) > 8 {
-		systemFingerprint = "fp_" + s.ResponseID[len(s.ResponseID)-8:]
-	}
-
 	return &api.ChatCompletionResponse{
 		ID:                s.ResponseID,
 		Object:            "chat.completion",
 		Created:           s.Created,
 		Model:             s.Model,
-		SystemFingerprint: systemFingerprint,
+		SystemFingerprint: s.SystemFingerprint,
 		Choices: []api.Choice{{
 			Index:        0,
 			Message:      msg,
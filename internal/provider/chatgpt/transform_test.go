@@ -0,0 +1,77 @@
+package chatgpt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/sse"
+)
+
+func TestProcessEventInterleavedToolCallIndexes(t *testing.T) {
+	events := []sse.Event{
+		{Event: EventResponseOutputItemAdded, Data: json.RawMessage(`{"output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_a","name":"get_weather"}}`)},
+		// The search event arrives before the web_search_call item is added
+		{Event: EventWebSearchCallSearching, Data: json.RawMessage(`{"item_id":"ws_1","output_index":1,"query":"weather"}`)},
+		{Event: EventResponseOutputItemAdded, Data: json.RawMessage(`{"output_index":1,"item":{"type":"web_search_call","id":"ws_1"}}`)},
+		{Event: EventResponseFunctionCallArgumentsDelta, Data: json.RawMessage(`{"output_index":0,"delta":"{\"city\":\"Paris\"}"}`)},
+		{Event: EventResponseOutputItemAdded, Data: json.RawMessage(`{"output_index":2,"item":{"type":"function_call","id":"fc_2","call_id":"call_b","name":"get_time"}}`)},
+		{Event: EventResponseFunctionCallArgumentsDelta, Data: json.RawMessage(`{"output_index":2,"delta":"{}"}`)},
+		{Event: EventResponseOutputItemDone, Data: json.RawMessage(`{"output_index":1,"item":{"type":"web_search_call","id":"ws_1"}}`)},
+		{Event: EventResponseOutputItemDone, Data: json.RawMessage(`{"output_index":0,"item":{"type":"function_call","id":"fc_1","call_id":"call_a","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}`)},
+	}
+
+	state := NewStreamState()
+	idByIndex := map[int]string{}
+	var seen []int
+	for _, event := range events {
+		chunks, err := state.ProcessEvent(&event)
+		if err != nil {
+			t.Fatalf("ProcessEvent(%s) error = %v", event.Event, err)
+		}
+		for _, chunk := range chunks {
+			for _, tc := range chunk.Choices[0].Delta.ToolCalls {
+				if tc.Index == nil {
+					t.Fatalf("tool call delta without index in %s", event.Event)
+				}
+				seen = append(seen, *tc.Index)
+				if tc.ID != "" {
+					if prev, ok := idByIndex[*tc.Index]; ok && prev != tc.ID {
+						t.Errorf("index %d reused for %q and %q", *tc.Index, prev, tc.ID)
+					}
+					idByIndex[*tc.Index] = tc.ID
+				}
+			}
+		}
+	}
+
+	wantIDs := map[int]string{0: "call_a", 1: "ws_1", 2: "call_b"}
+	for idx, id := range wantIDs {
+		if idByIndex[idx] != id {
+			t.Errorf("index %d announced as %q, want %q", idx, idByIndex[idx], id)
+		}
+		if tc := state.ToolCalls[idx]; tc == nil || tc.ID != id {
+			t.Errorf("state.ToolCalls[%d] = %+v, want id %q", idx, tc, id)
+		}
+	}
+	if len(state.ToolCalls) != len(wantIDs) {
+		t.Errorf("got %d tool calls, want %d", len(state.ToolCalls), len(wantIDs))
+	}
+
+	// call_a added, ws_1 searching, call_a delta, call_b added, call_b delta, ws_1 done
+	wantSeen := []int{0, 1, 0, 2, 2, 1}
+	if len(seen) != len(wantSeen) {
+		t.Fatalf("tool call delta indexes = %v, want %v", seen, wantSeen)
+	}
+	for i := range seen {
+		if seen[i] != wantSeen[i] {
+			t.Fatalf("tool call delta indexes = %v, want %v", seen, wantSeen)
+		}
+	}
+
+	if got := state.ToolCalls[0].Function.Arguments; got != `{"city":"Paris"}` {
+		t.Errorf("call_a arguments = %q", got)
+	}
+	if state.ClientToolCalls != 2 {
+		t.Errorf("ClientToolCalls = %d, want 2", state.ClientToolCalls)
+	}
+}
@@ -299,6 +299,18 @@ type WebSearchCallItem struct {
 	Parameters *WebSearchCallParam `json:"parameters,omitempty"`
 }
 
+// WebSearchAction is the action of a completed web_search_call item. Sources
+// are only present when "web_search_call.action.sources" is requested in include.
+type WebSearchAction struct {
+	Type    string `json:"type"` // "search", "open_page", "find"
+	Query   string `json:"query,omitempty"`
+	Sources []struct {
+		Type  string `json:"type"` // "url"
+		URL   string `json:"url"`
+		Title string `json:"title,omitempty"`
+	} `json:"sources,omitempty"`
+}
+
 // WebSearchCallParam represents web search parameters.
 type WebSearchCallParam struct {
 	Query      string   `json:"query,omitempty"`
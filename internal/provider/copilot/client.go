@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -13,6 +14,8 @@ import (
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/auth"
 	"github.com/edgard/opencompat/internal/httputil"
+	"github.com/edgard/opencompat/internal/logging"
+	"github.com/edgard/opencompat/internal/metrics"
 	"github.com/google/uuid"
 )
 
@@ -22,21 +25,42 @@ type CopilotToken struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// Background token refresh timing
+const (
+	tokenRefreshLead  = 2 * time.Minute  // renew this long before the request-path margin is reached
+	tokenRefreshRetry = time.Minute      // delay after a failed renewal
+	tokenRefreshFloor = 30 * time.Second // minimum delay between renewals
+)
+
 // Client handles communication with the Copilot API.
 type Client struct {
 	store        *auth.Store
 	httpClient   *http.Client
+	timeout      time.Duration // per-request idle timeout (0 = no deadline)
+	tokenURL     string
+	chatURL      string
+	modelsURL    string
 	mu           sync.RWMutex
 	copilotToken *CopilotToken
+
+	stopTokenRefresh    chan struct{}
+	tokenRefreshDone    chan struct{}
+	tokenRefreshStarted bool
 }
 
-// NewClient creates a new Copilot client.
-func NewClient(store *auth.Store) *Client {
+// NewClient creates a new Copilot client using the endpoints from cfg.
+// No client-level timeout is set: requests are bounded individually by cfg.UpstreamTimeout.
+func NewClient(store *auth.Store, cfg *Config) *Client {
 	return &Client{
-		store: store,
-		httpClient: &http.Client{
-			Timeout: 5 * time.Minute,
-		},
+		store:      store,
+		httpClient: httputil.NewClient(),
+		timeout:    cfg.UpstreamTimeout,
+		tokenURL:   cfg.TokenURL,
+		chatURL:    cfg.ChatURL,
+		modelsURL:  cfg.ModelsURL(),
+
+		stopTokenRefresh: make(chan struct{}),
+		tokenRefreshDone: make(chan struct{}),
 	}
 }
 
@@ -55,7 +79,7 @@ func (c *Client) getGitHubToken() (string, error) {
 // getCopilotToken returns a valid Copilot API token, refreshing if necessary.
 func (c *Client) getCopilotToken(ctx context.Context) (string, error) {
 	c.mu.RLock()
-	if c.copilotToken != nil && time.Now().Add(60*time.Second).Before(c.copilotToken.ExpiresAt) {
+	if c.copilotToken != nil && time.Now().Add(auth.ExpiryMargin()).Before(c.copilotToken.ExpiresAt) {
 		token := c.copilotToken.Token
 		c.mu.RUnlock()
 		return token, nil
@@ -67,7 +91,7 @@ func (c *Client) getCopilotToken(ctx context.Context) (string, error) {
 	defer c.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if c.copilotToken != nil && time.Now().Add(60*time.Second).Before(c.copilotToken.ExpiresAt) {
+	if c.copilotToken != nil && time.Now().Add(auth.ExpiryMargin()).Before(c.copilotToken.ExpiresAt) {
 		return c.copilotToken.Token, nil
 	}
 
@@ -84,12 +108,110 @@ func (c *Client) getCopilotToken(ctx context.Context) (string, error) {
 	}
 
 	c.copilotToken = token
+	metrics.IncTokenRefresh(ProviderID)
 	return token.Token, nil
 }
 
+// StartTokenRefresh starts a goroutine that renews the Copilot token ahead of
+// its expiry, so requests don't stall on the exchange when it lapses.
+func (c *Client) StartTokenRefresh() {
+	c.mu.Lock()
+	if c.tokenRefreshStarted {
+		c.mu.Unlock()
+		return
+	}
+	c.tokenRefreshStarted = true
+	c.mu.Unlock()
+
+	slog.Debug("background token refresh started", "provider", ProviderID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(c.tokenRefreshDone)
+		defer cancel()
+
+		timer := time.NewTimer(c.nextTokenRefresh())
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-c.stopTokenRefresh:
+				slog.Debug("background token refresh stopped", "provider", ProviderID)
+				return
+			case <-timer.C:
+				delay := tokenRefreshRetry
+				if err := c.renewCopilotToken(ctx); err != nil {
+					slog.Warn("failed to refresh copilot token", "provider", ProviderID, "error", err)
+				} else {
+					delay = c.nextTokenRefresh()
+				}
+				timer.Reset(delay)
+			}
+		}
+	}()
+
+	go func() {
+		select {
+		case <-c.stopTokenRefresh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// StopTokenRefresh stops the background token refresh goroutine.
+func (c *Client) StopTokenRefresh() {
+	c.mu.Lock()
+	if !c.tokenRefreshStarted {
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-c.stopTokenRefresh:
+		// Already closed
+	default:
+		close(c.stopTokenRefresh)
+	}
+	<-c.tokenRefreshDone
+}
+
+// nextTokenRefresh returns how long to wait before renewing the cached token.
+// Without a cached token the renewal is due immediately.
+func (c *Client) nextTokenRefresh() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.copilotToken == nil {
+		return 0
+	}
+	delay := time.Until(c.copilotToken.ExpiresAt) - auth.ExpiryMargin() - tokenRefreshLead
+	return max(delay, tokenRefreshFloor)
+}
+
+// renewCopilotToken exchanges the GitHub token for a new Copilot token and caches it.
+// The exchange runs without the lock so requests keep using the current token meanwhile.
+func (c *Client) renewCopilotToken(ctx context.Context) error {
+	githubToken, err := c.getGitHubToken()
+	if err != nil {
+		return err
+	}
+	token, err := c.refreshCopilotToken(ctx, githubToken)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.copilotToken = token
+	c.mu.Unlock()
+	metrics.IncTokenRefresh(ProviderID)
+	slog.Debug("refreshed copilot token", "provider", ProviderID, "expires_at", token.ExpiresAt)
+	return nil
+}
+
 // refreshCopilotToken exchanges a GitHub token for a Copilot API token.
 func (c *Client) refreshCopilotToken(ctx context.Context, githubToken string) (*CopilotToken, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", CopilotTokenURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.tokenURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +222,7 @@ func (c *Client) refreshCopilotToken(ctx context.Context, githubToken string) (*
 	req.Header.Set("Editor-Version", EditorVersion)
 	req.Header.Set("Editor-Plugin-Version", EditorPluginVersion)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httputil.DoWithTimeout(c.httpClient, req, c.timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to request Copilot token: %w", err)
 	}
@@ -111,8 +233,15 @@ func (c *Client) refreshCopilotToken(ctx context.Context, githubToken string) (*
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		// GitHub token was revoked or expired
+		return nil, &auth.ReauthRequiredError{ProviderID: ProviderID, Reason: "GitHub token rejected"}
+	}
+	if isQuotaStatus(resp.StatusCode) {
+		return nil, quotaError(resp.StatusCode, body)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("copilot token request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("copilot token request failed with status %d: %s", resp.StatusCode, logging.Redact(string(body)))
 	}
 
 	var tokenResp struct {
@@ -129,6 +258,25 @@ func (c *Client) refreshCopilotToken(ctx context.Context, githubToken string) (*
 	}, nil
 }
 
+// CheckAuth exchanges the GitHub token for a fresh Copilot token.
+// The new token replaces the cached one.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	githubToken, err := c.getGitHubToken()
+	if err != nil {
+		return err
+	}
+
+	token, err := c.refreshCopilotToken(ctx, githubToken)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.copilotToken = token
+	c.mu.Unlock()
+	return nil
+}
+
 // SendRequest sends a chat completion request to the Copilot API.
 func (c *Client) SendRequest(ctx context.Context, chatReq *api.ChatCompletionRequest) (*http.Response, error) {
 	// Get valid Copilot token
@@ -144,7 +292,7 @@ func (c *Client) SendRequest(ctx context.Context, chatReq *api.ChatCompletionReq
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", CopilotChatURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.chatURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -175,11 +323,16 @@ func (c *Client) SendRequest(ctx context.Context, chatReq *api.ChatCompletionReq
 	}
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := httputil.DoWithTimeout(c.httpClient, req, c.timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
+	// Handle gzip-encoded SSE from intermediate proxies
+	if err := httputil.DecompressBody(resp); err != nil {
+		return nil, err
+	}
+
 	return resp, nil
 }
 
@@ -0,0 +1,63 @@
+package copilot
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+func TestSendRequestDecodesGzipSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz,
+			`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"hel"}}]}`+"\n\n"+
+				`data: {"id":"c1","object":"chat.completion.chunk","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`+"\n\n"+
+				"data: [DONE]\n\n")
+		_ = gz.Close()
+	}))
+	defer srv.Close()
+
+	// Compression is disabled so, as with a proxy compressing unprompted,
+	// the transport leaves the gzip body alone
+	c := &Client{
+		httpClient:   &http.Client{Transport: &http.Transport{DisableCompression: true}},
+		chatURL:      srv.URL,
+		copilotToken: &CopilotToken{Token: "test-token", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+
+	resp, err := c.SendRequest(context.Background(), &api.ChatCompletionRequest{Model: "gpt-4o", Stream: true})
+	if err != nil {
+		t.Fatalf("SendRequest() error = %v", err)
+	}
+
+	s := NewStream(resp, true)
+	defer func() { _ = s.Close() }()
+	var content string
+	for {
+		chunk, err := s.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
+			content += chunk.Choices[0].Delta.Content
+		}
+	}
+	if content != "hello" {
+		t.Errorf("content = %q, want %q", content, "hello")
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
@@ -1,10 +1,14 @@
 package copilot
 
 import (
-	"os"
+	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/config"
 )
 
 // Provider identification
@@ -13,6 +17,10 @@ const ProviderID = "copilot"
 // Environment variable names for Copilot provider
 const (
 	EnvModelsRefresh = "OPENCOMPAT_COPILOT_MODELS_REFRESH"
+	EnvBaseURL       = "OPENCOMPAT_COPILOT_BASE_URL"
+	EnvTokenURL      = "OPENCOMPAT_COPILOT_TOKEN_URL"
+	EnvChatURL       = "OPENCOMPAT_COPILOT_CHAT_URL"
+	EnvTimeout       = "OPENCOMPAT_COPILOT_TIMEOUT"
 )
 
 // Default values
@@ -28,7 +36,7 @@ const (
 	GitHubScopes         = "read:user"
 )
 
-// Copilot API configuration
+// Copilot API configuration (public endpoints, overridable for GitHub Enterprise)
 const (
 	CopilotTokenURL = "https://api.github.com/copilot_internal/v2/token"
 	CopilotBaseURL  = "https://api.githubcopilot.com"
@@ -46,16 +54,46 @@ const (
 
 // Config holds Copilot-specific configuration.
 type Config struct {
-	ModelsRefresh int // refresh interval in minutes
+	ModelsRefresh   int           // refresh interval in minutes
+	BaseURL         string        // Copilot API base URL (models are fetched from {BaseURL}/models)
+	TokenURL        string        // Copilot token exchange URL
+	ChatURL         string        // chat completions URL (default: {BaseURL}/chat/completions)
+	UpstreamTimeout time.Duration // per-request idle timeout (0 = no deadline)
 }
 
 // LoadConfig reads Copilot configuration from environment variables.
 func LoadConfig() *Config {
+	baseURL := strings.TrimRight(getEnv(EnvBaseURL, CopilotBaseURL), "/")
 	return &Config{
-		ModelsRefresh: getEnvInt(EnvModelsRefresh, DefaultModelsRefresh),
+		ModelsRefresh:   getEnvInt(EnvModelsRefresh, DefaultModelsRefresh),
+		BaseURL:         baseURL,
+		TokenURL:        getEnv(EnvTokenURL, CopilotTokenURL),
+		ChatURL:         getEnv(EnvChatURL, baseURL+"/chat/completions"),
+		UpstreamTimeout: config.Load().ProviderUpstreamTimeout(EnvTimeout),
 	}
 }
 
+// ModelsURL returns the endpoint for fetching available models.
+func (c *Config) ModelsURL() string {
+	return c.BaseURL + "/models"
+}
+
+// Validate checks that the configured endpoints are absolute http(s) URLs.
+func (c *Config) Validate() error {
+	endpoints := []struct{ env, value string }{
+		{EnvBaseURL, c.BaseURL},
+		{EnvTokenURL, c.TokenURL},
+		{EnvChatURL, c.ChatURL},
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s %q: must be an absolute http(s) URL", e.env, e.value)
+		}
+	}
+	return nil
+}
+
 // EnvVarDoc documents an environment variable.
 type EnvVarDoc struct {
 	Name        string
@@ -67,11 +105,22 @@ type EnvVarDoc struct {
 func EnvVarDocs() []EnvVarDoc {
 	return []EnvVarDoc{
 		{Name: EnvModelsRefresh, Description: "Models refresh interval in minutes", Default: strconv.Itoa(DefaultModelsRefresh)},
+		{Name: EnvBaseURL, Description: "Copilot API base URL (GitHub Enterprise)", Default: CopilotBaseURL},
+		{Name: EnvTokenURL, Description: "Copilot token exchange URL (GitHub Enterprise)", Default: CopilotTokenURL},
+		{Name: EnvChatURL, Description: "Chat completions URL", Default: "{base URL}/chat/completions"},
+		{Name: EnvTimeout, Description: "Upstream timeout in seconds (0 = no deadline)", Default: "OPENCOMPAT_UPSTREAM_TIMEOUT"},
 	}
 }
 
+func getEnv(key, defaultVal string) string {
+	if val := config.Getenv(key); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
 func getEnvInt(key string, defaultVal int) int {
-	if val := os.Getenv(key); val != "" {
+	if val := config.Getenv(key); val != "" {
 		if i, err := strconv.Atoi(val); err == nil {
 			return i
 		}
@@ -14,12 +14,10 @@ import (
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/httputil"
+	"github.com/edgard/opencompat/internal/logging"
 )
 
 const (
-	// CopilotModelsURL is the endpoint for fetching available models.
-	CopilotModelsURL = CopilotBaseURL + "/models"
-
 	// ModelsDiskCacheTTL is how long disk cache is valid (7 days).
 	ModelsDiskCacheTTL = 7 * 24 * time.Hour
 )
@@ -29,6 +27,7 @@ type ModelsCache struct {
 	mu             sync.RWMutex
 	models         []api.Model
 	modelIDs       map[string]bool
+	vision         map[string]bool // vision support per model, for models whose metadata reports it
 	fetchedAt      time.Time
 	client         *Client
 	cacheTTL       time.Duration
@@ -70,9 +69,9 @@ func (c *ModelsCache) GetModels() []api.Model {
 
 	// If no client or not logged in, try disk cache only
 	if c.client == nil || c.client.store == nil {
-		models, err := c.loadFromDisk()
+		models, vision, err := c.loadFromDisk()
 		if err == nil && len(models) > 0 {
-			c.updateCache(models)
+			c.updateCache(models, vision)
 			return c.models
 		}
 		// Return empty list - user needs to login
@@ -80,9 +79,9 @@ func (c *ModelsCache) GetModels() []api.Model {
 	}
 
 	// Try to fetch from API
-	models, err := c.fetchFromAPI()
+	models, vision, err := c.fetchFromAPI()
 	if err == nil {
-		c.updateCache(models)
+		c.updateCache(models, vision)
 		// Save to disk asynchronously
 		go c.saveToDisk()
 		return c.models
@@ -91,10 +90,10 @@ func (c *ModelsCache) GetModels() []api.Model {
 	slog.Warn("failed to fetch models from API", "provider", "copilot", "error", err)
 
 	// Try disk cache as fallback
-	models, err = c.loadFromDisk()
+	models, vision, err = c.loadFromDisk()
 	if err == nil && len(models) > 0 {
 		slog.Debug("using cached models from disk", "provider", "copilot")
-		c.updateCache(models)
+		c.updateCache(models, vision)
 		return c.models
 	}
 
@@ -102,6 +101,14 @@ func (c *ModelsCache) GetModels() []api.Model {
 	return nil
 }
 
+// HasModels returns true if the cache holds at least one model.
+// Unlike GetModels, it never triggers a fetch.
+func (c *ModelsCache) HasModels() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.models) > 0
+}
+
 // SupportsModel checks if a model ID is supported.
 func (c *ModelsCache) SupportsModel(modelID string) bool {
 	c.mu.RLock()
@@ -115,15 +122,24 @@ func (c *ModelsCache) SupportsModel(modelID string) bool {
 	return supported
 }
 
+// SupportsVision reports whether a model accepts image input. Models whose
+// metadata doesn't say (including those from an older disk cache) are assumed to.
+func (c *ModelsCache) SupportsVision(modelID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	supported, known := c.vision[modelID]
+	return !known || supported
+}
+
 // RefreshModels forces a refresh of the models list.
 func (c *ModelsCache) RefreshModels(ctx context.Context) error {
-	models, err := c.fetchFromAPIWithContext(ctx)
+	models, vision, err := c.fetchFromAPIWithContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	c.mu.Lock()
-	c.updateCache(models)
+	c.updateCache(models, vision)
 	c.mu.Unlock()
 
 	go c.saveToDisk()
@@ -131,8 +147,9 @@ func (c *ModelsCache) RefreshModels(ctx context.Context) error {
 }
 
 // updateCache updates the in-memory cache (must hold write lock).
-func (c *ModelsCache) updateCache(models []api.Model) {
+func (c *ModelsCache) updateCache(models []api.Model, vision map[string]bool) {
 	c.models = models
+	c.vision = vision
 	c.modelIDs = make(map[string]bool, len(models))
 	for _, m := range models {
 		c.modelIDs[m.ID] = true
@@ -141,25 +158,26 @@ func (c *ModelsCache) updateCache(models []api.Model) {
 }
 
 // fetchFromAPI fetches models from the Copilot API.
-func (c *ModelsCache) fetchFromAPI() ([]api.Model, error) {
+func (c *ModelsCache) fetchFromAPI() ([]api.Model, map[string]bool, error) {
 	return c.fetchFromAPIWithContext(context.Background())
 }
 
 // fetchFromAPIWithContext fetches models from the Copilot API with context.
-func (c *ModelsCache) fetchFromAPIWithContext(ctx context.Context) ([]api.Model, error) {
+// It also returns the vision support reported in each model's capabilities.
+func (c *ModelsCache) fetchFromAPIWithContext(ctx context.Context) ([]api.Model, map[string]bool, error) {
 	if c.client == nil {
-		return nil, fmt.Errorf("no client configured")
+		return nil, nil, fmt.Errorf("no client configured")
 	}
 
 	// Get valid Copilot token
 	token, err := c.client.getCopilotToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get token: %w", err)
+		return nil, nil, fmt.Errorf("failed to get token: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", CopilotModelsURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.client.modelsURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -169,56 +187,76 @@ func (c *ModelsCache) fetchFromAPIWithContext(ctx context.Context) ([]api.Model,
 	req.Header.Set("Editor-Plugin-Version", EditorPluginVersion)
 	req.Header.Set("Copilot-Integration-Id", CopilotIntegrationID)
 
-	resp, err := c.client.httpClient.Do(req)
+	resp, err := httputil.DoWithTimeout(c.client.httpClient, req, c.client.timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch models: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch models: %w", err)
+	}
+	if err := httputil.DecompressBody(resp); err != nil {
+		return nil, nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("models request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, nil, fmt.Errorf("models request failed with status %d: %s", resp.StatusCode, logging.Redact(string(body)))
 	}
 
 	var response struct {
 		Data []struct {
-			ID          string `json:"id"`
-			Name        string `json:"name"`
-			Version     string `json:"version"`
-			ModelFamily string `json:"model_family"`
-			Vendor      string `json:"vendor"`
+			ID           string `json:"id"`
+			Name         string `json:"name"`
+			Version      string `json:"version"`
+			ModelFamily  string `json:"model_family"`
+			Vendor       string `json:"vendor"`
+			Capabilities struct {
+				Family   string `json:"family"`
+				Supports struct {
+					Vision *bool `json:"vision"`
+				} `json:"supports"`
+			} `json:"capabilities"`
 		} `json:"data"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to parse models response: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse models response: %w", err)
 	}
 
 	models := make([]api.Model, 0, len(response.Data))
+	vision := make(map[string]bool)
 	for _, m := range response.Data {
+		if m.Capabilities.Supports.Vision != nil {
+			vision[m.ID] = *m.Capabilities.Supports.Vision
+		}
 		ownedBy := m.Vendor
 		if ownedBy == "" {
 			ownedBy = "unknown"
 		}
+		family := m.ModelFamily
+		if family == "" {
+			family = m.Capabilities.Family
+		}
 		models = append(models, api.Model{
 			ID:      m.ID,
 			Object:  "model",
 			OwnedBy: ownedBy,
+			Name:    m.Name,
+			Family:  family,
 		})
 	}
 
 	if len(models) == 0 {
-		return nil, fmt.Errorf("no models returned from API")
+		return nil, nil, fmt.Errorf("no models returned from API")
 	}
 
-	return models, nil
+	return models, vision, nil
 }
 
 // Disk cache helpers
 
 type modelsCacheMeta struct {
-	FetchedAt time.Time   `json:"fetched_at"`
-	Models    []api.Model `json:"models"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Models    []api.Model     `json:"models"`
+	Vision    map[string]bool `json:"vision,omitempty"` // absent in caches written before vision checks
 }
 
 func (c *ModelsCache) cacheDir() string {
@@ -244,6 +282,7 @@ func (c *ModelsCache) saveToDisk() {
 	meta := modelsCacheMeta{
 		FetchedAt: c.fetchedAt,
 		Models:    c.models,
+		Vision:    c.vision,
 	}
 	c.mu.RUnlock()
 
@@ -259,17 +298,19 @@ func (c *ModelsCache) saveToDisk() {
 	}
 }
 
-func (c *ModelsCache) loadFromDisk() ([]api.Model, error) {
+// loadFromDisk reads the models cache. Caches written by older versions lack
+// model names, families and vision flags; those fields are simply left empty.
+func (c *ModelsCache) loadFromDisk() ([]api.Model, map[string]bool, error) {
 	cachePath := filepath.Join(c.cacheDir(), "models.json")
 
 	data, err := os.ReadFile(cachePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var meta modelsCacheMeta
 	if err := json.Unmarshal(data, &meta); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Check if disk cache is too old
@@ -280,7 +321,7 @@ func (c *ModelsCache) loadFromDisk() ([]api.Model, error) {
 		)
 	}
 
-	return meta.Models, nil
+	return meta.Models, meta.Vision, nil
 }
 
 // StartBackgroundRefresh starts a goroutine that periodically refreshes the models.
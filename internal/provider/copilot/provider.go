@@ -3,6 +3,8 @@ package copilot
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/auth"
@@ -18,6 +20,9 @@ func init() {
 			DeviceFlowCfg: GetDeviceFlowConfig(),
 			EnvVars:       convertEnvVarDocs(EnvVarDocs()),
 			Factory:       New,
+			ValidateConfig: func() error {
+				return LoadConfig().Validate()
+			},
 		})
 	})
 }
@@ -45,7 +50,10 @@ type Provider struct {
 // New creates a new Copilot provider.
 func New(store *auth.Store) (provider.Provider, error) {
 	cfg := LoadConfig()
-	client := NewClient(store)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	client := NewClient(store, cfg)
 	return &Provider{
 		client:      client,
 		modelsCache: NewModelsCache(client, cfg.ModelsRefresh),
@@ -68,9 +76,25 @@ func (p *Provider) SupportsModel(modelID string) bool {
 	return p.modelsCache.SupportsModel(modelID)
 }
 
+// SupportsTemperature returns true: temperature is forwarded to Copilot.
+func (p *Provider) SupportsTemperature() bool {
+	return true
+}
+
 // ChatCompletion sends a chat completion request.
 func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
-	// Transform messages: convert system role to assistant (Copilot compatibility)
+	// Non-vision models reject images with an opaque upstream 400
+	if hasImageContent(req.Messages) && !p.modelsCache.SupportsVision(req.Model) {
+		return nil, &api.UpstreamError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Model '%s' does not support image inputs. Remove the images or use a vision-capable model", req.Model),
+			Type:       api.ErrorTypeInvalidRequest,
+			Code:       "model_not_vision_capable",
+		}
+	}
+
+	// Transform messages: convert system/developer roles to assistant (Copilot compatibility).
+	// Content is passed through as sent, so image_url.detail reaches the upstream unchanged.
 	messages := transformMessages(req.Messages)
 
 	// Convert provider request to API request for Copilot
@@ -101,12 +125,12 @@ func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatComplet
 	return NewStream(resp, req.Stream), nil
 }
 
-// transformMessages converts system messages to assistant role for Copilot compatibility.
+// transformMessages converts system and developer messages to assistant role for Copilot compatibility.
 func transformMessages(messages []api.Message) []api.Message {
 	result := make([]api.Message, len(messages))
 	for i, msg := range messages {
 		result[i] = msg
-		if msg.Role == "system" {
+		if msg.Role == "system" || msg.Role == "developer" {
 			result[i].Role = "assistant"
 		}
 	}
@@ -120,14 +144,26 @@ func (p *Provider) Init() error {
 	return nil
 }
 
+// Ready returns true once the models list has been loaded.
+func (p *Provider) Ready() bool {
+	return p.modelsCache.HasModels()
+}
+
 // Start begins background tasks.
 func (p *Provider) Start() {
 	p.modelsCache.StartBackgroundRefresh()
+	p.client.StartTokenRefresh()
 }
 
 // Close stops background tasks.
 func (p *Provider) Close() {
 	p.modelsCache.StopBackgroundRefresh()
+	p.client.StopTokenRefresh()
+}
+
+// Health exchanges the GitHub token for a Copilot token.
+func (p *Provider) Health(ctx context.Context) error {
+	return p.client.CheckAuth(ctx)
 }
 
 // RefreshModels forces a refresh of the models list.
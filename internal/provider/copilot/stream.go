@@ -3,11 +3,13 @@ package copilot
 import (
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/logging"
 	"github.com/edgard/opencompat/internal/sse"
 )
 
@@ -48,7 +50,11 @@ func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
 		if s.resp.StatusCode != http.StatusOK {
 			s.done = true
 			body, _ := io.ReadAll(s.resp.Body)
-			s.err = api.NewUpstreamError(s.resp.StatusCode, parseUpstreamError(body))
+			if isQuotaStatus(s.resp.StatusCode) {
+				s.err = quotaError(s.resp.StatusCode, body)
+			} else {
+				s.err = api.NewUpstreamError(s.resp.StatusCode, parseUpstreamError(body))
+			}
 			return nil, s.err
 		}
 
@@ -144,6 +150,26 @@ func normalizeResponse(resp *api.ChatCompletionResponse) {
 	}
 }
 
+// quotaMessage is returned when Copilot refuses service for the account.
+const quotaMessage = "GitHub Copilot subscription required or quota exceeded. Check your plan at https://github.com/settings/copilot"
+
+// isQuotaStatus reports whether a Copilot status code indicates a missing
+// subscription or exhausted quota.
+func isQuotaStatus(statusCode int) bool {
+	return statusCode == http.StatusPaymentRequired || statusCode == http.StatusForbidden
+}
+
+// quotaError builds the error for subscription/quota failures.
+// The upstream body is usually opaque, so it is only logged at debug level.
+func quotaError(statusCode int, body []byte) *api.UpstreamError {
+	slog.Debug("copilot quota or subscription error", "status", statusCode, "body", logging.Truncate(string(body), 500))
+	return &api.UpstreamError{
+		StatusCode: statusCode,
+		Message:    quotaMessage,
+		Code:       "insufficient_quota",
+	}
+}
+
 // parseUpstreamError extracts a meaningful error message from upstream response.
 func parseUpstreamError(body []byte) string {
 	var errResp struct {
@@ -163,10 +189,7 @@ func parseUpstreamError(body []byte) string {
 	}
 
 	if message == "" {
-		bodyStr := string(body)
-		if len(bodyStr) > 500 {
-			bodyStr = bodyStr[:500] + "..."
-		}
+		bodyStr := logging.Truncate(string(body), 500)
 		if bodyStr == "" {
 			return "unknown error"
 		}
@@ -0,0 +1,29 @@
+package mock
+
+import "github.com/edgard/opencompat/internal/config"
+
+// Provider identification
+const ProviderID = "mock"
+
+// Scripted models; the model name selects the response.
+const (
+	ModelEcho        = "echo"         // repeats the last user message
+	ModelToolCall    = "toolcall"     // calls the first declared function tool
+	ModelReasoning   = "reasoning"    // streams reasoning_content, then echoes
+	ModelRefusal     = "refusal"      // refuses
+	ModelError       = "error"        // fails before streaming starts
+	ModelStreamError = "stream-error" // fails after the first chunk
+)
+
+// Scripted text
+const (
+	reasoningText   = "The user wants their message repeated back."
+	refusalText     = "I'm sorry, but I can't help with that."
+	defaultToolName = "mock_tool"
+	emptyEchoText   = "(empty message)"
+)
+
+// Enabled reports whether the mock provider should be registered (OPENCOMPAT_ENABLE_MOCK).
+func Enabled() bool {
+	return config.Load().EnableMock
+}
@@ -0,0 +1,267 @@
+// Package mock implements a credential-free provider with deterministic,
+// scripted responses for end-to-end tests. It is only registered when
+// OPENCOMPAT_ENABLE_MOCK=true.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/provider"
+	"github.com/edgard/opencompat/internal/tokens"
+)
+
+func init() {
+	provider.AddRegistration(func(r *provider.Registry) {
+		if !Enabled() {
+			return
+		}
+		r.RegisterMeta(provider.ProviderMeta{
+			ID:         ProviderID,
+			Name:       "Mock (testing)",
+			AuthMethod: auth.AuthMethodNone,
+			Factory:    New,
+		})
+	})
+}
+
+// modelIDs lists the scripted models in display order.
+var modelIDs = []string{ModelEcho, ModelToolCall, ModelReasoning, ModelRefusal, ModelError, ModelStreamError}
+
+// Provider implements the mock provider.
+type Provider struct{}
+
+// New creates a new mock provider.
+func New(store *auth.Store) (provider.Provider, error) {
+	return &Provider{}, nil
+}
+
+// ID returns the provider identifier.
+func (p *Provider) ID() string {
+	return ProviderID
+}
+
+// Models returns the scripted models.
+func (p *Provider) Models() []api.Model {
+	models := make([]api.Model, len(modelIDs))
+	for i, id := range modelIDs {
+		models[i] = api.Model{ID: id, Object: "model", OwnedBy: ProviderID}
+	}
+	return models
+}
+
+// SupportsModel checks if a model ID is one of the scripted models.
+func (p *Provider) SupportsModel(modelID string) bool {
+	return slices.Contains(modelIDs, modelID)
+}
+
+// Health always succeeds; the mock has no upstream.
+func (p *Provider) Health(ctx context.Context) error {
+	return nil
+}
+
+// ChatCompletion returns the scripted response for the requested model.
+func (p *Provider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
+	if req.Model == ModelError {
+		return nil, &api.UpstreamError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    "Mock upstream error",
+			Type:       api.ErrorTypeServer,
+			Code:       "mock_error",
+		}
+	}
+
+	b := &scriptBuilder{
+		id:      fmt.Sprintf("chatcmpl-mock-%d", time.Now().UnixNano()),
+		created: time.Now().Unix(),
+		model:   req.Model,
+		msg:     &api.Message{Role: "assistant"},
+	}
+	b.chunk(&api.Delta{Role: "assistant"})
+
+	finishReason := "stop"
+	text := lastUserText(req.Messages)
+	switch req.Model {
+	case ModelEcho:
+		b.text(text)
+	case ModelReasoning:
+		b.reasoning(reasoningText)
+		b.text(text)
+	case ModelRefusal:
+		b.msg.Refusal = refusalText
+		b.chunk(&api.Delta{Refusal: refusalText})
+		b.completion += refusalText
+	case ModelToolCall:
+		b.toolCall(toolName(req.Tools), text)
+		finishReason = "tool_calls"
+	case ModelStreamError:
+		b.text(text)
+		return b.stream(req, "", &api.UpstreamError{
+			StatusCode: http.StatusBadGateway,
+			Message:    "Mock stream interrupted",
+			Type:       api.ErrorTypeServer,
+			Code:       "mock_stream_error",
+		}), nil
+	}
+
+	return b.stream(req, finishReason, nil), nil
+}
+
+// lastUserText returns the text of the last user message, or a placeholder.
+func lastUserText(messages []api.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		if text := messages[i].GetContentString(); text != "" {
+			return text
+		}
+		var parts []string
+		for _, part := range messages[i].GetContentParts() {
+			if part.Type == "text" && part.Text != "" {
+				parts = append(parts, part.Text)
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, "\n")
+		}
+	}
+	return emptyEchoText
+}
+
+// toolName returns the first declared function name, or a placeholder.
+func toolName(tools []api.Tool) string {
+	for _, tool := range tools {
+		if tool.Type == "function" && tool.Function.Name != "" {
+			return tool.Function.Name
+		}
+	}
+	return defaultToolName
+}
+
+// scriptBuilder accumulates the chunks of a scripted response along with the
+// equivalent non-streaming message.
+type scriptBuilder struct {
+	id         string
+	created    int64
+	model      string
+	chunks     []*api.ChatCompletionChunk
+	msg        *api.Message
+	content    string
+	completion string // all generated text, for the usage estimate
+}
+
+// chunk appends a chunk carrying delta.
+func (b *scriptBuilder) chunk(delta *api.Delta) {
+	b.chunks = append(b.chunks, &api.ChatCompletionChunk{
+		ID:      b.id,
+		Object:  "chat.completion.chunk",
+		Created: b.created,
+		Model:   b.model,
+		Choices: []api.Choice{{Index: 0, Delta: delta}},
+	})
+}
+
+// text streams content one word at a time.
+func (b *scriptBuilder) text(text string) {
+	for _, word := range splitWords(text) {
+		b.chunk(&api.Delta{Content: word})
+	}
+	b.content += text
+	b.completion += text
+}
+
+// reasoning streams reasoning_content one word at a time.
+func (b *scriptBuilder) reasoning(text string) {
+	for _, word := range splitWords(text) {
+		b.chunk(&api.Delta{ReasoningContent: word})
+	}
+	b.msg.ReasoningContent += text
+	b.completion += text
+}
+
+// toolCall emits a single complete tool call whose arguments carry text.
+func (b *scriptBuilder) toolCall(name, text string) {
+	args, _ := json.Marshal(map[string]string{"input": text})
+	call := api.ToolCall{
+		ID:       "call_mock_0",
+		Type:     "function",
+		Function: api.FunctionCall{Name: name, Arguments: string(args)},
+	}
+	streamed := call
+	streamed.Index = new(int)
+	b.chunk(&api.Delta{ToolCalls: []api.ToolCall{streamed}})
+	b.msg.ToolCalls = append(b.msg.ToolCalls, call)
+	b.completion += name + string(args)
+}
+
+// stream finalizes the script. An empty finishReason omits the final chunk;
+// err, when set, is reported by Err after the chunks are consumed.
+func (b *scriptBuilder) stream(req *provider.ChatCompletionRequest, finishReason string, err error) *Stream {
+	prompt := tokens.EstimateMessages(req.Model, req.Messages, req.Tools)
+	completion := tokens.Estimate(req.Model, b.completion)
+	usage := &api.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+
+	s := &Stream{chunks: b.chunks, err: err}
+	if err != nil {
+		return s
+	}
+
+	reason := finishReason
+	s.chunks = append(s.chunks, &api.ChatCompletionChunk{
+		ID:      b.id,
+		Object:  "chat.completion.chunk",
+		Created: b.created,
+		Model:   b.model,
+		Choices: []api.Choice{{Index: 0, Delta: &api.Delta{}, FinishReason: &reason}},
+	})
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		s.chunks = append(s.chunks, &api.ChatCompletionChunk{
+			ID:      b.id,
+			Object:  "chat.completion.chunk",
+			Created: b.created,
+			Model:   b.model,
+			Choices: []api.Choice{},
+			Usage:   usage,
+		})
+	}
+
+	b.msg.SetContentString(b.content)
+	s.response = &api.ChatCompletionResponse{
+		ID:      b.id,
+		Object:  "chat.completion",
+		Created: b.created,
+		Model:   b.model,
+		Choices: []api.Choice{{Index: 0, Message: b.msg, FinishReason: &reason}},
+		Usage:   usage,
+	}
+	return s
+}
+
+// splitWords splits text into words, keeping each word's trailing whitespace
+// so the pieces concatenate back to the original.
+func splitWords(text string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i-1] == ' ' && text[i] != ' ' {
+			words = append(words, text[start:i])
+			start = i
+		}
+	}
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+	return words
+}
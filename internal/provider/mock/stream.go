@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"io"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// Stream replays a precomputed script.
+type Stream struct {
+	chunks   []*api.ChatCompletionChunk
+	response *api.ChatCompletionResponse
+	err      error // reported once all chunks are consumed
+	next     int
+}
+
+// Next returns the next scripted chunk.
+func (s *Stream) Next() (*api.ChatCompletionChunk, error) {
+	if s.next >= len(s.chunks) {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[s.next]
+	s.next++
+	return chunk, nil
+}
+
+// Response returns the non-streaming response (nil for failing scripts).
+func (s *Stream) Response() *api.ChatCompletionResponse {
+	return s.response
+}
+
+// Err returns the scripted error once the stream is exhausted.
+func (s *Stream) Err() error {
+	if s.next < len(s.chunks) {
+		return nil
+	}
+	return s.err
+}
+
+// Close is a no-op; the mock holds no resources.
+func (s *Stream) Close() error {
+	return nil
+}
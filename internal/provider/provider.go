@@ -4,6 +4,7 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/auth"
@@ -35,9 +36,10 @@ type ChatCompletionRequest struct {
 	Stream           bool
 	StreamOptions    *api.StreamOptions
 	ReasoningEffort  string
-	ReasoningSummary string // Override via X-Reasoning-Summary header
-	ReasoningCompat  string // Override via X-Reasoning-Compat header
-	TextVerbosity    string // Override via X-Text-Verbosity header
+	ReasoningSummary string // Override via reasoning_summary field or X-Reasoning-Summary header
+	ReasoningCompat  string // Override via reasoning_compat field or X-Reasoning-Compat header
+	TextVerbosity    string // Override via text_verbosity field or X-Text-Verbosity header
+	ConversationID   string // Client conversation id from X-OpenCompat-Conversation header or user field
 
 	// Optional parameters (supported by some providers like Copilot)
 	Temperature         *float64
@@ -99,3 +101,86 @@ type Refresher interface {
 	// RefreshModels forces a refresh of the provider's models or data.
 	RefreshModels(ctx context.Context) error
 }
+
+// InstructionsVersioner is an optional interface for providers whose system
+// instructions are fetched from a versioned upstream release.
+type InstructionsVersioner interface {
+	// InstructionsVersion returns the release in use ("" if unknown).
+	InstructionsVersion() string
+}
+
+// TemperatureSupporter is an optional interface for providers that pass
+// temperature through to the upstream model.
+type TemperatureSupporter interface {
+	// SupportsTemperature returns true if temperature reaches the upstream.
+	SupportsTemperature() bool
+}
+
+// HealthChecker is an optional interface for providers that can verify
+// upstream access with a minimal authenticated call.
+type HealthChecker interface {
+	// Health returns nil if the provider's credentials are accepted upstream.
+	Health(ctx context.Context) error
+}
+
+// AliasLister is an optional interface for providers that accept model
+// aliases in addition to the IDs returned by Models().
+type AliasLister interface {
+	// ModelAliases returns the accepted alias names (without provider prefix).
+	ModelAliases() []string
+}
+
+// ModelNormalizer is an optional interface for providers that resolve
+// aliases or suffixes to a canonical upstream model.
+type ModelNormalizer interface {
+	// NormalizeModel returns the canonical model ID for a model ID without provider prefix.
+	NormalizeModel(modelID string) string
+}
+
+// RequestPreviewer is an optional interface for providers that can show the
+// upstream request a chat completion would send, without sending it.
+type RequestPreviewer interface {
+	// PreviewRequest returns the transformed upstream request body.
+	PreviewRequest(req *ChatCompletionRequest) (any, error)
+}
+
+// InstructionsInfo describes the system instructions a provider injects for a model.
+type InstructionsInfo struct {
+	Model      string    `json:"model"`
+	PromptFile string    `json:"prompt_file"`
+	Source     string    `json:"source"` // where the content came from (provider-specific)
+	Version    string    `json:"version,omitempty"`
+	FetchedAt  time.Time `json:"fetched_at,omitzero"`
+	Content    string    `json:"content"`
+}
+
+// InstructionsDescriber is an optional interface for providers that inject
+// system instructions into every request.
+type InstructionsDescriber interface {
+	// DescribeInstructions returns the instructions for a model ID (without provider prefix).
+	DescribeInstructions(modelID string) (*InstructionsInfo, error)
+}
+
+// EffortValidator is an optional interface for providers that can check a
+// reasoning effort against a model's supported range instead of clamping it.
+type EffortValidator interface {
+	// ValidateEffort returns an error describing the allowed range if effort
+	// (or an effort suffix on modelID) is invalid or unsupported for the model.
+	ValidateEffort(modelID, effort string) error
+}
+
+// ContextLimiter is an optional interface for providers that know their
+// models' context windows.
+type ContextLimiter interface {
+	// ContextWindow returns the total token limit (prompt plus completion)
+	// for a model ID without provider prefix, or 0 if unknown.
+	ContextWindow(modelID string) int
+}
+
+// ReadyChecker is an optional interface for providers that can report
+// whether they are ready to serve requests (e.g., instructions loaded).
+// Providers that don't implement it are considered ready once active.
+type ReadyChecker interface {
+	// Ready returns true if the provider can serve requests.
+	Ready() bool
+}
@@ -1,9 +1,12 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/edgard/opencompat/internal/api"
 	"github.com/edgard/opencompat/internal/auth"
@@ -21,19 +24,31 @@ type EnvVarDoc struct {
 
 // ProviderMeta contains metadata about a provider type.
 type ProviderMeta struct {
-	ID            string
-	Name          string // Human-readable name (e.g., "ChatGPT")
-	AuthMethod    auth.AuthMethod
-	OAuthCfg      *auth.OAuthConfig      // OAuth configuration (for OAuth providers)
-	DeviceFlowCfg *auth.DeviceFlowConfig // Device flow config (for device flow providers)
-	EnvVars       []EnvVarDoc            // Environment variable documentation
-	Factory       ProviderFactory
+	ID             string
+	Name           string // Human-readable name (e.g., "ChatGPT")
+	AuthMethod     auth.AuthMethod
+	OAuthCfg       *auth.OAuthConfig      // OAuth configuration (for OAuth providers)
+	DeviceFlowCfg  *auth.DeviceFlowConfig // Device flow config (for device flow providers)
+	EnvVars        []EnvVarDoc            // Environment variable documentation
+	APIKeyEnv      string                 // Env var read for unattended login (API key providers)
+	Factory        ProviderFactory
+	ValidateConfig func() error // Checks the provider's settings (optional)
+}
+
+// LoggedIn reports whether the provider has credentials. Providers that need
+// none are always logged in.
+func (m ProviderMeta) LoggedIn(store *auth.Store) bool {
+	return m.AuthMethod == auth.AuthMethodNone || store.IsLoggedIn(m.ID)
 }
 
 // Registry manages providers.
+// Providers can be activated while serving, so access to them is locked.
 type Registry struct {
-	metas     map[string]ProviderMeta // All known providers
-	providers map[string]Provider     // Active providers (logged in)
+	metas           map[string]ProviderMeta // All known providers
+	mu              sync.RWMutex            // guards providers
+	providers       map[string]Provider     // Active providers (logged in)
+	defaultProvider string                  // Provider tried for models without a prefix (empty = none)
+	disabled        map[string]bool         // Providers skipped by Initialize
 }
 
 // NewRegistry creates a new registry.
@@ -41,6 +56,7 @@ func NewRegistry() *Registry {
 	return &Registry{
 		metas:     make(map[string]ProviderMeta),
 		providers: make(map[string]Provider),
+		disabled:  make(map[string]bool),
 	}
 }
 
@@ -67,21 +83,72 @@ func (r *Registry) RegisterMeta(meta ProviderMeta) {
 	r.metas[meta.ID] = meta
 }
 
-// Initialize creates provider instances for all logged-in providers.
+// DisableProviders prevents providers from being activated by Initialize.
+// Metadata stays registered so login and logout keep working.
+func (r *Registry) DisableProviders(providerIDs []string) {
+	for _, id := range providerIDs {
+		r.disabled[strings.ToLower(id)] = true
+	}
+}
+
+// IsDisabled returns true if a provider was disabled via DisableProviders.
+func (r *Registry) IsDisabled(providerID string) bool {
+	return r.disabled[providerID]
+}
+
+// ValidateConfigs checks the settings of every registered provider, whether
+// or not it is logged in or disabled, so a bad value fails at startup.
+func (r *Registry) ValidateConfigs() error {
+	for _, meta := range r.ListMetas() {
+		if meta.ValidateConfig == nil {
+			continue
+		}
+		if err := meta.ValidateConfig(); err != nil {
+			return fmt.Errorf("%s: %w", meta.ID, err)
+		}
+	}
+	return nil
+}
+
+// Initialize creates provider instances for all logged-in, enabled providers.
 func (r *Registry) Initialize(store *auth.Store) error {
-	for id, meta := range r.metas {
-		if !store.IsLoggedIn(id) {
-			continue // Silent skip - provider not logged in
+	_, err := r.ActivateNew(store)
+	return err
+}
+
+// ActivateNew creates instances for logged-in, enabled providers that are not
+// active yet and returns their IDs, sorted. It is safe to call while serving,
+// e.g. after a login on a server started without providers.
+func (r *Registry) ActivateNew(store *auth.Store) ([]string, error) {
+	var activated []string
+	for _, meta := range r.ListMetas() {
+		if _, active := r.GetActiveProvider(meta.ID); active || !meta.LoggedIn(store) || r.disabled[meta.ID] {
+			continue // Silent skip - already active, not logged in or disabled
 		}
 
 		p, err := meta.Factory(store)
 		if err != nil {
-			return fmt.Errorf("failed to initialize provider %s: %w", id, err)
+			return activated, fmt.Errorf("failed to initialize provider %s: %w", meta.ID, err)
 		}
 		if p != nil {
-			r.providers[id] = p
+			r.mu.Lock()
+			r.providers[meta.ID] = p
+			r.mu.Unlock()
+			activated = append(activated, meta.ID)
 		}
 	}
+	return activated, nil
+}
+
+// SetDefaultProvider sets the provider used for models without a provider prefix.
+// An empty ID disables default routing.
+func (r *Registry) SetDefaultProvider(providerID string) error {
+	if providerID != "" {
+		if _, known := r.metas[providerID]; !known {
+			return fmt.Errorf("unknown default provider: %s", providerID)
+		}
+	}
+	r.defaultProvider = providerID
 	return nil
 }
 
@@ -113,14 +180,44 @@ func ParseModel(model string) (providerID, modelID string, err error) {
 	return model[:idx], model[idx+1:], nil
 }
 
+// resolveModel splits a model string into provider and model IDs.
+// A model without a prefix is routed to the default provider when it supports
+// the model and no other active provider does.
+func (r *Registry) resolveModel(model string) (providerID, modelID string, err error) {
+	providerID, modelID, err = ParseModel(model)
+	if err == nil || r.defaultProvider == "" {
+		return providerID, modelID, err
+	}
+
+	providers := r.active()
+	def, ok := providers[r.defaultProvider]
+	if !ok || !def.SupportsModel(model) {
+		return "", "", err
+	}
+
+	var supporting []string
+	for id, p := range providers {
+		if p.SupportsModel(model) {
+			supporting = append(supporting, id)
+		}
+	}
+	if len(supporting) > 1 {
+		sort.Strings(supporting)
+		return "", "", fmt.Errorf("model %s is supported by multiple providers (%s); model must include provider prefix (e.g., '%s/%s')",
+			model, strings.Join(supporting, ", "), r.defaultProvider, model)
+	}
+
+	return r.defaultProvider, model, nil
+}
+
 // GetProvider returns the provider for a model string.
 func (r *Registry) GetProvider(model string) (Provider, string, error) {
-	providerID, modelID, err := ParseModel(model)
+	providerID, modelID, err := r.resolveModel(model)
 	if err != nil {
 		return nil, "", err
 	}
 
-	p, ok := r.providers[providerID]
+	p, ok := r.GetActiveProvider(providerID)
 	if !ok {
 		// Check if provider is known but not logged in
 		if _, known := r.metas[providerID]; known {
@@ -135,7 +232,7 @@ func (r *Registry) GetProvider(model string) (Provider, string, error) {
 // AllModels returns all models from all active providers, prefixed with provider ID.
 func (r *Registry) AllModels() []api.Model {
 	var models []api.Model
-	for _, p := range r.providers {
+	for _, p := range r.active() {
 		for _, m := range p.Models() {
 			// Prefix model ID with provider
 			prefixed := m
@@ -150,14 +247,31 @@ func (r *Registry) AllModels() []api.Model {
 	return models
 }
 
-// IsModelSupported checks if a model (with prefix) is supported.
+// ModelNames returns every prefixed model ID and alias accepted by active providers.
+func (r *Registry) ModelNames() []string {
+	var names []string
+	for _, m := range r.AllModels() {
+		names = append(names, m.ID)
+	}
+	for id, p := range r.active() {
+		if al, ok := p.(AliasLister); ok {
+			for _, alias := range al.ModelAliases() {
+				names = append(names, id+"/"+alias)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsModelSupported checks if a model (with prefix, or routed to the default provider) is supported.
 func (r *Registry) IsModelSupported(model string) bool {
-	providerID, modelID, err := ParseModel(model)
+	providerID, modelID, err := r.resolveModel(model)
 	if err != nil {
 		return false
 	}
 
-	p, ok := r.providers[providerID]
+	p, ok := r.GetActiveProvider(providerID)
 	if !ok {
 		return false
 	}
@@ -167,18 +281,90 @@ func (r *Registry) IsModelSupported(model string) bool {
 
 // HasProviders returns true if at least one provider is active.
 func (r *Registry) HasProviders() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	return len(r.providers) > 0
 }
 
+// ReadyStatus returns the readiness of each active provider, keyed by provider ID.
+func (r *Registry) ReadyStatus() map[string]bool {
+	providers := r.active()
+	status := make(map[string]bool, len(providers))
+	for id, p := range providers {
+		ready := true
+		if rc, ok := p.(ReadyChecker); ok {
+			ready = rc.Ready()
+		}
+		status[id] = ready
+	}
+	return status
+}
+
+// InstructionsVersions returns the instructions release of each active
+// provider implementing InstructionsVersioner, keyed by provider ID.
+// Providers without a known release are omitted.
+func (r *Registry) InstructionsVersions() map[string]string {
+	versions := make(map[string]string)
+	for id, p := range r.active() {
+		if iv, ok := p.(InstructionsVersioner); ok {
+			if v := iv.InstructionsVersion(); v != "" {
+				versions[id] = v
+			}
+		}
+	}
+	return versions
+}
+
+// HealthStatus runs health checks on all active providers, keyed by provider ID.
+// Providers that don't implement HealthChecker report nil (healthy).
+func (r *Registry) HealthStatus(ctx context.Context) map[string]error {
+	providers := r.active()
+	status := make(map[string]error, len(providers))
+	for id, p := range providers {
+		var err error
+		if hc, ok := p.(HealthChecker); ok {
+			err = hc.Health(ctx)
+		}
+		status[id] = err
+	}
+	return status
+}
+
+// RefreshAll forces a refresh on all active providers that implement Refresher,
+// keyed by provider ID. Providers without Refresher are omitted.
+func (r *Registry) RefreshAll(ctx context.Context) map[string]error {
+	providers := r.active()
+	results := make(map[string]error, len(providers))
+	for id, p := range providers {
+		if refresher, ok := p.(Refresher); ok {
+			results[id] = refresher.RefreshModels(ctx)
+		}
+	}
+	return results
+}
+
+// active returns a snapshot of the active providers, so callers can iterate
+// (and make upstream calls) without holding the lock.
+func (r *Registry) active() map[string]Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return maps.Clone(r.providers)
+}
+
 // GetActiveProvider returns an active provider by ID.
 func (r *Registry) GetActiveProvider(providerID string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	p, ok := r.providers[providerID]
 	return p, ok
 }
 
 // CloseAll closes all active providers that implement LifecycleProvider.
 func (r *Registry) CloseAll() {
-	for _, p := range r.providers {
+	for _, p := range r.active() {
 		if lp, ok := p.(LifecycleProvider); ok {
 			lp.Close()
 		}
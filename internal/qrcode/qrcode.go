@@ -0,0 +1,492 @@
+// Package qrcode renders short strings as QR codes for display in a terminal.
+//
+// Only what device-flow login needs is implemented: byte mode, error
+// correction level L and versions 1-10 (up to 271 bytes), following
+// ISO/IEC 18004.
+package qrcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// errTooLong is returned when the input does not fit in version 10.
+var errTooLong = errors.New("qrcode: data too long")
+
+// version describes the error correction layout of one QR version at level L.
+type version struct {
+	ecPerBlock int   // error correction codewords per block
+	blocks     []int // data codewords of each block
+	alignment  []int // alignment pattern center coordinates
+}
+
+// versions holds the level L layouts for versions 1-10 (index 0 is version 1).
+var versions = []version{
+	{7, []int{19}, nil},
+	{10, []int{34}, []int{6, 18}},
+	{15, []int{55}, []int{6, 22}},
+	{20, []int{80}, []int{6, 26}},
+	{26, []int{108}, []int{6, 30}},
+	{18, []int{68, 68}, []int{6, 34}},
+	{20, []int{78, 78}, []int{6, 22, 38}},
+	{24, []int{97, 97}, []int{6, 24, 42}},
+	{30, []int{116, 116}, []int{6, 26, 46}},
+	{18, []int{68, 68, 69, 69}, []int{6, 28, 50}},
+}
+
+// dataCapacity returns the number of data codewords of a version.
+func (v version) dataCapacity() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// Code is an encoded QR symbol; Modules[y][x] is true for dark modules.
+type Code struct {
+	Modules [][]bool
+}
+
+// Encode encodes data in byte mode at the smallest version that fits.
+func Encode(data string) (*Code, error) {
+	for i, v := range versions {
+		ver := i + 1
+		countBits := 8
+		if ver >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*v.dataCapacity() {
+			continue
+		}
+		codewords := interleave(v, encodeData(data, countBits, v.dataCapacity()))
+		return &Code{Modules: buildMatrix(ver, v, codewords)}, nil
+	}
+	return nil, errTooLong
+}
+
+// String renders the code with Unicode half blocks, two module rows per text
+// line, inside a two-module quiet zone. Colors are inverted (dark modules are
+// left as background) so the code scans on dark-themed terminals.
+func (c *Code) String() string {
+	const quiet = 2
+	size := len(c.Modules)
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < size && y < size && c.Modules[y][x]
+	}
+
+	var sb strings.Builder
+	total := size + 2*quiet
+	for y := 0; y < total; y += 2 {
+		for x := 0; x < total; x++ {
+			top, bottom := dark(x, y), dark(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune(' ')
+			case top:
+				sb.WriteRune('▄')
+			case bottom:
+				sb.WriteRune('▀')
+			default:
+				sb.WriteRune('█')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// bitBuffer accumulates a big-endian bit stream.
+type bitBuffer struct {
+	bytes []byte
+	n     int
+}
+
+func (b *bitBuffer) put(value, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if value>>i&1 == 1 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// encodeData builds the padded data codewords for byte mode.
+func encodeData(data string, countBits, capacity int) []byte {
+	var buf bitBuffer
+	buf.put(0b0100, 4) // byte mode
+	buf.put(len(data), countBits)
+	for i := 0; i < len(data); i++ {
+		buf.put(int(data[i]), 8)
+	}
+
+	// Terminator of up to four zero bits, then zero bits to a byte boundary
+	buf.put(0, min(4, capacity*8-buf.n))
+	if rem := buf.n % 8; rem != 0 {
+		buf.put(0, 8-rem)
+	}
+
+	for pad := 0; len(buf.bytes) < capacity; pad++ {
+		if pad%2 == 0 {
+			buf.bytes = append(buf.bytes, 0xEC)
+		} else {
+			buf.bytes = append(buf.bytes, 0x11)
+		}
+	}
+	return buf.bytes
+}
+
+// interleave splits data into blocks, appends Reed-Solomon error correction
+// and interleaves the codewords in transmission order.
+func interleave(v version, data []byte) []byte {
+	var dataBlocks, ecBlocks [][]byte
+	maxData := 0
+	for _, n := range v.blocks {
+		block := data[:n]
+		data = data[n:]
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, reedSolomon(block, v.ecPerBlock))
+		maxData = max(maxData, n)
+	}
+
+	var out []byte
+	for i := range maxData {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := range v.ecPerBlock {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+// GF(256) tables for the QR field polynomial x^8+x^4+x^3+x^2+1.
+var gfExp, gfLog = func() (exp [512]byte, log [256]byte) {
+	x := 1
+	for i := range 255 {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// reedSolomon returns n error correction codewords for data.
+func reedSolomon(data []byte, n int) []byte {
+	// Generator polynomial: product of (x - a^i) for i in [0, n), highest degree first
+	gen := []byte{1}
+	for i := range n {
+		next := make([]byte, len(gen)+1)
+		for j, c := range gen {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfExp[i])
+		}
+		gen = next
+	}
+
+	rem := make([]byte, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range n {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return rem
+}
+
+// matrix is a symbol under construction; reserved marks function modules.
+type matrix struct {
+	size     int
+	dark     [][]bool
+	reserved [][]bool
+}
+
+func newMatrix(size int) *matrix {
+	m := &matrix{size: size, dark: make([][]bool, size), reserved: make([][]bool, size)}
+	for i := range size {
+		m.dark[i] = make([]bool, size)
+		m.reserved[i] = make([]bool, size)
+	}
+	return m
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.reserved[y][x] = true
+}
+
+// buildMatrix places function patterns and codewords, then applies the
+// mask with the lowest penalty.
+func buildMatrix(ver int, v version, codewords []byte) [][]bool {
+	size := 17 + 4*ver
+	m := newMatrix(size)
+
+	// Finder patterns with separators
+	for _, c := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := -1; dy <= 7; dy++ {
+			for dx := -1; dx <= 7; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				ring := max(abs(dx-3), abs(dy-3))
+				m.set(x, y, ring != 2 && ring != 4)
+			}
+		}
+	}
+
+	// Alignment patterns, skipping those overlapping the finders
+	for _, cy := range v.alignment {
+		for _, cx := range v.alignment {
+			if m.reserved[cy][cx] {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Timing patterns
+	for i := 8; i < size-8; i++ {
+		m.set(i, 6, i%2 == 0)
+		m.set(6, i, i%2 == 0)
+	}
+
+	// Reserve format and version areas (filled after masking) and the dark module
+	m.placeFormat(0)
+	if ver >= 7 {
+		m.placeVersion(ver)
+	}
+	m.set(8, size-8, true)
+
+	m.placeData(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		m.applyMask(mask)
+		m.placeFormat(mask)
+		if p := m.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // undo
+	}
+	m.applyMask(best)
+	m.placeFormat(best)
+	return m.dark
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// placeData fills the non-reserved modules in the standard zigzag order.
+func (m *matrix) placeData(codewords []byte) {
+	bit := 0
+	total := len(codewords) * 8
+	upward := true
+	for right := m.size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right-- // skip the vertical timing pattern
+		}
+		for i := range m.size {
+			y := i
+			if upward {
+				y = m.size - 1 - i
+			}
+			for dx := range 2 {
+				x := right - dx
+				if m.reserved[y][x] {
+					continue
+				}
+				if bit < total {
+					m.dark[y][x] = codewords[bit/8]>>(7-bit%8)&1 == 1
+				}
+				bit++
+			}
+		}
+		upward = !upward
+	}
+}
+
+// maskFuncs are the eight data mask conditions, indexed by mask pattern.
+var maskFuncs = []func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (y/2+x/3)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// applyMask XORs a mask over the data modules; applying it twice undoes it.
+func (m *matrix) applyMask(mask int) {
+	fn := maskFuncs[mask]
+	for y := range m.size {
+		for x := range m.size {
+			if !m.reserved[y][x] && fn(x, y) {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// bch returns value with its BCH remainder appended, for format and version info.
+func bch(value, poly, polyBits, dataBits int) int {
+	rem := value << (polyBits - 1)
+	for i := dataBits + polyBits - 2; i >= polyBits-1; i-- {
+		if rem>>i&1 == 1 {
+			rem ^= poly << (i - polyBits + 1)
+		}
+	}
+	return value<<(polyBits-1) | rem
+}
+
+// placeFormat writes the 15-bit format information (level L, given mask) in
+// both copies around the finder patterns.
+func (m *matrix) placeFormat(mask int) {
+	const levelL = 0b01
+	bits := bch(levelL<<3|mask, 0x537, 11, 5) ^ 0x5412
+
+	for i := range 15 {
+		dark := bits>>i&1 == 1
+
+		// Copy around the top-left finder
+		switch {
+		case i < 6:
+			m.set(8, i, dark)
+		case i < 8:
+			m.set(8, i+1, dark)
+		case i == 8:
+			m.set(7, 8, dark)
+		default:
+			m.set(14-i, 8, dark)
+		}
+
+		// Copy split between the bottom-left and top-right finders
+		if i < 8 {
+			m.set(m.size-1-i, 8, dark)
+		} else {
+			m.set(8, m.size-15+i, dark)
+		}
+	}
+}
+
+// placeVersion writes the 18-bit version information (versions 7 and up).
+func (m *matrix) placeVersion(ver int) {
+	bits := bch(ver, 0x1F25, 13, 6)
+	for i := range 18 {
+		dark := bits>>i&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// finderLike is the dark-light-dark-dark-dark-light-dark run penalized by rule 3.
+var finderLike = []bool{true, false, true, true, true, false, true}
+
+// penalty scores the symbol using the four ISO/IEC 18004 mask evaluation rules.
+func (m *matrix) penalty() int {
+	score := 0
+	n := m.size
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return m.dark[x][y]
+		}
+		return m.dark[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := range n {
+			// Rule 1: runs of five or more same-colored modules
+			run := 1
+			for x := 1; x < n; x++ {
+				if at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+
+			// Rule 3: finder-like 1:1:3:1:1 patterns with four light modules on a side
+			light := func(from, to int) bool {
+				for x := max(from, 0); x < min(to, n); x++ {
+					if at(x, y, vertical) {
+						return false
+					}
+				}
+				return true
+			}
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for i, want := range finderLike {
+					if at(x+i, y, vertical) != want {
+						match = false
+						break
+					}
+				}
+				if match && (light(x-4, x) || light(x+7, x+11)) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	// Rule 2: 2x2 blocks of one color
+	dark := 0
+	for y := range n {
+		for x := range n {
+			if m.dark[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := m.dark[y][x]
+				if m.dark[y][x+1] == c && m.dark[y+1][x] == c && m.dark[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+
+	// Rule 4: deviation of the dark module ratio from 50%
+	score += abs(dark*20-n*n*10) / (n * n) * 10
+	return score
+}
@@ -0,0 +1,144 @@
+package qrcode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// deviceURLSymbol is "https://github.com/login/device" at version 2, level L,
+// mask 7, as produced by Kazuhiko Arase's reference QRCode for JavaScript.
+var deviceURLSymbol = []string{
+	"#######...##.###..#######",
+	"#.....#.#.###.##..#.....#",
+	"#.###.#.#..##.##..#.###.#",
+	"#.###.#...#.......#.###.#",
+	"#.###.#.#..#..###.#.###.#",
+	"#.....#.###....##.#.....#",
+	"#######.#.#.#.#.#.#######",
+	"........##..#.#.#........",
+	"##.#..##..##.##.#.###.##.",
+	".#.###.##...#...###.....#",
+	"####.##.###..#.........##",
+	".##.#..##...###.##..#....",
+	"#..#..#.#...##.####..#.##",
+	".....#....#.##..#.##.##.#",
+	"#..##.#....####.#.###.#.#",
+	".#.#.....#.####..#..#..#.",
+	"#######.#...#...#######..",
+	"........#########...##..#",
+	"#######.###..#.##.#.##.##",
+	"#.....#.....#.#.#...###.#",
+	"#.###.#..##.###.######...",
+	"#.###.#.#..........####..",
+	"#.###.#...#.####...##.#.#",
+	"#.....#.######..#.#..#...",
+	"#######.#..###.##.##...##",
+}
+
+func TestEncodeMatchesReference(t *testing.T) {
+	code, err := Encode("https://github.com/login/device")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if len(code.Modules) != len(deviceURLSymbol) {
+		t.Fatalf("size = %d, want %d", len(code.Modules), len(deviceURLSymbol))
+	}
+	for y, row := range code.Modules {
+		var sb strings.Builder
+		for _, dark := range row {
+			if dark {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		if got := sb.String(); got != deviceURLSymbol[y] {
+			t.Errorf("row %2d = %s\n        want %s", y, got, deviceURLSymbol[y])
+		}
+	}
+}
+
+func TestEncodeVersionSize(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		size int
+	}{
+		{name: "version 1", data: "A", size: 21},
+		{name: "version 1 full", data: strings.Repeat("a", 17), size: 21},
+		{name: "version 2", data: strings.Repeat("a", 18), size: 25},
+		{name: "version 10 full", data: strings.Repeat("a", 271), size: 57},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.data)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if len(code.Modules) != tt.size {
+				t.Errorf("size = %d, want %d", len(code.Modules), tt.size)
+			}
+			for y, row := range code.Modules {
+				if len(row) != tt.size {
+					t.Fatalf("row %d has %d modules, want %d", y, len(row), tt.size)
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceFormat(t *testing.T) {
+	// Level L format information from ISO/IEC 18004 Annex C, by mask
+	want := []int{
+		0b111011111000100,
+		0b111001011110011,
+		0b111110110101010,
+		0b111100010011101,
+		0b110011000101111,
+		0b110001100011000,
+		0b110110001000001,
+		0b110100101110110,
+	}
+	for mask, bits := range want {
+		m := newMatrix(21)
+		m.placeFormat(mask)
+
+		var first, second int
+		for i := range 15 {
+			var x, y int
+			switch {
+			case i < 6:
+				x, y = 8, i
+			case i < 8:
+				x, y = 8, i+1
+			case i == 8:
+				x, y = 7, 8
+			default:
+				x, y = 14-i, 8
+			}
+			if m.dark[y][x] {
+				first |= 1 << i
+			}
+			if i < 8 {
+				x, y = m.size-1-i, 8
+			} else {
+				x, y = 8, m.size-15+i
+			}
+			if m.dark[y][x] {
+				second |= 1 << i
+			}
+		}
+		if first != bits || second != bits {
+			t.Errorf("mask %d format = %015b / %015b, want %015b", mask, first, second, bits)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	for _, n := range []int{272, 4096} {
+		if _, err := Encode(strings.Repeat("a", n)); !errors.Is(err, errTooLong) {
+			t.Errorf("Encode(%d bytes) error = %v, want errTooLong", n, err)
+		}
+	}
+}
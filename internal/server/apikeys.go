@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// bearerToken returns the token from an "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// lookupAPIKey returns the model prefixes allowed for key.
+// Keys are compared in constant time so response timing does not leak them.
+func lookupAPIKey(keys map[string][]string, key string) ([]string, bool) {
+	if key == "" {
+		return nil, false
+	}
+	var (
+		allowed []string
+		found   bool
+	)
+	for candidate, prefixes := range keys {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			allowed, found = prefixes, true
+		}
+	}
+	return allowed, found
+}
+
+// authorizeKey resolves the API key sent with the request when OPENCOMPAT_API_KEYS
+// is set and returns its allowed model prefixes (nil = every model).
+// It writes a 401 and returns false if the key is missing or unknown.
+func (h *Handlers) authorizeKey(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	if h.cfg.APIKeys == nil {
+		return nil, true
+	}
+	allowed, ok := lookupAPIKey(h.cfg.APIKeys, bearerToken(r))
+	if !ok {
+		code := "invalid_api_key"
+		api.WriteError(w, http.StatusUnauthorized, api.ErrorTypeAuthentication,
+			"Missing or invalid API key; send it as a Bearer token in the Authorization header", &code, nil)
+		return nil, false
+	}
+	return allowed, true
+}
+
+// modelAllowed reports whether a provider/model name matches one of the allowed
+// prefixes. An empty list allows every model.
+func modelAllowed(allowed []string, model string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(allowed, func(prefix string) bool {
+		return strings.HasPrefix(model, prefix)
+	})
+}
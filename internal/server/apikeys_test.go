@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/config"
+)
+
+func TestAPIKeyModelAllowlist(t *testing.T) {
+	cfg := &config.Config{APIKeys: map[string][]string{"key-a": {"alpha/"}}}
+	s := newTestServer(t, cfg,
+		&stubProvider{id: "alpha", models: []string{"m1"}},
+		&stubProvider{id: "beta", models: []string{"m1"}},
+	)
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		model    string
+		wantCode int
+		wantType string
+	}{
+		{name: "allowed model", key: "key-a", model: "alpha/m1", wantCode: http.StatusOK},
+		{name: "model outside allowlist", key: "key-a", model: "beta/m1", wantCode: http.StatusForbidden, wantType: api.ErrorTypePermission},
+		{name: "unknown key", key: "key-x", model: "alpha/m1", wantCode: http.StatusUnauthorized, wantType: api.ErrorTypeAuthentication},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"model":"` + tt.model + `","messages":[{"role":"user","content":"hi"}]}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			rec := serve(s, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantType == "" {
+				return
+			}
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if resp.Error.Type != tt.wantType {
+				t.Errorf("error type = %q, want %q", resp.Error.Type, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/provider"
+	"github.com/edgard/opencompat/internal/tokens"
+)
+
+// contextLengthError returns an OpenAI-style context_length_exceeded message if the
+// estimated prompt plus the requested completion cannot fit the model's context window.
+// Returns "" when the request fits or the provider does not report a limit.
+func contextLengthError(p provider.Provider, modelID string, req *api.ChatCompletionRequest) string {
+	limiter, ok := p.(provider.ContextLimiter)
+	if !ok {
+		return ""
+	}
+	window := limiter.ContextWindow(modelID)
+	if window <= 0 {
+		return ""
+	}
+
+	promptTokens := tokens.EstimateMessages(modelID, req.Messages, req.Tools)
+	completionTokens := 0
+	if req.MaxCompletionTokens != nil {
+		completionTokens = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil {
+		completionTokens = *req.MaxTokens
+	}
+	if promptTokens+completionTokens <= window {
+		return ""
+	}
+
+	if completionTokens == 0 {
+		return fmt.Sprintf("This model's maximum context length is %d tokens. However, your messages resulted in approximately %d tokens. Please reduce the length of the messages.",
+			window, promptTokens)
+	}
+	return fmt.Sprintf("This model's maximum context length is %d tokens. However, you requested approximately %d tokens (%d in the messages, %d in the completion). Please reduce the length of the messages or completion.",
+		window, promptTokens+completionTokens, promptTokens, completionTokens)
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// DebugTransform handles POST /debug/transform.
+// It translates a chat completion request exactly as ChatCompletions would and
+// returns the upstream request body without sending it. Only registered when
+// OPENCOMPAT_DEBUG is enabled.
+func (h *Handlers) DebugTransform(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.WriteMethodNotAllowed(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req api.ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBadRequest(w, "Invalid JSON: "+err.Error())
+		return
+	}
+	if req.Model == "" {
+		api.WriteBadRequestWithParam(w, "model is required", "model")
+		return
+	}
+
+	if target, ok := resolveRoute(h.cfg.Routes, req.Model); ok {
+		w.Header().Set("X-OpenCompat-Route", target)
+		req.Model = target
+	}
+
+	p, modelID, err := h.registry.GetProvider(req.Model)
+	if err != nil {
+		if strings.Contains(err.Error(), "requires login") {
+			api.WriteError(w, http.StatusUnauthorized, api.ErrorTypeAuthentication, err.Error(), nil, nil)
+			return
+		}
+		api.WriteModelNotFound(w, req.Model, h.modelSuggestions(req.Model)...)
+		return
+	}
+	if !h.registry.IsModelSupported(req.Model) {
+		api.WriteModelNotFound(w, req.Model, h.modelSuggestions(req.Model)...)
+		return
+	}
+
+	previewer, ok := p.(provider.RequestPreviewer)
+	if !ok {
+		api.WriteBadRequestWithParam(w, fmt.Sprintf("provider %s does not support request previews", p.ID()), "model")
+		return
+	}
+
+	upstreamReq, err := previewer.PreviewRequest(newProviderRequest(&req, modelID))
+	if err != nil {
+		api.WriteBadRequest(w, "Transform failed: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-OpenCompat-Provider", p.ID())
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(upstreamReq)
+}
+
+// Instructions handles GET /v1/instructions/{model}.
+// It returns the system instructions the model runs with, including local
+// overrides, plus the cached release version. Only registered when
+// OPENCOMPAT_DEBUG is enabled.
+func (h *Handlers) Instructions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteMethodNotAllowed(w)
+		return
+	}
+
+	model := strings.TrimPrefix(r.URL.Path, "/v1/instructions/")
+	if model == "" {
+		api.WriteBadRequestWithParam(w, "model is required", "model")
+		return
+	}
+	if target, ok := resolveRoute(h.cfg.Routes, model); ok {
+		w.Header().Set("X-OpenCompat-Route", target)
+		model = target
+	}
+
+	p, modelID, err := h.registry.GetProvider(model)
+	if err != nil || !h.registry.IsModelSupported(model) {
+		api.WriteModelNotFound(w, model, h.modelSuggestions(model)...)
+		return
+	}
+
+	describer, ok := p.(provider.InstructionsDescriber)
+	if !ok {
+		api.WriteNotFound(w, fmt.Sprintf("Provider %s does not inject instructions", p.ID()))
+		return
+	}
+
+	info, err := describer.DescribeInstructions(modelID)
+	if err != nil {
+		api.WriteError(w, http.StatusServiceUnavailable, api.ErrorTypeServiceUnavailable, "Failed to load instructions: "+err.Error(), nil, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-OpenCompat-Provider", p.ID())
+	_ = json.NewEncoder(w).Encode(info)
+}
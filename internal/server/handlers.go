@@ -1,33 +1,82 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/auth"
 	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/ledger"
+	"github.com/edgard/opencompat/internal/metrics"
 	"github.com/edgard/opencompat/internal/provider"
 )
 
 // Maximum request body size (10MB)
 const maxRequestBodySize = 10 * 1024 * 1024
 
+// deepHealthTimeout bounds the upstream calls made by /health?deep=true.
+const deepHealthTimeout = 15 * time.Second
+
 // validRoles defines the valid message roles for OpenAI API
 var validRoles = map[string]bool{
 	"system":    true,
+	"developer": true, // successor to system in newer OpenAI SDKs
 	"user":      true,
 	"assistant": true,
 	"tool":      true,
 }
 
-// logIgnoredParameters logs warnings for parameters that are accepted but ignored.
+// Allowed values for per-request reasoning/verbosity overrides
+var (
+	validReasoningEfforts   = []string{"none", "minimal", "low", "medium", "high", "xhigh"}
+	validReasoningSummaries = []string{"auto", "concise", "detailed"}
+	validReasoningCompats   = []string{"none", "think-tags", "o3", "legacy", "reasoning-content"}
+	validTextVerbosities    = []string{"low", "medium", "high"}
+)
+
+// requestOption resolves a per-request override. The body field wins over
+// headers, which are tried in order. An invalid body value returns an error
+// message; invalid header values are logged and ignored.
+func requestOption(r *http.Request, requestID, bodyValue string, headers, allowed []string) (string, string) {
+	if bodyValue != "" {
+		if !slices.Contains(allowed, bodyValue) {
+			return "", fmt.Sprintf("Invalid value '%s'. Must be one of: %s", bodyValue, strings.Join(allowed, ", "))
+		}
+		return bodyValue, ""
+	}
+
+	for _, name := range headers {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if slices.Contains(allowed, value) {
+			return value, ""
+		}
+		slog.Warn("ignoring invalid header value",
+			"request_id", requestID,
+			"header", name,
+			"value", value,
+		)
+	}
+	return "", ""
+}
+
+// logIgnoredParameters logs warnings for parameters that are accepted but ignored and returns their names.
 // providerID is used to determine which parameters are actually ignored (some providers support them).
-func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, providerID string) {
+func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, providerID string) []string {
 	var ignored []string
 
 	// These parameters are ignored by all providers
@@ -40,12 +89,9 @@ func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, prov
 	if req.Seed != nil {
 		ignored = append(ignored, "seed")
 	}
-	if req.User != "" {
-		ignored = append(ignored, "user")
-	}
 
-	// These parameters are only ignored by ChatGPT (Copilot supports them)
-	if providerID != "copilot" {
+	// These parameters are only ignored by ChatGPT (Copilot and Anthropic support them)
+	if providerID == "chatgpt" {
 		if req.Temperature != nil {
 			ignored = append(ignored, "temperature")
 		}
@@ -61,6 +107,13 @@ func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, prov
 		if req.MaxCompletionTokens != nil {
 			ignored = append(ignored, "max_completion_tokens")
 		}
+		if req.ParallelToolCalls != nil {
+			ignored = append(ignored, "parallel_tool_calls")
+		}
+	}
+
+	// These parameters are only supported by Copilot
+	if providerID != "copilot" {
 		if req.PresencePenalty != nil {
 			ignored = append(ignored, "presence_penalty")
 		}
@@ -70,14 +123,26 @@ func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, prov
 		if req.ResponseFormat != nil {
 			ignored = append(ignored, "response_format")
 		}
-		if req.ParallelToolCalls != nil {
-			ignored = append(ignored, "parallel_tool_calls")
-		}
 	}
 
-	// reasoning_effort is only supported by ChatGPT (ignored by Copilot)
-	if providerID == "copilot" && req.ReasoningEffort != "" {
-		ignored = append(ignored, "reasoning_effort")
+	// Reasoning and verbosity options are only supported by ChatGPT
+	if providerID != "chatgpt" {
+		if req.ReasoningEffort != "" {
+			ignored = append(ignored, "reasoning_effort")
+		}
+		if req.ReasoningSummary != "" {
+			ignored = append(ignored, "reasoning_summary")
+		}
+		if req.ReasoningCompat != "" {
+			ignored = append(ignored, "reasoning_compat")
+		}
+		if req.TextVerbosity != "" {
+			ignored = append(ignored, "text_verbosity")
+		}
+		// ChatGPT uses user as a conversation id for the prompt cache key
+		if req.User != "" {
+			ignored = append(ignored, "user")
+		}
 	}
 
 	if len(ignored) > 0 {
@@ -86,6 +151,7 @@ func logIgnoredParameters(requestID string, req *api.ChatCompletionRequest, prov
 			"params", strings.Join(ignored, ", "),
 		)
 	}
+	return ignored
 }
 
 // writeStreamError writes an appropriate error response, checking for UpstreamError first.
@@ -98,38 +164,270 @@ func writeStreamError(w http.ResponseWriter, err error, prefix string) {
 	api.WriteServerError(w, prefix+err.Error())
 }
 
-// formatErrorForSSE formats an error message for SSE streams, including status code if available.
-func formatErrorForSSE(err error, prefix string) string {
+// errorDetailForSSE builds the error payload for SSE streams, including the
+// upstream status code, type and code if available.
+func errorDetailForSSE(err error, prefix string) api.ErrorDetail {
 	var upstreamErr *api.UpstreamError
 	if errors.As(err, &upstreamErr) {
-		return fmt.Sprintf("%s (status %d): %s", prefix, upstreamErr.StatusCode, upstreamErr.Message)
+		detail := api.ErrorDetail{
+			Message: fmt.Sprintf("%s (status %d): %s", prefix, upstreamErr.StatusCode, upstreamErr.Message),
+			Type:    api.ErrorTypeServer,
+		}
+		if upstreamErr.Type != "" {
+			detail.Type = upstreamErr.Type
+		}
+		if upstreamErr.Code != "" {
+			detail.Code = &upstreamErr.Code
+		}
+		return detail
+	}
+	return api.ErrorDetail{
+		Message: prefix + ": " + err.Error(),
+		Type:    api.ErrorTypeServer,
 	}
-	return prefix + ": " + err.Error()
 }
 
 // Handlers holds the HTTP handlers and their dependencies.
 type Handlers struct {
-	registry *provider.Registry
-	cfg      *config.Config
+	registry    *provider.Registry
+	cfg         *config.Config
+	build       BuildInfo
+	initialized atomic.Bool // Set once provider initialization (prefetch) succeeds
+
+	// In-flight completion tracking for graceful shutdown
+	drainMu  sync.Mutex
+	draining bool
+	inflight sync.WaitGroup
+
+	// Concurrency limit; nil when OPENCOMPAT_MAX_CONCURRENT is unset
+	slots chan struct{}
+
+	// Responses recorded for Idempotency-Key replays
+	idempotency idempotencyCache
+
+	// Deterministic response cache; nil unless OPENCOMPAT_RESPONSE_CACHE is set
+	responses *responseCache
 }
 
 // NewHandlers creates a new handlers instance.
 func NewHandlers(registry *provider.Registry, cfg *config.Config) *Handlers {
-	return &Handlers{
-		registry: registry,
-		cfg:      cfg,
+	h := &Handlers{
+		registry:    registry,
+		cfg:         cfg,
+		idempotency: idempotencyCache{entries: make(map[string]*idempotentResponse)},
+	}
+	if cfg.MaxConcurrent > 0 {
+		h.slots = make(chan struct{}, cfg.MaxConcurrent)
 	}
+	if cfg.ResponseCache && cfg.ResponseCacheSize > 0 && cfg.ResponseCacheTTL > 0 {
+		h.responses = newResponseCache(cfg.ResponseCacheSize, time.Duration(cfg.ResponseCacheTTL)*time.Second)
+	}
+	return h
 }
 
-// Health handles GET /health
+// Health handles GET /health (alias for liveness).
+// With ?deep=true, each active provider is checked with an authenticated upstream call.
+// Both forms include build information and the instructions release in use.
 func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("deep") != "true" {
+		h.liveness(w, r, true)
+		return
+	}
 	if r.Method != http.MethodGet {
 		api.WriteMethodNotAllowed(w)
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(r.Context(), deepHealthTimeout)
+	defer cancel()
+
+	type providerHealth struct {
+		Ready   bool   `json:"ready"`
+		Healthy bool   `json:"healthy"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	ready := h.registry.ReadyStatus()
+	providers := make(map[string]providerHealth, len(ready))
+	healthy := 0
+	for id, err := range h.registry.HealthStatus(ctx) {
+		ph := providerHealth{Ready: ready[id], Healthy: err == nil}
+		if err != nil {
+			ph.Error = err.Error()
+		} else if ph.Ready {
+			healthy++
+		}
+		providers[id] = ph
+	}
+
+	// ok: all providers usable; degraded: some usable; unhealthy: none usable
+	status := "ok"
+	statusCode := http.StatusOK
+	switch {
+	case !h.initialized.Load() || healthy == 0:
+		status = "unhealthy"
+		statusCode = http.StatusServiceUnavailable
+	case healthy < len(providers):
+		status = "degraded"
+	}
+
+	body := map[string]any{
+		"status":    status,
+		"providers": providers,
+	}
+	h.addBuildInfo(body)
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// addBuildInfo adds the build and instructions version fields reported by /health.
+func (h *Handlers) addBuildInfo(body map[string]any) {
+	body["version"] = h.build.Version
+	body["commit"] = h.build.Commit
+	body["date"] = h.build.Date
+	if versions := h.registry.InstructionsVersions(); len(versions) > 0 {
+		body["instructions"] = versions
+	}
+}
+
+// Live handles GET /health/live and /livez.
+// Always returns 200 while the process is running.
+func (h *Handlers) Live(w http.ResponseWriter, r *http.Request) {
+	h.liveness(w, r, false)
+}
+
+// liveness writes the liveness response, with build information if withBuild is set.
+func (h *Handlers) liveness(w http.ResponseWriter, r *http.Request, withBuild bool) {
+	if r.Method != http.MethodGet {
+		api.WriteMethodNotAllowed(w)
+		return
+	}
+
+	body := map[string]any{"status": "ok"}
+	if withBuild {
+		_, body["ready"] = h.readyState()
+		h.addBuildInfo(body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Ready handles GET /health/ready and /readyz.
+// Returns 200 when initialization has completed and at least one provider is ready, 503 otherwise.
+func (h *Handlers) Ready(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteMethodNotAllowed(w)
+		return
+	}
+
+	providers, ready := h.readyState()
+	status := "ok"
+	statusCode := http.StatusOK
+	if !ready {
+		status = "not_ready"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	body := map[string]any{
+		"status":    status,
+		"providers": providers,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// readyState returns the readiness of each active provider and whether the
+// server is ready: initialization has completed and at least one provider is ready.
+func (h *Handlers) readyState() (map[string]bool, bool) {
+	providers := h.registry.ReadyStatus()
+	if !h.initialized.Load() {
+		return providers, false
+	}
+	for _, ready := range providers {
+		if ready {
+			return providers, true
+		}
+	}
+	return providers, false
+}
+
+// beginCompletion registers an in-flight completion request.
+// Returns false if the server is shutting down and the request must be rejected.
+func (h *Handlers) beginCompletion() bool {
+	h.drainMu.Lock()
+	defer h.drainMu.Unlock()
+	if h.draining {
+		return false
+	}
+	h.inflight.Add(1)
+	return true
+}
+
+// acquireSlot reserves one of the OPENCOMPAT_MAX_CONCURRENT slots, waiting up
+// to OPENCOMPAT_QUEUE_TIMEOUT for one to free up. Returns false if none did or
+// the client went away. Always succeeds when no limit is configured.
+func (h *Handlers) acquireSlot(ctx context.Context) bool {
+	if h.slots == nil {
+		return true
+	}
+	select {
+	case h.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timeout := h.cfg.QueueTimeoutDuration()
+	if timeout <= 0 {
+		return false
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case h.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseSlot frees a slot taken by acquireSlot.
+func (h *Handlers) releaseSlot() {
+	if h.slots != nil {
+		<-h.slots
+	}
+}
+
+// Drain stops accepting new completion requests and waits for in-flight ones
+// to finish, or until ctx is done.
+func (h *Handlers) Drain(ctx context.Context) error {
+	h.drainMu.Lock()
+	h.draining = true
+	h.drainMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetInitialized marks provider initialization as complete.
+func (h *Handlers) SetInitialized() {
+	h.initialized.Store(true)
 }
 
 // Models handles GET /v1/models
@@ -139,8 +437,21 @@ func (h *Handlers) Models(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	allowed, ok := h.authorizeKey(w, r)
+	if !ok {
+		return
+	}
+
 	// Get all models from all active providers (with provider prefix)
 	models := h.registry.AllModels()
+	if models == nil {
+		models = []api.Model{} // serve "data": [] when no provider is active
+	}
+	if len(allowed) > 0 {
+		models = slices.DeleteFunc(models, func(m api.Model) bool {
+			return !modelAllowed(allowed, m.ID)
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(api.ModelsResponse{
@@ -156,9 +467,44 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject new work while draining for shutdown
+	if !h.beginCompletion() {
+		api.WriteError(w, http.StatusServiceUnavailable, api.ErrorTypeServiceUnavailable, "Server is shutting down", nil, nil)
+		return
+	}
+	defer h.inflight.Done()
+
 	// Get request ID from context (set by middleware)
 	requestID := GetRequestID(r.Context())
 
+	// Require a server API key when OPENCOMPAT_API_KEYS is set
+	allowedModels, ok := h.authorizeKey(w, r)
+	if !ok {
+		return
+	}
+
+	// Degraded mode (OPENCOMPAT_ALLOW_NO_PROVIDERS): nothing can serve the request yet
+	if !h.registry.HasProviders() {
+		api.WriteError(w, http.StatusServiceUnavailable, api.ErrorTypeServiceUnavailable,
+			"No provider is logged in; log in with 'opencompat login' and send SIGHUP to the server", nil, nil)
+		return
+	}
+
+	// Apply backpressure once OPENCOMPAT_MAX_CONCURRENT requests are running
+	if !h.acquireSlot(r.Context()) {
+		slog.Warn("concurrency limit reached, rejecting request",
+			"request_id", requestID,
+			"max_concurrent", h.cfg.MaxConcurrent,
+			"queue_timeout", h.cfg.QueueTimeoutDuration(),
+		)
+		retryAfter := max(h.cfg.QueueTimeout, 1)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		api.WriteError(w, http.StatusServiceUnavailable, api.ErrorTypeServiceUnavailable,
+			fmt.Sprintf("Too many concurrent requests (limit %d); retry later", h.cfg.MaxConcurrent), nil, nil)
+		return
+	}
+	defer h.releaseSlot()
+
 	// Limit request body size to prevent DoS
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 
@@ -179,6 +525,13 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Resolve friendly names from the routing table
+	if target, ok := resolveRoute(h.cfg.Routes, req.Model); ok {
+		slog.Debug("model routed", "request_id", requestID, "model", req.Model, "target", target)
+		w.Header().Set("X-OpenCompat-Route", target)
+		req.Model = target
+	}
+
 	// Get provider for the model (model must include provider prefix)
 	p, modelID, err := h.registry.GetProvider(req.Model)
 	if err != nil {
@@ -189,22 +542,44 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		}
 		// Check if it's a missing provider prefix
 		if strings.Contains(err.Error(), "must include provider prefix") {
-			api.WriteBadRequestWithParam(w, err.Error(), "model")
+			message := err.Error()
+			if suggestions := h.modelSuggestions(req.Model); len(suggestions) > 0 {
+				message += ". Did you mean " + strings.Join(suggestions, ", ") + "?"
+			}
+			api.WriteBadRequestWithParam(w, message, "model")
 			return
 		}
-		api.WriteModelNotFound(w, req.Model)
+		api.WriteModelNotFound(w, req.Model, h.modelSuggestions(req.Model)...)
 		return
 	}
 
-	// Log warnings for ignored parameters (after we know the provider)
-	logIgnoredParameters(requestID, &req, p.ID())
+	// Enforce the API key's model allowlist on the resolved provider/model
+	if !modelAllowed(allowedModels, p.ID()+"/"+modelID) {
+		code, param := "model_not_allowed", "model"
+		api.WriteError(w, http.StatusForbidden, api.ErrorTypePermission,
+			fmt.Sprintf("The API key is not allowed to use model '%s'", p.ID()+"/"+modelID), &code, &param)
+		return
+	}
+
+	// Log warnings for ignored parameters (after we know the provider) and echo them to the client
+	if ignored := logIgnoredParameters(requestID, &req, p.ID()); len(ignored) > 0 {
+		w.Header().Set("X-OpenCompat-Warnings", strings.Join(ignored, ", "))
+	}
 
 	// Check if model is supported by the provider
 	if !h.registry.IsModelSupported(req.Model) {
-		api.WriteModelNotFound(w, req.Model)
+		api.WriteModelNotFound(w, req.Model, h.modelSuggestions(req.Model)...)
 		return
 	}
 
+	// Report what actually serves the request (after routes, default provider and aliases)
+	servedModel := modelID
+	if normalizer, ok := p.(provider.ModelNormalizer); ok {
+		servedModel = normalizer.NormalizeModel(modelID)
+	}
+	w.Header().Set("X-OpenCompat-Provider", p.ID())
+	w.Header().Set("X-OpenCompat-Model", servedModel)
+
 	// Validate messages
 	if len(req.Messages) == 0 {
 		api.WriteBadRequestWithParam(w, "messages is required", "messages")
@@ -216,7 +591,7 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		// Validate role
 		if !validRoles[msg.Role] {
 			api.WriteBadRequestWithParam(w,
-				fmt.Sprintf("Invalid role '%s'. Must be one of: system, user, assistant, tool", msg.Role),
+				fmt.Sprintf("Invalid role '%s'. Must be one of: system, developer, user, assistant, tool", msg.Role),
 				fmt.Sprintf("messages[%d].role", i))
 			return
 		}
@@ -228,10 +603,155 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 				fmt.Sprintf("messages[%d].tool_call_id", i))
 			return
 		}
+
+		// Validate inline base64 images before they reach the upstream
+		for j, part := range msg.GetContentParts() {
+			if part.Type != "image_url" || part.ImageURL == nil || !strings.HasPrefix(part.ImageURL.URL, "data:") {
+				continue
+			}
+			if err := validateImageDataURL(part.ImageURL.URL, h.cfg.MaxImageBytes); err != nil {
+				api.WriteBadRequestWithParam(w,
+					fmt.Sprintf("Invalid image in messages[%d]: %v", i, err),
+					fmt.Sprintf("messages[%d].content[%d].image_url", i, j))
+				return
+			}
+		}
+	}
+
+	// Validate tool definitions
+	if param, err := validateTools(req.Tools); err != nil {
+		api.WriteBadRequestWithParam(w, err.Error(), param)
+		return
+	}
+
+	// Validate tool_choice against the declared tools
+	if err := validateToolChoice(req.ToolChoice, req.Tools); err != nil {
+		api.WriteBadRequestWithParam(w, err.Error(), "tool_choice")
+		return
+	}
+
+	// Download remote images for upstreams that cannot fetch URLs (opt-in)
+	if h.cfg.InlineImages {
+		req.Messages = inlineImages(r.Context(), requestID, req.Messages, h.cfg.MaxImageBytes)
+	}
+
+	// Reject prompts that cannot fit the model's context window (opt-in, estimated)
+	if h.cfg.EnforceContext {
+		if message := contextLengthError(p, modelID, &req); message != "" {
+			code, param := "context_length_exceeded", "messages"
+			api.WriteError(w, http.StatusBadRequest, api.ErrorTypeInvalidRequest, message, &code, &param)
+			return
+		}
+	}
+
+	// Resolve reasoning/verbosity overrides from body fields or headers
+	reasoningEffort := req.ReasoningEffort
+	var reasoningSummary, reasoningCompat, textVerbosity string
+	overrides := []struct {
+		param   string
+		body    string
+		headers []string
+		allowed []string
+		dst     *string
+	}{
+		// reasoning_effort starts from the body value, which the provider normalizes
+		{"reasoning_effort", "", []string{"X-OpenCompat-Reasoning-Effort"}, validReasoningEfforts, &reasoningEffort},
+		{"reasoning_summary", req.ReasoningSummary, []string{"X-OpenCompat-Reasoning-Summary", "X-Reasoning-Summary"}, validReasoningSummaries, &reasoningSummary},
+		{"reasoning_compat", req.ReasoningCompat, []string{"X-OpenCompat-Reasoning-Compat", "X-Reasoning-Compat"}, validReasoningCompats, &reasoningCompat},
+		{"text_verbosity", req.TextVerbosity, []string{"X-OpenCompat-Text-Verbosity", "X-Text-Verbosity"}, validTextVerbosities, &textVerbosity},
+	}
+	var effective []string
+	for _, o := range overrides {
+		if *o.dst == "" {
+			value, errMsg := requestOption(r, requestID, o.body, o.headers, o.allowed)
+			if errMsg != "" {
+				api.WriteBadRequestWithParam(w, errMsg, o.param)
+				return
+			}
+			*o.dst = value
+		}
+		if *o.dst != "" {
+			effective = append(effective, o.param+"="+*o.dst)
+		}
+	}
+	if len(effective) > 0 {
+		w.Header().Set("X-OpenCompat-Overrides", strings.Join(effective, ", "))
+	}
+
+	// In strict mode, reject efforts the provider would otherwise clamp
+	if h.cfg.StrictEffort {
+		if validator, ok := p.(provider.EffortValidator); ok {
+			if err := validator.ValidateEffort(modelID, reasoningEffort); err != nil {
+				api.WriteBadRequestWithParam(w, err.Error(), "reasoning_effort")
+				return
+			}
+		}
 	}
 
 	// Build provider request (provider handles model normalization internally)
-	providerReq := &provider.ChatCompletionRequest{
+	providerReq := newProviderRequest(&req, modelID)
+	providerReq.ReasoningEffort = reasoningEffort
+	providerReq.ReasoningSummary = reasoningSummary
+	providerReq.ReasoningCompat = reasoningCompat
+	providerReq.TextVerbosity = textVerbosity
+	providerReq.ConversationID = r.Header.Get("X-OpenCompat-Conversation")
+	if providerReq.ConversationID == "" {
+		providerReq.ConversationID = req.User
+	}
+
+	// Serve identical deterministic requests from the response cache (opt-in)
+	var cacheKey string
+	if h.responses != nil && cacheable(p, providerReq) {
+		var apiKey string
+		if h.cfg.APIKeys != nil {
+			apiKey = bearerToken(r)
+		}
+		cacheKey = responseCacheKey(p, providerReq, apiKey)
+		if body, ok := h.responses.get(cacheKey); ok {
+			slog.Debug("response cache hit", "request_id", requestID, "provider", p.ID(), "model", modelID)
+			w.Header().Set("X-OpenCompat-Cache", "hit")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+			return
+		}
+		w.Header().Set("X-OpenCompat-Cache", "miss")
+	}
+
+	// Send request to provider
+	upstreamStart := time.Now()
+	stream, err := p.ChatCompletion(r.Context(), providerReq)
+	metrics.ObserveUpstreamLatency(p.ID(), time.Since(upstreamStart))
+	if err != nil {
+		if _, ok := auth.IsReauthRequired(err); ok {
+			api.WriteError(w, http.StatusUnauthorized, api.ErrorTypeAuthentication, err.Error(), nil, nil)
+			return
+		}
+		writeStreamError(w, err, "Failed to send request: ")
+		return
+	}
+	defer func() { _ = stream.Close() }()
+
+	meta := completionMeta{
+		requestID:    requestID,
+		providerID:   p.ID(),
+		model:        modelID,
+		includeUsage: req.StreamOptions != nil && req.StreamOptions.IncludeUsage,
+		ndjson:       wantsNDJSON(r),
+		cacheKey:     cacheKey,
+	}
+
+	// Handle streaming vs non-streaming
+	if req.Stream {
+		h.handleStreaming(w, stream, meta)
+	} else {
+		h.handleNonStreaming(w, stream, meta)
+	}
+}
+
+// newProviderRequest builds the provider-facing request for modelID (without provider prefix).
+// Reasoning and verbosity options are taken from the body as-is.
+func newProviderRequest(req *api.ChatCompletionRequest, modelID string) *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
 		Model:               modelID,
 		Messages:            req.Messages,
 		Tools:               req.Tools,
@@ -239,9 +759,9 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		Stream:              req.Stream,
 		StreamOptions:       req.StreamOptions,
 		ReasoningEffort:     req.ReasoningEffort,
-		ReasoningSummary:    r.Header.Get("X-Reasoning-Summary"),
-		ReasoningCompat:     r.Header.Get("X-Reasoning-Compat"),
-		TextVerbosity:       r.Header.Get("X-Text-Verbosity"),
+		ReasoningSummary:    req.ReasoningSummary,
+		ReasoningCompat:     req.ReasoningCompat,
+		TextVerbosity:       req.TextVerbosity,
 		Temperature:         req.Temperature,
 		TopP:                req.TopP,
 		MaxTokens:           req.MaxTokens,
@@ -252,29 +772,96 @@ func (h *Handlers) ChatCompletions(w http.ResponseWriter, r *http.Request) {
 		ResponseFormat:      req.ResponseFormat,
 		ParallelToolCalls:   req.ParallelToolCalls,
 	}
+}
 
-	// Send request to provider
-	stream, err := p.ChatCompletion(r.Context(), providerReq)
-	if err != nil {
-		api.WriteServerError(w, "Failed to send request: "+err.Error())
-		return
+// modelSuggestions returns known model names and routes close to an unknown model.
+func (h *Handlers) modelSuggestions(model string) []string {
+	candidates := h.registry.ModelNames()
+	for name := range h.cfg.Routes {
+		candidates = append(candidates, name)
 	}
-	defer func() { _ = stream.Close() }()
+	return suggestModels(model, candidates)
+}
 
-	// Handle streaming vs non-streaming
-	if req.Stream {
-		h.handleStreaming(w, stream)
-	} else {
-		h.handleNonStreaming(w, stream)
+// completionMeta identifies a routed completion request for logging and usage accounting.
+type completionMeta struct {
+	requestID    string
+	providerID   string
+	model        string // Model ID without provider prefix
+	includeUsage bool   // client requested stream_options.include_usage
+	ndjson       bool   // stream as newline-delimited JSON instead of SSE
+	cacheKey     string // response cache key ("" = not cached)
+}
+
+// recordUsage logs and records token usage for a completed request, keyed by provider and model.
+func recordUsage(meta completionMeta, usage *api.Usage) {
+	if usage == nil {
+		return
 	}
+	reasoning, cached := 0, 0
+	if usage.CompletionTokensDetails != nil {
+		reasoning = usage.CompletionTokensDetails.ReasoningTokens
+	}
+	if usage.PromptTokensDetails != nil {
+		cached = usage.PromptTokensDetails.CachedTokens
+	}
+
+	slog.Info("token usage",
+		"request_id", meta.requestID,
+		"provider", meta.providerID,
+		"model", meta.model,
+		"prompt_tokens", usage.PromptTokens,
+		"completion_tokens", usage.CompletionTokens,
+		"total_tokens", usage.TotalTokens,
+		"estimated", usage.Estimated,
+	)
+
+	metrics.AddTokenUsage(meta.providerID, meta.model, usage.PromptTokens, usage.CompletionTokens, reasoning, cached)
+	ledger.Record(ledger.Entry{
+		Time:             time.Now().UTC(),
+		Provider:         meta.providerID,
+		Model:            meta.model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		ReasoningTokens:  reasoning,
+		CachedTokens:     cached,
+		Estimated:        usage.Estimated,
+	})
 }
 
-func (h *Handlers) handleStreaming(w http.ResponseWriter, stream provider.Stream) {
-	var sseWriter *SSEWriter
+func (h *Handlers) handleStreaming(w http.ResponseWriter, stream provider.Stream, meta completionMeta) {
+	var writer StreamWriter
 	var streamErr error
+	var usage *api.Usage
+	var lastChunk *api.ChatCompletionChunk
+
+	start := time.Now()
+	defer func() {
+		metrics.ObserveStreamDuration(meta.providerID, time.Since(start))
+	}()
+
+	// Closing the stream unblocks a Next() stuck waiting on a silent upstream
+	var firstChunkTimer *time.Timer
+	if timeout := h.cfg.FirstChunkTimeoutDuration(); timeout > 0 {
+		firstChunkTimer = time.AfterFunc(timeout, func() { _ = stream.Close() })
+	}
 
 	for {
 		chunk, err := stream.Next()
+		if firstChunkTimer != nil {
+			// Stop reports false once the timer has fired and closed the stream
+			fired := !firstChunkTimer.Stop()
+			firstChunkTimer = nil
+			if fired {
+				slog.Warn("no stream chunk received before first chunk timeout",
+					"request_id", meta.requestID,
+					"provider", meta.providerID,
+					"timeout", h.cfg.FirstChunkTimeoutDuration(),
+				)
+				h.writeFirstChunkTimeout(w, meta)
+				return
+			}
+		}
 		if err != nil {
 			if err != io.EOF {
 				streamErr = err
@@ -282,24 +869,29 @@ func (h *Handlers) handleStreaming(w http.ResponseWriter, stream provider.Stream
 			break
 		}
 
-		// Initialize SSE writer on first successful chunk
-		if sseWriter == nil {
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		lastChunk = chunk
+
+		// Initialize the stream writer on first successful chunk
+		if writer == nil {
 			var initErr error
-			sseWriter, initErr = NewSSEWriter(w)
+			writer, initErr = newStreamWriter(w, meta.ndjson)
 			if initErr != nil {
 				api.WriteServerError(w, initErr.Error())
 				return
 			}
 		}
 
-		if err := sseWriter.WriteChunk(chunk); err != nil {
+		if err := writer.WriteChunk(chunk); err != nil {
 			// Client disconnected
 			return
 		}
 	}
 
 	// If no chunks were sent, we can still return a proper HTTP error
-	if sseWriter == nil {
+	if writer == nil {
 		// Prefer streamErr if set, otherwise check stream.Err()
 		err := streamErr
 		if err == nil {
@@ -317,15 +909,58 @@ func (h *Handlers) handleStreaming(w http.ResponseWriter, stream provider.Stream
 	// streamErr is set when Next() returns a non-EOF error.
 	// stream.Err() may return additional errors from SSE event processing (e.g., response.failed).
 	if streamErr != nil {
-		_ = sseWriter.WriteError(formatErrorForSSE(streamErr, "Stream error"))
+		_ = writer.WriteError(errorDetailForSSE(streamErr, "Stream error"))
 	} else if err := stream.Err(); err != nil {
-		_ = sseWriter.WriteError(formatErrorForSSE(err, "Upstream error"))
+		_ = writer.WriteError(errorDetailForSSE(err, "Upstream error"))
+	}
+
+	// Fall back to the accumulated response when usage wasn't streamed
+	streamedUsage := usage != nil
+	if usage == nil {
+		if resp := stream.Response(); resp != nil {
+			usage = resp.Usage
+		}
+	}
+
+	// A requested usage chunk always precedes [DONE], even when upstream
+	// reported nothing (zeros, flagged as estimated)
+	if meta.includeUsage && !streamedUsage {
+		chunkUsage := usage
+		if chunkUsage == nil {
+			chunkUsage = &api.Usage{Estimated: true}
+		}
+		_ = writer.WriteChunk(&api.ChatCompletionChunk{
+			ID:      lastChunk.ID,
+			Object:  "chat.completion.chunk",
+			Created: lastChunk.Created,
+			Model:   lastChunk.Model,
+			Choices: []api.Choice{},
+			Usage:   chunkUsage,
+		})
 	}
 
-	_ = sseWriter.WriteDone()
+	_ = writer.WriteDone()
+	recordUsage(meta, usage)
+}
+
+// writeFirstChunkTimeout ends a stream whose upstream stayed silent past
+// OPENCOMPAT_FIRST_CHUNK_TIMEOUT with an SSE error event.
+func (h *Handlers) writeFirstChunkTimeout(w http.ResponseWriter, meta completionMeta) {
+	writer, err := newStreamWriter(w, meta.ndjson)
+	if err != nil {
+		api.WriteServerError(w, err.Error())
+		return
+	}
+	code := "first_chunk_timeout"
+	_ = writer.WriteError(api.ErrorDetail{
+		Message: fmt.Sprintf("No response from upstream within %s", h.cfg.FirstChunkTimeoutDuration()),
+		Type:    api.ErrorTypeServer,
+		Code:    &code,
+	})
+	_ = writer.WriteDone()
 }
 
-func (h *Handlers) handleNonStreaming(w http.ResponseWriter, stream provider.Stream) {
+func (h *Handlers) handleNonStreaming(w http.ResponseWriter, stream provider.Stream, meta completionMeta) {
 	// Consume the stream to build the response
 	for {
 		_, err := stream.Next()
@@ -351,6 +986,18 @@ func (h *Handlers) handleNonStreaming(w http.ResponseWriter, stream provider.Str
 		return
 	}
 
+	recordUsage(meta, response.Usage)
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		api.WriteServerError(w, "Failed to encode response: "+err.Error())
+		return
+	}
+	body = append(body, '\n')
+	if meta.cacheKey != "" {
+		h.responses.put(meta.cacheKey, body)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
+	_, _ = w.Write(body)
 }
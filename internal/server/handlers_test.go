@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/ledger"
+)
+
+func TestUsageAttributedToRoutedProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ledger.LedgerFile)
+	if err := ledger.Enable(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ledger.Close)
+
+	cfg := &config.Config{Routes: map[string]string{"fast": "beta/m2"}}
+	s := newTestServer(t, cfg,
+		&stubProvider{id: "alpha", models: []string{"m1"}},
+		&stubProvider{id: "beta", models: []string{"m2"}},
+	)
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stream := range []bool{false, true} {
+		body, _ := json.Marshal(map[string]any{
+			"model":    "fast",
+			"stream":   stream,
+			"messages": []map[string]string{{"role": "user", "content": "hi"}},
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body)))
+		s.httpServer.Handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("stream=%v: status = %d, body %s", stream, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("X-OpenCompat-Provider"); got != "beta" {
+			t.Errorf("stream=%v: X-OpenCompat-Provider = %q, want beta", stream, got)
+		}
+	}
+
+	// Close flushes the queued entries
+	ledger.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []ledger.Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e ledger.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("decode ledger line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("ledger has %d entries, want 2 (non-streaming and streaming)", len(entries))
+	}
+	for _, e := range entries {
+		if e.Provider != "beta" || e.Model != "m2" {
+			t.Errorf("usage recorded for %s/%s, want beta/m2", e.Provider, e.Model)
+		}
+		if e.PromptTokens != 3 || e.CompletionTokens != 1 {
+			t.Errorf("usage = %d prompt / %d completion, want 3 / 1", e.PromptTokens, e.CompletionTokens)
+		}
+	}
+}
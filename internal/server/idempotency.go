@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// idempotencyTTL is how long a completed response is replayed for a repeated key.
+const idempotencyTTL = 10 * time.Minute
+
+// maxIdempotencyKeyLength bounds Idempotency-Key header values.
+const maxIdempotencyKeyLength = 256
+
+// idempotentResponse is a recorded non-streaming response shared by every
+// request carrying the same Idempotency-Key. done is closed once it is complete.
+type idempotentResponse struct {
+	bodyHash [sha256.Size]byte
+	done     chan struct{}
+	expires  time.Time
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+// idempotencyCache maps scoped Idempotency-Key values to their responses.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+}
+
+// claim returns the entry for key and whether the caller created it (and must
+// run the request). Expired entries are pruned on the way.
+func (c *idempotencyCache) claim(key string, bodyHash [sha256.Size]byte) (*idempotentResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	maps.DeleteFunc(c.entries, func(_ string, e *idempotentResponse) bool {
+		return !e.expires.IsZero() && now.After(e.expires)
+	})
+
+	if e, ok := c.entries[key]; ok {
+		return e, false
+	}
+	e := &idempotentResponse{bodyHash: bodyHash, done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true
+}
+
+// complete stores the recorded response and wakes waiting duplicates.
+// Server errors are not kept, so a later retry runs the request again.
+func (c *idempotencyCache) complete(key string, e *idempotentResponse, rec *recordingWriter) {
+	c.mu.Lock()
+	e.status = rec.status
+	if e.status == 0 {
+		e.status = http.StatusInternalServerError // handler panicked before writing
+	}
+	e.header = rec.header.Clone()
+	e.body = rec.body.Bytes()
+	if e.status >= http.StatusInternalServerError {
+		delete(c.entries, key)
+	} else {
+		e.expires = time.Now().Add(idempotencyTTL)
+	}
+	c.mu.Unlock()
+	close(e.done)
+}
+
+// recordingWriter buffers a response so it can be replayed to duplicates.
+type recordingWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *recordingWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
+
+// replay writes a recorded response to w.
+func (e *idempotentResponse) replay(w http.ResponseWriter, replayed bool) {
+	maps.Copy(w.Header(), e.header)
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// Idempotent wraps a non-streaming completion handler so requests repeating an
+// Idempotency-Key share one upstream call: a duplicate attaches to the in-flight
+// request or replays the completed response for idempotencyTTL. Keys are scoped
+// to the caller's API key. Streaming requests and requests without the header
+// pass through unchanged.
+func (h *Handlers) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+		if key == "" || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			api.WriteBadRequest(w, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+		if err != nil {
+			api.WriteBadRequest(w, "Request body too large (max 10MB)")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var probe struct {
+			Stream bool `json:"stream"`
+		}
+		if json.Unmarshal(body, &probe) != nil || probe.Stream {
+			next(w, r)
+			return
+		}
+
+		scoped := bearerToken(r) + "\x00" + key
+		e, owner := h.idempotency.claim(scoped, sha256.Sum256(body))
+		if owner {
+			rec := &recordingWriter{header: http.Header{}}
+			func() {
+				// Always release waiters, even if the handler panics
+				defer h.idempotency.complete(scoped, e, rec)
+				next(rec, r)
+			}()
+			e.replay(w, false)
+			return
+		}
+
+		if e.bodyHash != sha256.Sum256(body) {
+			api.WriteError(w, http.StatusUnprocessableEntity, api.ErrorTypeInvalidRequest,
+				"Idempotency-Key was already used with a different request body", nil, nil)
+			return
+		}
+
+		requestID := GetRequestID(r.Context())
+		slog.Debug("replaying idempotent request", "request_id", requestID, "idempotency_key", key)
+		select {
+		case <-e.done:
+			e.replay(w, true)
+		case <-r.Context().Done():
+		}
+	}
+}
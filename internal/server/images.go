@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/httputil"
+)
+
+// inlineImageTimeout bounds each remote image download (OPENCOMPAT_INLINE_IMAGES).
+const inlineImageTimeout = 10 * time.Second
+
+// supportedImageTypes are the image media types accepted by upstream providers.
+var supportedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imageClient downloads remote images for inlining over the shared upstream
+// transport; each download is bounded by inlineImageTimeout.
+var imageClient = httputil.NewClient()
+
+// inlineImages returns messages with http(s) image URLs replaced by base64 data URLs.
+// Images that cannot be fetched, are not supported or exceed maxBytes keep their original URL.
+// Messages without remote images are returned unchanged.
+func inlineImages(ctx context.Context, requestID string, messages []api.Message, maxBytes int) []api.Message {
+	result := messages
+	copied := false
+	for i, msg := range messages {
+		parts := msg.GetContentParts()
+		changed := false
+		for j, part := range parts {
+			if part.Type != "image_url" || part.ImageURL == nil {
+				continue
+			}
+			url := part.ImageURL.URL
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				continue
+			}
+			dataURL, err := fetchImageDataURL(ctx, url, maxBytes)
+			if err != nil {
+				slog.Warn("failed to inline image, passing URL through",
+					"request_id", requestID,
+					"message", i,
+					"error", err,
+				)
+				continue
+			}
+			imageURL := *part.ImageURL
+			imageURL.URL = dataURL
+			parts[j].ImageURL = &imageURL
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		content, err := json.Marshal(parts)
+		if err != nil {
+			continue
+		}
+		if !copied {
+			result = append([]api.Message(nil), messages...)
+			copied = true
+		}
+		result[i].Content = content
+	}
+	return result
+}
+
+// fetchImageDataURL downloads an image and encodes it as a base64 data URL.
+// At most maxBytes (DefaultMaxImageBytes if unset) are read from the body.
+func fetchImageDataURL(ctx context.Context, url string, maxBytes int) (string, error) {
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultMaxImageBytes
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, inlineImageTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := imageClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("image request failed with status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > int64(maxBytes) {
+		return "", fmt.Errorf("image exceeds %d bytes", maxBytes)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxBytes {
+		return "", fmt.Errorf("image exceeds %d bytes", maxBytes)
+	}
+
+	// Trust a supported Content-Type header; otherwise sniff the bytes
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !supportedImageTypes[mediaType] {
+		mediaType = http.DetectContentType(data)
+	}
+	if !supportedImageTypes[mediaType] {
+		return "", fmt.Errorf("unsupported image type %q", mediaType)
+	}
+
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// validateImageDataURL checks that a data: image URL is base64 encoded, has a
+// supported media type, decodes cleanly and is at most maxBytes once decoded.
+func validateImageDataURL(url string, maxBytes int) error {
+	rest, _ := strings.CutPrefix(url, "data:")
+	header, data, ok := strings.Cut(rest, ",")
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !ok || !isBase64 {
+		return fmt.Errorf("image data URL must have the form data:<media-type>;base64,<data>")
+	}
+	if !supportedImageTypes[strings.ToLower(mediaType)] {
+		return fmt.Errorf("unsupported image type %q. Must be one of: image/png, image/jpeg, image/gif, image/webp", mediaType)
+	}
+	if base64.StdEncoding.DecodedLen(len(data)) > maxBytes+2 {
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", maxBytes)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("image data is not valid base64: %v", err)
+	}
+	if len(decoded) > maxBytes {
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", maxBytes)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG for content sniffing.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func TestFetchImageDataURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small.png":
+			_, _ = w.Write([]byte(pngHeader + "data"))
+		case "/unbounded.png":
+			// No Content-Length: only the body cap stops the download
+			w.Header().Set("Content-Type", "image/png")
+			w.(http.Flusher).Flush()
+			chunk := strings.Repeat("x", 1024)
+			for range 64 {
+				if _, err := w.Write([]byte(chunk)); err != nil {
+					return
+				}
+			}
+		case "/page.html":
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "/small.png", want: "data:image/png;base64,iVBORw0KGgpkYXRh"},
+		{path: "/unbounded.png", wantErr: "exceeds 4096 bytes"},
+		{path: "/page.html", wantErr: "unsupported image type"},
+		{path: "/missing.png", wantErr: "status 404"},
+	}
+	for _, tt := range tests {
+		got, err := fetchImageDataURL(context.Background(), srv.URL+tt.path, 4096)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.path, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
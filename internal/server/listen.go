@@ -0,0 +1,41 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// unixSocketPerm restricts the socket to the owning user.
+const unixSocketPerm = 0600
+
+// listen creates a listener for the given network ("tcp" or "unix").
+// For Unix sockets, a stale socket file from a previous run is removed first
+// and the socket is restricted to the current user. The socket file is
+// removed automatically when the listener is closed.
+func listen(network, address string) (net.Listener, error) {
+	if network != "unix" {
+		return net.Listen(network, address)
+	}
+
+	if info, err := os.Stat(address); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("refusing to replace non-socket file: %s", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(address, unixSocketPerm); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
@@ -5,17 +5,95 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/logging"
+	"github.com/edgard/opencompat/internal/metrics"
 )
 
-// Context key for request ID
-type contextKey string
+// knownRoutes lists registered paths used as metric labels.
+// Unknown paths are grouped under "other" to bound label cardinality.
+var knownRoutes = map[string]bool{
+	"/health":                 true,
+	"/health/live":            true,
+	"/health/ready":           true,
+	"/livez":                  true,
+	"/readyz":                 true,
+	"/metrics":                true,
+	"/debug/transform":        true,
+	"/v1/models":              true,
+	"/v1/chat/completions":    true,
+	"/v1/chat/completions/ws": true,
+}
+
+// instructionsRoutePrefix is the prefix of the per-model instructions debug route.
+const instructionsRoutePrefix = "/v1/instructions/"
+
+// routeLabel returns the metric label for a request path.
+func routeLabel(path string) string {
+	if knownRoutes[path] {
+		return path
+	}
+	if strings.HasPrefix(path, instructionsRoutePrefix) {
+		return "/v1/instructions"
+	}
+	return "other"
+}
+
+// canonicalPath maps case and trailing-slash variants of a known route
+// (e.g. /V1/Models/) to its registered path. Other paths are returned
+// unchanged, so unknown /v1/* paths still reach the OpenAI-style 404.
+func canonicalPath(path string) string {
+	trimmed := strings.TrimRight(path, "/")
+	if trimmed == "" {
+		return path
+	}
+	for route := range knownRoutes {
+		if strings.EqualFold(trimmed, route) {
+			return route
+		}
+	}
+	// Only the prefix is normalized; the model name after it is kept as sent
+	if len(trimmed) > len(instructionsRoutePrefix) && strings.EqualFold(trimmed[:len(instructionsRoutePrefix)], instructionsRoutePrefix) {
+		return instructionsRoutePrefix + trimmed[len(instructionsRoutePrefix):]
+	}
+	return path
+}
 
-const requestIDKey contextKey = "request_id"
+// NormalizePathMiddleware rewrites case and trailing-slash variants of known
+// routes to their registered path before routing.
+func NormalizePathMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if path := canonicalPath(r.URL.Path); path != r.URL.Path {
+			r.URL.Path = path
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maxRequestIDLength bounds incoming request IDs to keep log lines sane.
+const maxRequestIDLength = 128
+
+// validRequestID reports whether an incoming request ID is safe to reuse.
+// Only printable ASCII without spaces is accepted to avoid log injection.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
 
 // generateRequestID creates a short random request ID.
 func generateRequestID() string {
@@ -26,61 +104,106 @@ func generateRequestID() string {
 
 // GetRequestID retrieves the request ID from context.
 func GetRequestID(ctx context.Context) string {
-	if id, ok := ctx.Value(requestIDKey).(string); ok {
-		return id
-	}
-	return ""
+	return logging.RequestID(ctx)
 }
 
-// CORSMiddleware adds CORS headers to responses.
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, OpenAI-Beta")
-		w.Header().Set("Access-Control-Expose-Headers", "x-request-id")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// CORSMiddleware returns middleware that adds CORS headers to responses.
+// A "*" entry in allowedOrigins allows any origin; otherwise the request
+// Origin is echoed back only when it is in the allowlist.
+func CORSMiddleware(allowedOrigins []string) func(http.Handler) http.Handler {
+	allowAny := slices.Contains(allowedOrigins, "*")
 
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			allowed := allowAny
+			if !allowAny {
+				w.Header().Add("Vary", "Origin")
+				allowed = origin != "" && slices.Contains(allowedOrigins, origin)
+			}
+
+			if allowed {
+				if allowAny {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, OpenAI-Beta, X-Request-Id, Idempotency-Key, X-OpenCompat-Conversation, X-OpenCompat-Reasoning-Effort, X-OpenCompat-Reasoning-Summary, X-OpenCompat-Reasoning-Compat, X-OpenCompat-Text-Verbosity, X-Reasoning-Summary, X-Reasoning-Compat, X-Text-Verbosity")
+				w.Header().Set("Access-Control-Expose-Headers", "x-request-id, x-opencompat-route, x-opencompat-overrides, x-opencompat-provider, x-opencompat-model, x-opencompat-warnings, x-opencompat-cache, idempotent-replayed")
+				w.Header().Set("Access-Control-Max-Age", "86400")
+			}
+
+			if r.Method == "OPTIONS" {
+				if origin != "" && !allowed {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-// RequestIDMiddleware generates a unique request ID and adds it to context and response header.
+// RequestIDMiddleware adds a request ID to context and response header.
+// An incoming X-Request-Id is reused so logs can be correlated across hops;
+// a new ID is generated when it is absent or malformed.
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := generateRequestID()
-		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		requestID := r.Header.Get("X-Request-Id")
+		if !validRequestID(requestID) {
+			requestID = generateRequestID()
+		}
+		ctx := logging.WithRequestID(r.Context(), requestID)
 		w.Header().Set("x-request-id", requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// LoggingMiddleware logs HTTP requests at debug level.
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+// LoggingMiddleware logs one access line per request at the given level and
+// records request metrics. Provider and model are taken from the response
+// headers set by the chat completions handler.
+func LoggingMiddleware(level slog.Level) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		// Wrap response writer to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			// Wrap response writer to capture status code and bytes written
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
-		next.ServeHTTP(wrapped, r)
+			next.ServeHTTP(wrapped, r)
 
-		duration := time.Since(start)
-		requestID := GetRequestID(r.Context())
+			duration := time.Since(start)
+			metrics.RecordRequest(routeLabel(r.URL.Path), wrapped.statusCode, duration)
 
-		slog.Debug("request completed",
-			"request_id", requestID,
-			"method", r.Method,
-			"path", r.URL.Path,
-			"status", wrapped.statusCode,
-			"duration", duration,
-		)
-	})
+			ctx := r.Context()
+			if !slog.Default().Enabled(ctx, level) {
+				return
+			}
+			// The request ID middleware runs inside this one, so read the ID from the response
+			attrs := []slog.Attr{
+				slog.String("request_id", wrapped.Header().Get("x-request-id")),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Duration("duration", duration),
+				slog.Int64("bytes", wrapped.bytes),
+			}
+			if p := wrapped.Header().Get("x-opencompat-provider"); p != "" {
+				attrs = append(attrs, slog.String("provider", p))
+			}
+			if m := wrapped.Header().Get("x-opencompat-model"); m != "" {
+				attrs = append(attrs, slog.String("model", m))
+			}
+			if slog.Default().Enabled(ctx, slog.LevelDebug) {
+				attrs = append(attrs, slog.Any("headers", logging.RedactHeaders(r.Header)))
+			}
+			slog.LogAttrs(ctx, level, "request completed", attrs...)
+		})
+	}
 }
 
 // RecoveryMiddleware recovers from panics and returns a 500 error.
@@ -91,7 +214,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 				requestID := GetRequestID(r.Context())
 				slog.Error("panic recovered",
 					"request_id", requestID,
-					"error", err,
+					"error", logging.Redact(fmt.Sprint(err)),
 				)
 				api.WriteServerError(w, "Internal server error")
 			}
@@ -100,10 +223,11 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// responseWriter wraps http.ResponseWriter to capture the status code.
+// responseWriter wraps http.ResponseWriter to capture the status code and body size.
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -111,6 +235,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
 // Flush implements http.Flusher for streaming support.
 func (rw *responseWriter) Flush() {
 	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
@@ -118,6 +248,11 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController (WebSocket hijacking).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // ChainMiddleware chains multiple middleware together.
 func ChainMiddleware(h http.Handler, middleware ...func(http.Handler) http.Handler) http.Handler {
 	for i := len(middleware) - 1; i >= 0; i-- {
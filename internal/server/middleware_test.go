@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/config"
+)
+
+func TestNormalizePathMiddleware(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/v1/models", want: "/v1/models"},
+		{path: "/V1/Models", want: "/v1/models"},
+		{path: "/v1/models/", want: "/v1/models"},
+		{path: "/v1/chat/completions/", want: "/v1/chat/completions"},
+		{path: "/V1/Chat/Completions/WS", want: "/v1/chat/completions/ws"},
+		{path: "/V1/Instructions/GPT-5-Codex/", want: "/v1/instructions/GPT-5-Codex"},
+		{path: "/v1/foo/", want: "/v1/foo/"},
+		{path: "/", want: "/"},
+	}
+
+	var got string
+	handler := NormalizePathMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Path
+	}))
+	for _, tt := range tests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if got != tt.want {
+			t.Errorf("%s routed as %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizedPathsReachHandlers(t *testing.T) {
+	s := newTestServer(t, &config.Config{CORSOrigins: []string{"*"}}, &stubProvider{id: "stub", models: []string{"m1"}})
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("models", func(t *testing.T) {
+		code, body := get(t, s, "/V1/Models")
+		if code != http.StatusOK || body["object"] != "list" {
+			t.Errorf("GET /V1/Models = %d %v, want 200 list", code, body)
+		}
+	})
+
+	t.Run("chat completions", func(t *testing.T) {
+		body := `{"model":"stub/m1","messages":[{"role":"user","content":"hi"}]}`
+		rec := serve(s, httptest.NewRequest(http.MethodPost, "/v1/chat/completions/", strings.NewReader(body)))
+		var resp api.ChatCompletionResponse
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &resp) != nil || resp.Object != "chat.completion" {
+			t.Errorf("POST /v1/chat/completions/ = %d %s, want a completion", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("unknown endpoint", func(t *testing.T) {
+		rec := serve(s, httptest.NewRequest(http.MethodGet, "/v1/foo/", nil))
+		var resp api.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode body %q: %v", rec.Body, err)
+		}
+		if rec.Code != http.StatusNotFound || resp.Error.Type != api.ErrorTypeNotFound {
+			t.Errorf("GET /v1/foo/ = %d %q, want 404 %s", rec.Code, resp.Error.Type, api.ErrorTypeNotFound)
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/V1/Chat/Completions/", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rec := serve(s, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("OPTIONS = %d, Allow-Origin %q; want 200 *", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	})
+}
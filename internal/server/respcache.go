@@ -0,0 +1,113 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// responseCache is an in-memory LRU of encoded non-streaming responses for
+// deterministic requests (OPENCOMPAT_RESPONSE_CACHE).
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+}
+
+// cachedResponse is one responseCache entry.
+type cachedResponse struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// newResponseCache returns a cache holding at most size responses for ttl.
+func newResponseCache(size int, ttl time.Duration) *responseCache {
+	return &responseCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached body for key, dropping it if it has expired.
+func (c *responseCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedResponse)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.body, true
+}
+
+// put stores body for key, evicting the least recently used entry when full.
+func (c *responseCache) put(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*cachedResponse)
+		entry.body, entry.expires = body, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cachedResponse{key: key, body: body, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// cacheable reports whether a request is deterministic enough to cache:
+// non-streaming, without tools and with temperature explicitly 0 for a
+// provider that forwards temperature. Providers that ignore it sample anyway.
+func cacheable(p provider.Provider, req *provider.ChatCompletionRequest) bool {
+	ts, ok := p.(provider.TemperatureSupporter)
+	if !ok || !ts.SupportsTemperature() {
+		return false
+	}
+	return !req.Stream && len(req.Tools) == 0 && req.Temperature != nil && *req.Temperature == 0
+}
+
+// responseCacheKey hashes the transformed request together with the provider
+// and its instructions release, so a new release never serves old answers.
+// apiKey scopes entries to the caller when OPENCOMPAT_API_KEYS is set, so one
+// client is never served another's completion. The conversation id only
+// affects upstream prompt caching and is left out.
+func responseCacheKey(p provider.Provider, req *provider.ChatCompletionRequest, apiKey string) string {
+	keyed := *req
+	keyed.ConversationID = ""
+
+	var instructions string
+	if iv, ok := p.(provider.InstructionsVersioner); ok {
+		instructions = iv.InstructionsVersion()
+	}
+
+	data, _ := json.Marshal(struct {
+		APIKey       string
+		Provider     string
+		Instructions string
+		Request      provider.ChatCompletionRequest
+	}{apiKey, p.ID(), instructions, keyed})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/config"
+)
+
+func TestResponseCache(t *testing.T) {
+	cfg := &config.Config{
+		ResponseCache:     true,
+		ResponseCacheSize: 16,
+		ResponseCacheTTL:  60,
+		APIKeys:           map[string][]string{"key-a": nil, "key-b": nil},
+	}
+	s := newTestServer(t, cfg,
+		&stubProvider{id: "sampled", models: []string{"m1"}, temperature: true},
+		&stubProvider{id: "fixed", models: []string{"m1"}},
+	)
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		model string
+		key   string
+		want  string // X-OpenCompat-Cache
+	}{
+		{name: "first request", model: "sampled/m1", key: "key-a", want: "miss"},
+		{name: "repeat by same key", model: "sampled/m1", key: "key-a", want: "hit"},
+		{name: "same request by another key", model: "sampled/m1", key: "key-b", want: "miss"},
+		{name: "repeat by other key", model: "sampled/m1", key: "key-b", want: "hit"},
+		{name: "provider ignoring temperature", model: "fixed/m1", key: "key-a", want: ""},
+		{name: "provider ignoring temperature repeated", model: "fixed/m1", key: "key-a", want: ""},
+	}
+	for _, tt := range tests {
+		body := `{"model":"` + tt.model + `","temperature":0,"messages":[{"role":"user","content":"hi"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tt.key)
+		rec := serve(s, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", tt.name, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("X-OpenCompat-Cache"); got != tt.want {
+			t.Errorf("%s: X-OpenCompat-Cache = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
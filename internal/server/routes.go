@@ -0,0 +1,30 @@
+package server
+
+import "strings"
+
+// routeEffortSuffixes are model suffixes carried over from a routed name to its
+// target, so "fast-high" routes to "<target>-high".
+var routeEffortSuffixes = []string{"none", "minimal", "low", "medium", "high", "xhigh"}
+
+// resolveRoute maps a friendly model name to its configured provider/model target.
+// Returns the original model and false if no route matches.
+func resolveRoute(routes map[string]string, model string) (string, bool) {
+	if len(routes) == 0 {
+		return model, false
+	}
+
+	if target, ok := routes[model]; ok {
+		return target, true
+	}
+
+	for _, suffix := range routeEffortSuffixes {
+		base, ok := strings.CutSuffix(model, "-"+suffix)
+		if !ok {
+			continue
+		}
+		if target, ok := routes[base]; ok {
+			return target + "-" + suffix, true
+		}
+	}
+	return model, false
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// selfTestTimeout bounds each provider's startup self-test request.
+const selfTestTimeout = 10 * time.Second
+
+// SelfTest sends a tiny non-streaming completion to every active provider
+// and logs the outcome. Providers are probed in parallel, each bounded by
+// selfTestTimeout, so startup is delayed by at most that long. Failures are
+// logged only; the server still starts.
+func (s *Server) SelfTest(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, meta := range s.registry.ListMetas() {
+		p, ok := s.registry.GetActiveProvider(meta.ID)
+		if !ok {
+			continue
+		}
+		models := p.Models()
+		if len(models) == 0 {
+			slog.Warn("self-test skipped: provider lists no models", "provider", meta.ID)
+			continue
+		}
+		model := models[0].ID
+
+		wg.Go(func() {
+			start := time.Now()
+			err := selfTestProvider(ctx, p, model)
+			if err != nil {
+				slog.Error("self-test failed", "provider", meta.ID, "model", model, "duration", time.Since(start), "error", err)
+				return
+			}
+			slog.Info("self-test passed", "provider", meta.ID, "model", model, "duration", time.Since(start))
+		})
+	}
+	wg.Wait()
+}
+
+// selfTestProvider runs one probe completion and returns the first error.
+func selfTestProvider(ctx context.Context, p provider.Provider, model string) error {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	content, _ := json.Marshal("Reply with OK.")
+	stream, err := p.ChatCompletion(ctx, &provider.ChatCompletionRequest{
+		Model:    model,
+		Messages: []api.Message{{Role: "user", Content: content}},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	for {
+		if _, err := stream.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	if response := stream.Response(); response == nil || response.ID == "" {
+		return errors.New("no response received from upstream")
+	}
+	return nil
+}
@@ -2,13 +2,19 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/auth"
 	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/ledger"
+	"github.com/edgard/opencompat/internal/logging"
+	"github.com/edgard/opencompat/internal/metrics"
 	"github.com/edgard/opencompat/internal/provider"
 )
 
@@ -20,16 +26,48 @@ type Server struct {
 	cfg        *config.Config
 }
 
+// BuildInfo identifies the running binary; it is reported by /health.
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
 // New creates a new server instance.
-func New(registry *provider.Registry, cfg *config.Config) *Server {
+func New(registry *provider.Registry, cfg *config.Config, build BuildInfo) *Server {
 	handlers := NewHandlers(registry, cfg)
+	handlers.build = build
 
 	mux := http.NewServeMux()
 
 	// Register routes
 	mux.HandleFunc("/health", handlers.Health)
+	mux.HandleFunc("/health/live", handlers.Live)
+	mux.HandleFunc("/health/ready", handlers.Ready)
+	mux.HandleFunc("/livez", handlers.Live)
+	mux.HandleFunc("/readyz", handlers.Ready)
 	mux.HandleFunc("/v1/models", handlers.Models)
-	mux.HandleFunc("/v1/chat/completions", handlers.ChatCompletions)
+	mux.HandleFunc("/v1/chat/completions", handlers.Idempotent(handlers.ChatCompletions))
+	mux.HandleFunc("/v1/chat/completions/ws", handlers.ChatCompletionsWebSocket)
+
+	// Debug endpoints (opt-in; expose instructions and request internals)
+	if cfg.Debug {
+		mux.HandleFunc("/debug/transform", handlers.DebugTransform)
+		mux.HandleFunc(instructionsRoutePrefix, handlers.Instructions)
+	}
+
+	// Prometheus metrics (opt-in)
+	if cfg.Metrics {
+		metrics.Enable()
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
+	// Usage ledger (opt-in)
+	if cfg.UsageLog {
+		if err := ledger.Enable(ledger.Path()); err != nil {
+			slog.Warn("usage ledger disabled", "path", ledger.Path(), "error", err)
+		}
+	}
 
 	// Catch-all for unknown /v1/ endpoints - returns OpenAI-style 404
 	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
@@ -48,12 +86,13 @@ func New(registry *provider.Registry, cfg *config.Config) *Server {
 	handler := ChainMiddleware(
 		mux,
 		RecoveryMiddleware,
-		LoggingMiddleware,
+		NormalizePathMiddleware,
+		LoggingMiddleware(logging.ParseLevel(cfg.AccessLogLevel)),
 		RequestIDMiddleware,
-		CORSMiddleware,
+		CORSMiddleware(cfg.CORSOrigins),
 	)
 
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	_, addr := cfg.ListenAddress()
 
 	return &Server{
 		httpServer: &http.Server{
@@ -81,12 +120,36 @@ func (s *Server) PrefetchInstructions() error {
 			}
 		}
 	}
+	s.handlers.SetInitialized()
 	return nil
 }
 
+// ActivateProviders activates providers logged in since startup, initializing
+// and starting them like PrefetchInstructions and Start do. It returns the IDs
+// of the providers that became active.
+func (s *Server) ActivateProviders(store *auth.Store) ([]string, error) {
+	activated, err := s.registry.ActivateNew(store)
+	for _, id := range activated {
+		p, _ := s.registry.GetActiveProvider(id)
+		if lp, ok := p.(provider.LifecycleProvider); ok {
+			if initErr := lp.Init(); initErr != nil {
+				slog.Error("failed to initialize provider", "provider", id, "error", initErr)
+				continue
+			}
+			lp.Start()
+		}
+	}
+	return activated, err
+}
+
 // Start starts the HTTP server.
 // Should be called after PrefetchInstructions().
 func (s *Server) Start() error {
+	// Validate TLS settings before starting any background work
+	if (s.cfg.TLSCert == "") != (s.cfg.TLSKey == "") {
+		return errors.New("both OPENCOMPAT_TLS_CERT and OPENCOMPAT_TLS_KEY must be set to enable TLS")
+	}
+
 	// Start all lifecycle providers
 	for _, meta := range s.registry.ListMetas() {
 		p, ok := s.registry.GetActiveProvider(meta.ID)
@@ -98,19 +161,61 @@ func (s *Server) Start() error {
 		}
 	}
 
-	slog.Info("server starting", "addr", s.httpServer.Addr)
-	slog.Info("OpenAI-compatible API available", "url", fmt.Sprintf("http://%s/v1", s.httpServer.Addr))
+	network, address := s.cfg.ListenAddress()
+	listener, err := listen(network, address)
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if s.cfg.TLSEnabled() {
+		scheme = "https"
+	}
+
+	slog.Info("server starting", "network", network, "addr", address, "tls", s.cfg.TLSEnabled())
+	if network == "unix" {
+		slog.Info("OpenAI-compatible API available", "socket", address, "url", fmt.Sprintf("%s://localhost/v1", scheme))
+	} else {
+		slog.Info("OpenAI-compatible API available", "url", fmt.Sprintf("%s://%s/v1", scheme, address))
+	}
 
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	switch {
+	case s.cfg.TLSCert != "" && s.cfg.TLSKey != "":
+		err = s.httpServer.ServeTLS(listener, s.cfg.TLSCert, s.cfg.TLSKey)
+	case s.cfg.TLSSelfSigned:
+		cert, certErr := generateSelfSignedCert(s.cfg.Host)
+		if certErr != nil {
+			_ = listener.Close()
+			return fmt.Errorf("failed to generate self-signed certificate: %w", certErr)
+		}
+		slog.Warn("using self-signed TLS certificate (for local testing only)")
+		s.httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		err = s.httpServer.ServeTLS(listener, "", "")
+	default:
+		err = s.httpServer.Serve(listener)
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
 // Shutdown gracefully shuts down the server.
+// In-flight completions (including SSE streams) are allowed to finish until
+// ctx is done; new completion requests receive 503 in the meantime.
 func (s *Server) Shutdown(ctx context.Context) error {
-	// Close all providers
+	if err := s.handlers.Drain(ctx); err != nil {
+		slog.Warn("shutdown deadline reached with requests still in flight", "error", err)
+	}
+
+	err := s.httpServer.Shutdown(ctx)
+
+	// Close all providers once requests have drained
 	s.registry.CloseAll()
 
-	return s.httpServer.Shutdown(ctx)
+	// Flush buffered usage records
+	ledger.Close()
+
+	return err
 }
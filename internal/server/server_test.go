@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// stubProvider is a credential-free provider that answers every model it
+// lists with a fixed reply. It only reports ready once Init has run.
+type stubProvider struct {
+	id          string
+	models      []string
+	temperature bool // reported by SupportsTemperature
+	ready       atomic.Bool
+}
+
+func (p *stubProvider) ID() string { return p.id }
+
+func (p *stubProvider) Models() []api.Model {
+	models := make([]api.Model, len(p.models))
+	for i, id := range p.models {
+		models[i] = api.Model{ID: id, Object: "model", OwnedBy: p.id}
+	}
+	return models
+}
+
+func (p *stubProvider) SupportsModel(modelID string) bool {
+	return slices.Contains(p.models, modelID)
+}
+
+func (p *stubProvider) ChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (provider.Stream, error) {
+	return &stubStream{model: req.Model}, nil
+}
+
+func (p *stubProvider) SupportsTemperature() bool { return p.temperature }
+
+func (p *stubProvider) Init() error {
+	p.ready.Store(true)
+	return nil
+}
+
+func (p *stubProvider) Start() {}
+
+func (p *stubProvider) Close() {}
+
+func (p *stubProvider) Ready() bool { return p.ready.Load() }
+
+// stubStream streams a single content chunk; usage comes from Response.
+type stubStream struct {
+	model string
+	sent  bool
+}
+
+func (s *stubStream) Next() (*api.ChatCompletionChunk, error) {
+	if s.sent {
+		return nil, io.EOF
+	}
+	s.sent = true
+	return &api.ChatCompletionChunk{
+		ID:     "chatcmpl-stub",
+		Object: "chat.completion.chunk",
+		Model:  s.model,
+		Choices: []api.Choice{{
+			Delta: &api.Delta{Role: "assistant", Content: "ok"},
+		}},
+	}, nil
+}
+
+func (s *stubStream) Response() *api.ChatCompletionResponse {
+	return &api.ChatCompletionResponse{
+		ID:      "chatcmpl-stub",
+		Object:  "chat.completion",
+		Model:   s.model,
+		Choices: []api.Choice{{Message: &api.Message{Role: "assistant", Content: json.RawMessage(`"ok"`)}}},
+		Usage:   &api.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4},
+	}
+}
+
+func (s *stubStream) Err() error { return nil }
+
+func (s *stubStream) Close() error { return nil }
+
+// newTestServer returns a server over the given stub providers, all active.
+func newTestServer(t *testing.T, cfg *config.Config, providers ...*stubProvider) *Server {
+	t.Helper()
+	registry := provider.NewRegistry()
+	for _, p := range providers {
+		registry.RegisterMeta(provider.ProviderMeta{
+			ID:         p.id,
+			AuthMethod: auth.AuthMethodNone,
+			Factory:    func(*auth.Store) (provider.Provider, error) { return p, nil },
+		})
+	}
+	if err := registry.Initialize(nil); err != nil {
+		t.Fatal(err)
+	}
+	return New(registry, cfg, BuildInfo{Version: "test"})
+}
+
+// get issues a GET through the server's full handler chain.
+func get(t *testing.T, s *Server, path string) (int, map[string]any) {
+	t.Helper()
+	rec := serve(s, httptest.NewRequest(http.MethodGet, path, nil))
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: decode body %q: %v", path, rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+// serve runs a request through the server's full handler chain.
+func serve(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestReadinessFollowsProviderInit(t *testing.T) {
+	s := newTestServer(t, &config.Config{}, &stubProvider{id: "stub", models: []string{"m1"}})
+
+	for _, path := range []string{"/health/ready", "/readyz"} {
+		if code, body := get(t, s, path); code != http.StatusServiceUnavailable || body["status"] != "not_ready" {
+			t.Errorf("GET %s before init = %d %v, want 503 not_ready", path, code, body["status"])
+		}
+	}
+	for _, path := range []string{"/health/live", "/livez", "/health"} {
+		if code, body := get(t, s, path); code != http.StatusOK || body["status"] != "ok" {
+			t.Errorf("GET %s before init = %d %v, want 200 ok", path, code, body["status"])
+		}
+	}
+	if _, body := get(t, s, "/health"); body["ready"] != false {
+		t.Errorf("/health ready before init = %v, want false", body["ready"])
+	}
+
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatalf("PrefetchInstructions() error = %v", err)
+	}
+
+	for _, path := range []string{"/health/ready", "/readyz"} {
+		if code, body := get(t, s, path); code != http.StatusOK || body["status"] != "ok" {
+			t.Errorf("GET %s after init = %d %v, want 200 ok", path, code, body["status"])
+		}
+	}
+	if _, body := get(t, s, "/health"); body["ready"] != true {
+		t.Errorf("/health ready after init = %v, want true", body["ready"])
+	}
+}
+
+func TestReadinessWithoutProviders(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(t, s, "/health/ready"); code != http.StatusServiceUnavailable {
+		t.Errorf("GET /health/ready with no providers = %d, want 503", code)
+	}
+	if code, _ := get(t, s, "/health/live"); code != http.StatusOK {
+		t.Errorf("GET /health/live with no providers = %d, want 200", code)
+	}
+}
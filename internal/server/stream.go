@@ -3,11 +3,47 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"strings"
 
 	"github.com/edgard/opencompat/internal/api"
 )
 
+// ndjsonContentType selects newline-delimited JSON streaming via the Accept header.
+const ndjsonContentType = "application/x-ndjson"
+
+// StreamWriter writes a streaming chat completion in a wire format.
+type StreamWriter interface {
+	WriteChunk(chunk *api.ChatCompletionChunk) error
+	WriteError(detail api.ErrorDetail) error
+	WriteDone() error
+}
+
+// newStreamWriter returns a WebSocket writer for requests arriving over
+// /v1/chat/completions/ws, an NDJSON writer when ndjson is set, otherwise SSE.
+func newStreamWriter(w http.ResponseWriter, ndjson bool) (StreamWriter, error) {
+	if ws, ok := w.(*wsResponseWriter); ok {
+		return ws.streamWriter(), nil
+	}
+	if ndjson {
+		return NewNDJSONWriter(w)
+	}
+	return NewSSEWriter(w)
+}
+
+// wantsNDJSON reports whether the client asked for NDJSON streaming.
+func wantsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			if mediaType, _, err := mime.ParseMediaType(part); err == nil && mediaType == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // SSEWriter helps write SSE events to the client.
 type SSEWriter struct {
 	w       http.ResponseWriter
@@ -58,13 +94,8 @@ func (s *SSEWriter) WriteDone() error {
 }
 
 // WriteError writes an error as an SSE event.
-func (s *SSEWriter) WriteError(message string) error {
-	errResp := api.ErrorResponse{
-		Error: api.ErrorDetail{
-			Message: message,
-			Type:    api.ErrorTypeServer,
-		},
-	}
+func (s *SSEWriter) WriteError(detail api.ErrorDetail) error {
+	errResp := api.ErrorResponse{Error: detail}
 
 	data, err := json.Marshal(errResp)
 	if err != nil {
@@ -79,3 +110,82 @@ func (s *SSEWriter) WriteError(message string) error {
 	s.flusher.Flush()
 	return nil
 }
+
+// NDJSONWriter writes a stream as newline-delimited JSON: one chunk or error
+// object per line, with no [DONE] sentinel (the stream ends when the body does).
+type NDJSONWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// NewNDJSONWriter creates a new NDJSON writer.
+func NewNDJSONWriter(w http.ResponseWriter) (*NDJSONWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	return &NDJSONWriter{w: w, flusher: flusher}, nil
+}
+
+// WriteChunk writes a chat completion chunk as one JSON line.
+func (n *NDJSONWriter) WriteChunk(chunk *api.ChatCompletionChunk) error {
+	return n.writeLine(chunk)
+}
+
+// WriteError writes an error object as one JSON line.
+func (n *NDJSONWriter) WriteError(detail api.ErrorDetail) error {
+	return n.writeLine(api.ErrorResponse{Error: detail})
+}
+
+// WriteDone is a no-op: NDJSON streams have no end sentinel.
+func (n *NDJSONWriter) WriteDone() error {
+	return nil
+}
+
+func (n *NDJSONWriter) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := n.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	n.flusher.Flush()
+	return nil
+}
+
+// wsStreamWriter writes a stream as WebSocket text messages: one chunk or
+// error object per message, ending with a "[DONE]" message as in SSE.
+type wsStreamWriter struct {
+	conn *wsConn
+}
+
+// WriteChunk writes a chat completion chunk as one text message.
+func (s *wsStreamWriter) WriteChunk(chunk *api.ChatCompletionChunk) error {
+	return s.writeMessage(chunk)
+}
+
+// WriteError writes an error object as one text message.
+func (s *wsStreamWriter) WriteError(detail api.ErrorDetail) error {
+	return s.writeMessage(api.ErrorResponse{Error: detail})
+}
+
+// WriteDone writes the [DONE] message.
+func (s *wsStreamWriter) WriteDone() error {
+	return s.conn.writeText([]byte("[DONE]"))
+}
+
+func (s *wsStreamWriter) writeMessage(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.conn.writeText(data)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxModelSuggestions caps the "did you mean" hints in model-not-found errors.
+const maxModelSuggestions = 3
+
+// suggestModels returns up to maxModelSuggestions candidates close to model.
+// Models without a provider prefix are also compared against the unprefixed
+// candidate names, so "gpt-5-codex-max" can suggest "chatgpt/gpt-5.1-codex-max".
+func suggestModels(model string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	input := strings.ToLower(model)
+	_, _, hasPrefix := strings.Cut(input, "/")
+
+	// Allow roughly one edit per four characters, at least two
+	maxDistance := max(2, len(input)/4)
+
+	best := make(map[string]int)
+	for _, name := range candidates {
+		target := strings.ToLower(name)
+		if !hasPrefix {
+			if _, modelID, ok := strings.Cut(target, "/"); ok {
+				target = modelID
+			}
+		}
+		if d := levenshtein(input, target); d <= maxDistance {
+			if prev, ok := best[name]; !ok || d < prev {
+				best[name] = d
+			}
+		}
+	}
+
+	matches := make([]match, 0, len(best))
+	for name, d := range best {
+		matches = append(matches, match{name, d})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxModelSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
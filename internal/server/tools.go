@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// toolNamePattern is the function name format accepted by OpenAI-style upstreams.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validateTools checks function names before they reach the upstream, whose
+// rejection is a generic 400. It returns the offending param path with the error.
+func validateTools(tools []api.Tool) (string, error) {
+	for i, tool := range tools {
+		if tool.Type != "function" {
+			continue
+		}
+		if !toolNamePattern.MatchString(tool.Function.Name) {
+			return fmt.Sprintf("tools[%d].function.name", i),
+				fmt.Errorf("invalid function name '%s' in tools[%d]: must be 1-64 characters of a-z, A-Z, 0-9, underscores or dashes", tool.Function.Name, i)
+		}
+	}
+	return "", nil
+}
+
+// validateToolChoice checks tool_choice against the request's tools. Upstream
+// errors for a choice naming an undeclared function are cryptic, so they are
+// caught here. Object forms other than a named function are passed through.
+func validateToolChoice(choice json.RawMessage, tools []api.Tool) error {
+	if len(choice) == 0 || string(choice) == "null" {
+		return nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(choice, &mode); err == nil {
+		switch mode {
+		case "none", "auto":
+			return nil
+		case "required":
+			if len(tools) == 0 {
+				return errors.New("tool_choice 'required' needs at least one tool in tools")
+			}
+			return nil
+		}
+		return fmt.Errorf("tool_choice '%s' is invalid. Must be one of: none, auto, required, or a function object", mode)
+	}
+
+	var named struct {
+		Type     string `json:"type"`
+		Function *struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(choice, &named); err != nil {
+		return errors.New("tool_choice must be a string or an object")
+	}
+	if named.Type != "function" {
+		return nil
+	}
+	if named.Function == nil || named.Function.Name == "" {
+		return errors.New("tool_choice of type 'function' must include function.name")
+	}
+	for _, tool := range tools {
+		if tool.Function.Name == named.Function.Name {
+			return nil
+		}
+	}
+	return fmt.Errorf("tool_choice references function '%s', which is not defined in tools", named.Function.Name)
+}
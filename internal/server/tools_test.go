@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/config"
+)
+
+func TestValidateToolChoice(t *testing.T) {
+	weather := `[{"type":"function","function":{"name":"get_weather"}}]`
+	tests := []struct {
+		name       string
+		toolChoice string
+		tools      string
+		wantErr    string
+	}{
+		{name: "none", toolChoice: `"none"`, tools: weather},
+		{name: "auto", toolChoice: `"auto"`, tools: weather},
+		{name: "required with tools", toolChoice: `"required"`, tools: weather},
+		{name: "required without tools", toolChoice: `"required"`, wantErr: "needs at least one tool"},
+		{name: "unknown string", toolChoice: `"always"`, tools: weather, wantErr: "tool_choice 'always' is invalid"},
+		{name: "named function present", toolChoice: `{"type":"function","function":{"name":"get_weather"}}`, tools: weather},
+		{name: "named function missing", toolChoice: `{"type":"function","function":{"name":"get_time"}}`, tools: weather, wantErr: "not defined in tools"},
+		{name: "function without name", toolChoice: `{"type":"function"}`, tools: weather, wantErr: "must include function.name"},
+		{name: "non-function object", toolChoice: `{"type":"web_search"}`, tools: weather},
+	}
+
+	s := newTestServer(t, &config.Config{}, &stubProvider{id: "stub", models: []string{"m1"}})
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"model":"stub/m1","messages":[{"role":"user","content":"hi"}],"tool_choice":` + tt.toolChoice
+			if tt.tools != "" {
+				body += `,"tools":` + tt.tools
+			}
+			body += "}"
+
+			rec := serve(s, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+			if tt.wantErr == "" {
+				if rec.Code != http.StatusOK {
+					t.Errorf("status = %d, want 200; body %s", rec.Code, rec.Body)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400; body %s", rec.Code, rec.Body)
+			}
+			var resp api.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if resp.Error.Param == nil || *resp.Error.Param != "tool_choice" {
+				t.Errorf("param = %v, want tool_choice", resp.Error.Param)
+			}
+			if !strings.Contains(resp.Error.Message, tt.wantErr) {
+				t.Errorf("message = %q, want it to contain %q", resp.Error.Message, tt.wantErr)
+			}
+		})
+	}
+}
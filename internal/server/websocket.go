@@ -0,0 +1,366 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// websocketGUID is the fixed key suffix from RFC 6455 section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// maxControlPayload is the largest control frame payload (RFC 6455 section 5.5).
+const maxControlPayload = 125
+
+// WebSocket close codes (RFC 6455 section 7.4.1).
+const (
+	wsCloseNormal      = 1000
+	wsCloseProtocol    = 1002
+	wsCloseUnsupported = 1003
+	wsCloseTooBig      = 1009
+)
+
+// errWebSocketClosed is returned by readMessage when the peer sent a close frame.
+var errWebSocketClosed = errors.New("websocket closed by peer")
+
+// wsConn is a minimal server-side RFC 6455 connection: unfragmented writes,
+// reassembly of fragmented reads, and automatic ping/close handling.
+type wsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// upgradeWebSocket validates the handshake and hijacks the connection.
+// On failure nothing has been written and the caller can still reply over HTTP.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		return nil, errors.New("websocket upgrade requires GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, errors.New("missing websocket upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.New("unsupported websocket version (want 13)")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket upgrade not supported: %w", err)
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return &wsConn{conn: conn, br: brw.Reader}, nil
+}
+
+// headerContainsToken reports whether a comma-separated header includes token (case-insensitive).
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the next data message, answering pings and closes.
+// Messages larger than maxSize fail with a 1009 close.
+func (c *wsConn) readMessage(maxSize int64) (opcode byte, payload []byte, err error) {
+	for {
+		fin, op, data, err := c.readFrame(maxSize - int64(len(payload)))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			_ = c.close(wsCloseNormal, "")
+			return 0, nil, errWebSocketClosed
+		case wsOpContinuation:
+			if opcode == 0 {
+				_ = c.close(wsCloseProtocol, "unexpected continuation frame")
+				return 0, nil, errors.New("unexpected continuation frame")
+			}
+		default:
+			if opcode != 0 {
+				_ = c.close(wsCloseProtocol, "expected continuation frame")
+				return 0, nil, errors.New("expected continuation frame")
+			}
+			opcode = op
+		}
+
+		payload = append(payload, data...)
+		if fin {
+			return opcode, payload, nil
+		}
+	}
+}
+
+// readFrame reads a single frame, unmasking its payload.
+func (c *wsConn) readFrame(maxSize int64) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]) & (1<<63 - 1))
+	}
+
+	// Clients must mask every frame (RFC 6455 section 5.1)
+	if !masked {
+		_ = c.close(wsCloseProtocol, "client frames must be masked")
+		return false, 0, nil, errors.New("unmasked client frame")
+	}
+	// Control frames are never fragmented and carry at most 125 bytes (RFC 6455 section 5.5)
+	if opcode&0x8 != 0 && (!fin || length > maxControlPayload) {
+		_ = c.close(wsCloseProtocol, "invalid control frame")
+		return false, 0, nil, errors.New("invalid websocket control frame")
+	}
+	if length > maxSize {
+		_ = c.close(wsCloseTooBig, "message too large")
+		return false, 0, nil, errors.New("websocket message too large")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single unmasked, final frame.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeText sends a text message.
+func (c *wsConn) writeText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// close sends a close frame and closes the connection. Safe to call more than once.
+func (c *wsConn) close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	err := c.writeFrame(wsOpClose, payload)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	_ = c.conn.Close()
+	return err
+}
+
+// wsResponseWriter carries ChatCompletions over a WebSocket. Streams are
+// written by the wsStreamWriter it hands to newStreamWriter; anything written
+// to it directly (errors before streaming starts) is buffered and sent whole
+// as a single JSON message by finish.
+type wsResponseWriter struct {
+	conn      *wsConn
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (w *wsResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *wsResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *wsResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+// streamWriter switches the response to streaming and returns its writer.
+func (w *wsResponseWriter) streamWriter() StreamWriter {
+	w.WriteHeader(http.StatusOK)
+	w.streaming = true
+	return &wsStreamWriter{conn: w.conn}
+}
+
+// finish sends a buffered response body, if any.
+func (w *wsResponseWriter) finish() {
+	if !w.streaming && w.body.Len() > 0 {
+		_ = w.conn.writeText(bytes.TrimSpace(w.body.Bytes()))
+	}
+}
+
+// ChatCompletionsWebSocket handles /v1/chat/completions/ws.
+// The client sends a ChatCompletionRequest as the first text message; chunks
+// are pushed back as JSON text messages ending with a "[DONE]" message, exactly
+// as the SSE stream would carry them. Closing the socket cancels the upstream request.
+func (h *Handlers) ChatCompletionsWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Browsers don't preflight WebSockets, so enforce the CORS origin list here
+	if origin := r.Header.Get("Origin"); origin != "" && !slices.Contains(h.cfg.CORSOrigins, "*") && !slices.Contains(h.cfg.CORSOrigins, origin) {
+		api.WriteError(w, http.StatusForbidden, api.ErrorTypeInvalidRequest, "Origin not allowed", nil, nil)
+		return
+	}
+
+	// Reject bad keys before upgrading; ChatCompletions enforces the model allowlist
+	if _, ok := h.authorizeKey(w, r); !ok {
+		return
+	}
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		api.WriteBadRequest(w, err.Error())
+		return
+	}
+	requestID := GetRequestID(r.Context())
+
+	opcode, body, err := conn.readMessage(maxRequestBodySize)
+	if err != nil {
+		if !errors.Is(err, errWebSocketClosed) {
+			slog.Debug("websocket read failed", "request_id", requestID, "error", err)
+		}
+		_ = conn.close(wsCloseNormal, "")
+		return
+	}
+	if opcode != wsOpText {
+		_ = conn.close(wsCloseUnsupported, "expected a JSON text message")
+		return
+	}
+
+	// The socket always streams; force stream=true while keeping every other field as sent
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		_ = conn.writeText(errorResponseJSON(api.ErrorTypeInvalidRequest, "Invalid JSON: "+err.Error()))
+		_ = conn.close(wsCloseNormal, "")
+		return
+	}
+	fields["stream"] = json.RawMessage("true")
+	body, _ = json.Marshal(fields)
+
+	// Cancel the upstream request when the client closes the socket
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	go func() {
+		for {
+			if _, _, err := conn.readMessage(maxRequestBodySize); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	req := r.Clone(ctx)
+	req.Method = http.MethodPost
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	ww := &wsResponseWriter{conn: conn, header: http.Header{}}
+	h.ChatCompletions(ww, req)
+	ww.finish()
+
+	_ = conn.close(wsCloseNormal, "")
+}
+
+// errorResponseJSON encodes an OpenAI-style error body.
+func errorResponseJSON(errType, message string) []byte {
+	data, _ := json.Marshal(api.ErrorResponse{Error: api.ErrorDetail{Message: message, Type: errType}})
+	return data
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgard/opencompat/internal/api"
+	"github.com/edgard/opencompat/internal/config"
+)
+
+// wsTestClient is a minimal WebSocket client for exercising the server side.
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dialWebSocket opens /v1/chat/completions/ws on a test server.
+func dialWebSocket(t *testing.T) *wsTestClient {
+	t.Helper()
+	s := newTestServer(t, &config.Config{CORSOrigins: []string{"*"}}, &stubProvider{id: "stub", models: []string{"m1"}})
+	if err := s.PrefetchInstructions(); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.httpServer.Handler)
+	t.Cleanup(srv.Close)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, _ = io.WriteString(conn, "GET /v1/chat/completions/ws HTTP/1.1\r\n"+
+		"Host: test\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+	return &wsTestClient{conn: conn, br: br}
+}
+
+// send writes one masked, final frame.
+func (c *wsTestClient) send(t *testing.T, opcode byte, payload []byte) {
+	t.Helper()
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// read returns the next server frame.
+func (c *wsTestClient) read(t *testing.T) (opcode byte, payload []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocketStreamsChunks(t *testing.T) {
+	c := dialWebSocket(t)
+	c.send(t, wsOpText, []byte(`{"model":"stub/m1","messages":[{"role":"user","content":"hi"}]}`))
+
+	op, payload := c.read(t)
+	if op != wsOpText {
+		t.Fatalf("first frame opcode = %#x, want text", op)
+	}
+	var chunk api.ChatCompletionChunk
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		t.Fatalf("decode chunk %q: %v", payload, err)
+	}
+	if chunk.Object != "chat.completion.chunk" || chunk.Choices[0].Delta.Content != "ok" {
+		t.Errorf("chunk = %s", payload)
+	}
+
+	if op, payload := c.read(t); op != wsOpText || string(payload) != "[DONE]" {
+		t.Errorf("second frame = %#x %q, want [DONE]", op, payload)
+	}
+	if op, payload := c.read(t); op != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseNormal {
+		t.Errorf("final frame = %#x %v, want normal close", op, payload)
+	}
+}
+
+func TestWebSocketSendsErrorBeforeStreaming(t *testing.T) {
+	c := dialWebSocket(t)
+	c.send(t, wsOpText, []byte(`{"model":"stub/unknown","messages":[{"role":"user","content":"hi"}]}`))
+
+	op, payload := c.read(t)
+	if op != wsOpText {
+		t.Fatalf("frame opcode = %#x, want text", op)
+	}
+	var resp api.ErrorResponse
+	if err := json.Unmarshal(payload, &resp); err != nil {
+		t.Fatalf("decode error %q: %v", payload, err)
+	}
+	if resp.Error.Code == nil || *resp.Error.Code != "model_not_found" {
+		t.Errorf("error = %s, want model_not_found", payload)
+	}
+	if op, _ := c.read(t); op != wsOpClose {
+		t.Errorf("frame after error = %#x, want close", op)
+	}
+}
+
+func TestWebSocketRejectsOversizedControlFrame(t *testing.T) {
+	c := dialWebSocket(t)
+	c.send(t, wsOpPing, make([]byte, maxControlPayload+1))
+
+	op, payload := c.read(t)
+	if op != wsOpClose {
+		t.Fatalf("frame opcode = %#x, want close", op)
+	}
+	if code := binary.BigEndian.Uint16(payload); code != wsCloseProtocol {
+		t.Errorf("close code = %d, want %d", code, wsCloseProtocol)
+	}
+}
+
+func TestWebSocketAnswersPing(t *testing.T) {
+	c := dialWebSocket(t)
+	c.send(t, wsOpPing, []byte("hello"))
+
+	if op, payload := c.read(t); op != wsOpPong || string(payload) != "hello" {
+		t.Errorf("reply = %#x %q, want pong hello", op, payload)
+	}
+}
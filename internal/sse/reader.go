@@ -31,6 +31,10 @@ func NewReader(r io.Reader) *Reader {
 }
 
 // ReadEvent reads the next SSE event.
+// Lines are parsed per the SSE spec: "field: value" with a single optional
+// space after the colon, comment lines (starting with ':') and unknown fields
+// are ignored, and multiple data lines are joined with '\n' before the
+// payload is returned, so JSON split across data lines arrives whole.
 func (r *Reader) ReadEvent() (*Event, error) {
 	if r.done {
 		return nil, io.EOF
@@ -41,16 +45,13 @@ func (r *Reader) ReadEvent() (*Event, error) {
 
 	for {
 		line, err := r.reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				r.done = true
-				if len(dataLines) > 0 {
-					// Process any remaining data
-					break
-				}
-			}
+		if err != nil && err != io.EOF {
 			return nil, err
 		}
+		atEOF := err == io.EOF
+		if atEOF {
+			r.done = true
+		}
 
 		line = strings.TrimSuffix(line, "\n")
 		line = strings.TrimSuffix(line, "\r")
@@ -60,33 +61,46 @@ func (r *Reader) ReadEvent() (*Event, error) {
 			if event.Event != "" || len(dataLines) > 0 {
 				break
 			}
+			if atEOF {
+				return nil, io.EOF
+			}
 			continue
 		}
 
-		// Parse field
-		if strings.HasPrefix(line, "event:") {
-			event.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-		} else if strings.HasPrefix(line, "data:") {
-			data := strings.TrimPrefix(line, "data:")
-			data = strings.TrimSpace(data)
-			if data == "[DONE]" {
-				r.done = true
-				return nil, io.EOF
-			}
-			dataLines = append(dataLines, data)
-		} else if strings.HasPrefix(line, "id:") {
-			event.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
-		} else if strings.HasPrefix(line, "retry:") {
-			if v, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "retry:"))); err == nil {
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// Comment line
+		case "event":
+			event.Event = strings.TrimSpace(value)
+		case "data":
+			dataLines = append(dataLines, value)
+		case "id":
+			event.ID = strings.TrimSpace(value)
+		case "retry":
+			if v, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
 				event.Retry = v
 			}
 		}
-		// Ignore comments (lines starting with :)
+
+		// A final event without a trailing blank line is still delivered
+		if atEOF {
+			if event.Event == "" && len(dataLines) == 0 {
+				return nil, io.EOF
+			}
+			break
+		}
 	}
 
 	// Combine data lines
 	if len(dataLines) > 0 {
-		event.Data = json.RawMessage(strings.Join(dataLines, "\n"))
+		data := strings.Join(dataLines, "\n")
+		if strings.TrimSpace(data) == "[DONE]" {
+			r.done = true
+			return nil, io.EOF
+		}
+		event.Data = json.RawMessage(data)
 	}
 
 	return &event, nil
@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Event
+	}{
+		{
+			name:  "single event",
+			input: "event: message\ndata: {\"a\":1}\n\n",
+			want:  []Event{{Event: "message", Data: json.RawMessage(`{"a":1}`)}},
+		},
+		{
+			name:  "multi-line data joined",
+			input: "data: {\"a\":\ndata: 1}\n\n",
+			want:  []Event{{Data: json.RawMessage("{\"a\":\n1}")}},
+		},
+		{
+			name:  "comments and unknown fields ignored",
+			input: ": keep-alive\nfoo: bar\nevent: delta\nid: 7\nretry: 1500\ndata: {}\n\n",
+			want:  []Event{{Event: "delta", ID: "7", Retry: 1500, Data: json.RawMessage(`{}`)}},
+		},
+		{
+			name:  "CRLF line endings",
+			input: "event: a\r\ndata: {}\r\n\r\n",
+			want:  []Event{{Event: "a", Data: json.RawMessage(`{}`)}},
+		},
+		{
+			name:  "final event without trailing blank line",
+			input: "data: {\"n\":1}\n\ndata: {\"n\":2}",
+			want: []Event{
+				{Data: json.RawMessage(`{"n":1}`)},
+				{Data: json.RawMessage(`{"n":2}`)},
+			},
+		},
+		{
+			name:  "done sentinel ends the stream",
+			input: "data: {}\n\ndata: [DONE]\n\ndata: {\"late\":true}\n\n",
+			want:  []Event{{Data: json.RawMessage(`{}`)}},
+		},
+		{
+			name:  "comment only stream",
+			input: ": ping\n\n: ping\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.input))
+			var got []Event
+			for {
+				event, err := r.ReadEvent()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ReadEvent() error = %v", err)
+				}
+				got = append(got, *event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEventMultiLineDataUnmarshals(t *testing.T) {
+	input := "event: response.output_text.delta\ndata: {\"type\":\"delta\",\ndata: \"delta\":\"hi\"}\n\n"
+	event, err := NewReader(strings.NewReader(input)).ReadEvent()
+	if err != nil {
+		t.Fatalf("ReadEvent() error = %v", err)
+	}
+
+	var payload struct {
+		Type  string `json:"type"`
+		Delta string `json:"delta"`
+	}
+	if err := json.Unmarshal(event.Data, &payload); err != nil {
+		t.Fatalf("unmarshal joined data: %v", err)
+	}
+	if payload.Type != "delta" || payload.Delta != "hi" {
+		t.Errorf("payload = %+v, want type=delta delta=hi", payload)
+	}
+}
@@ -0,0 +1,88 @@
+// Package tokens provides approximate token counting for chat requests.
+//
+// Counts are heuristic (no tokenizer vocabularies are bundled) and are meant
+// for pre-flight checks and fallbacks, never for billing.
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/edgard/opencompat/internal/api"
+)
+
+// Per-item overheads, following OpenAI's published chat-format accounting.
+const (
+	messageOverhead = 4  // role and message delimiters
+	replyOverhead   = 3  // priming for the assistant reply
+	toolOverhead    = 8  // function definition wrapper
+	imageTokens     = 85 // low-detail image; the minimum any image costs
+)
+
+// familyCharsPerToken maps model name prefixes to the average number of ASCII
+// characters per token for that tokenizer family.
+var familyCharsPerToken = map[string]float64{
+	"gpt-":    4.0, // o200k_base
+	"claude-": 3.5,
+}
+
+// defaultCharsPerToken is used for models without a known family.
+const defaultCharsPerToken = 4.0
+
+// charsPerToken returns the ASCII characters-per-token ratio for a model.
+func charsPerToken(model string) float64 {
+	if idx := strings.LastIndexByte(model, '/'); idx != -1 {
+		model = model[idx+1:]
+	}
+	best, ratio := 0, defaultCharsPerToken
+	for prefix, r := range familyCharsPerToken {
+		if len(prefix) > best && strings.HasPrefix(model, prefix) {
+			best, ratio = len(prefix), r
+		}
+	}
+	return ratio
+}
+
+// Estimate returns the approximate number of tokens in text for a model.
+// ASCII text is divided by the model family's characters-per-token ratio;
+// other runes (CJK, emoji) are counted as one token each.
+func Estimate(model, text string) int {
+	ascii, other := 0, 0
+	for i := 0; i < len(text); {
+		if text[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		other++
+		i += size
+	}
+	return int(math.Ceil(float64(ascii)/charsPerToken(model))) + other
+}
+
+// EstimateMessages returns the approximate prompt tokens for messages and tool definitions.
+func EstimateMessages(model string, messages []api.Message, tools []api.Tool) int {
+	total := replyOverhead
+	for i := range messages {
+		msg := &messages[i]
+		total += messageOverhead + Estimate(model, msg.Role) + Estimate(model, msg.Name)
+		for _, part := range msg.GetContentParts() {
+			switch part.Type {
+			case "text":
+				total += Estimate(model, part.Text)
+			case "image_url":
+				total += imageTokens
+			}
+		}
+		for _, tc := range msg.ToolCalls {
+			total += Estimate(model, tc.Function.Name) + Estimate(model, tc.Function.Arguments)
+		}
+	}
+	for _, tool := range tools {
+		total += toolOverhead + Estimate(model, tool.Function.Name) +
+			Estimate(model, tool.Function.Description) + Estimate(model, string(tool.Function.Parameters))
+	}
+	return total
+}
@@ -7,7 +7,10 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -22,8 +25,10 @@ import (
 	"github.com/edgard/opencompat/internal/config"
 	"github.com/edgard/opencompat/internal/logging"
 	"github.com/edgard/opencompat/internal/provider"
-	_ "github.com/edgard/opencompat/internal/provider/chatgpt" // Register chatgpt provider
-	_ "github.com/edgard/opencompat/internal/provider/copilot" // Register copilot provider
+	_ "github.com/edgard/opencompat/internal/provider/anthropic" // Register anthropic provider
+	_ "github.com/edgard/opencompat/internal/provider/chatgpt"   // Register chatgpt provider
+	_ "github.com/edgard/opencompat/internal/provider/copilot"   // Register copilot provider
+	_ "github.com/edgard/opencompat/internal/provider/mock"      // Register mock provider (OPENCOMPAT_ENABLE_MOCK)
 	"github.com/edgard/opencompat/internal/server"
 )
 
@@ -36,16 +41,32 @@ var (
 const usageHeader = `OpenCompat - Personal API compatibility layer
 
 Usage:
-  opencompat [command]
+  opencompat [--config <path>] [command]
 
 Commands:
   login <provider>    Authenticate with a provider (e.g., chatgpt)
+                        --api-key-stdin  Read the API key from stdin (API key providers)
+                        --qr             Also show the login URL as a QR code (device flow)
   logout <provider>   Remove credentials for a provider
-  info                Show authentication status for all providers
-  models              List all supported providers and models
-  serve               Start the API server (default)
-  version             Show version information
+  info [--json]       Show authentication status for all providers
+  models [--json]     List all supported providers and models
+  refresh [provider]  Refresh instructions/models for logged-in providers
+  health [provider]   Check upstream access for logged-in providers
+  instructions <model>  Print the instructions a model runs with (e.g., chatgpt/gpt-5.1)
+  config [--json]     Show effective configuration and its sources
+  doctor              Diagnose common setup problems
+  usage [flags]       Summarize recorded token usage by day and model
+                        --since <date|age>  Only include usage since YYYY-MM-DD or an age (7d, 12h)
+                        --json              Output as JSON
+  serve [flags]       Start the API server (default)
+                        --host <addr>  Bind address (overrides OPENCOMPAT_HOST)
+                        --port <port>  Listen port (overrides OPENCOMPAT_PORT)
+  completion <shell>  Print shell completion script (bash, zsh, fish)
+  version             Show version and cached instructions release
   help                Show this help message
+
+Global Flags:
+  --config <path>       Config file (default: $XDG_DATA_HOME/opencompat/config.yaml)
 `
 
 // buildUsage constructs the full usage string with dynamic provider information.
@@ -55,7 +76,14 @@ func buildUsage() string {
 
 	registry := provider.NewRegistry()
 	provider.RegisterAll(registry)
-	metas := registry.ListMetas()
+	registry.DisableProviders(config.Load().DisabledProviders)
+
+	var metas []provider.ProviderMeta
+	for _, meta := range registry.ListMetas() {
+		if !registry.IsDisabled(meta.ID) {
+			metas = append(metas, meta)
+		}
+	}
 
 	// Providers section
 	sb.WriteString("\nProviders:\n")
@@ -68,16 +96,50 @@ func buildUsage() string {
 			authDesc = "API key"
 		case auth.AuthMethodDeviceFlow:
 			authDesc = "GitHub device login"
+		case auth.AuthMethodNone:
+			authDesc = "no login"
 		}
 		sb.WriteString(fmt.Sprintf("  %-19s %s (%s)\n", meta.ID, meta.Name, authDesc))
 	}
 
 	// Global environment variables
 	sb.WriteString("\nEnvironment Variables (Global):\n")
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_CONFIG", "Config file path (overridden by --config)", "data dir/config.yaml"))
 	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_HOST", "Server bind address", "127.0.0.1"))
 	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_PORT", "Server listen port", "8080"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_LISTEN", "Listen address (host:port or unix:/path.sock)", "host:port"))
 	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_LOG_LEVEL", "Log level (debug, info, warn, error)", "info"))
 	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_LOG_FORMAT", "Log format (text, json)", "text"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_ACCESS_LOG_LEVEL", "Level of the per-request access log (debug, info, warn, error)", "debug"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_METRICS", "Expose Prometheus metrics at /metrics", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_CORS_ORIGINS", "Comma-separated allowed CORS origins", "*"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_UPSTREAM_TIMEOUT", "Upstream idle timeout in seconds (0 = none)", "300"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_FIRST_CHUNK_TIMEOUT", "Seconds to wait for the first streamed chunk (0 = none)", "0"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_UPSTREAM_RETRIES", "Retries for upstream connection failures before a response (ChatGPT)", "2"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_MAX_CONCURRENT", "Maximum concurrent chat completions (0 = unlimited)", "0"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_QUEUE_TIMEOUT", "Seconds to wait for a free slot before a 503", "10"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_RESPONSE_CACHE", "Cache responses to temperature-0 requests without tools", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_RESPONSE_CACHE_SIZE", "Maximum cached responses", "256"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_RESPONSE_CACHE_TTL", "Seconds a cached response is served", "3600"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_TOKEN_EXPIRY_MARGIN", "Seconds before expiry to refresh access tokens", "60"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_DEFAULT_PROVIDER", "Provider for models without a prefix", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_DISABLED_PROVIDERS", "Comma-separated providers to never activate", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_ROUTES", "JSON file mapping model names to provider/model", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_API_KEYS", "JSON file of API keys and their allowed models", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_ENFORCE_CONTEXT", "Reject prompts estimated to exceed the context window", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_STRICT_EFFORT", "Reject unsupported reasoning efforts instead of clamping", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_INLINE_IMAGES", "Download image URLs and send them as base64", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_MAX_IMAGE_BYTES", "Maximum decoded image size in bytes", "20971520"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_USAGE_LOG", "Append token usage to usage.jsonl in the data dir", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_ENABLE_MOCK", "Register the mock provider for testing (no login)", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_SELFTEST", "Send a probe completion to each provider at startup", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_ALLOW_NO_PROVIDERS", "Serve with no provider logged in (activate later with SIGHUP)", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_DEBUG_BODIES", "Log upstream request/response bodies (debug level)", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_DEBUG", "Expose /debug/* endpoints", "false"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_REAUTH_PROMPT", "Offer re-login when a session expires (CLI)", "true"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_TLS_CERT", "TLS certificate file (enables HTTPS with key)", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_TLS_KEY", "TLS private key file", "none"))
+	sb.WriteString(fmt.Sprintf("  %-44s %s (default: %s)\n", "OPENCOMPAT_TLS_SELF_SIGNED", "Serve HTTPS with a generated self-signed cert", "false"))
 
 	// Provider-specific environment variables
 	for _, meta := range metas {
@@ -132,9 +194,16 @@ func getProviderIDs() []string {
 }
 
 func main() {
+	// Apply the config file before anything reads the environment
+	if err := loadConfigFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize logging for all commands
 	cfg := config.Load()
 	logging.Setup(cfg.LogLevel, cfg.LogFormat)
+	logging.SetDebugBodies(cfg.DebugBodies)
 
 	if len(os.Args) < 2 {
 		cmdServe()
@@ -150,10 +219,24 @@ func main() {
 		cmdInfo()
 	case "models":
 		cmdModels()
+	case "refresh":
+		cmdRefresh()
+	case "health":
+		cmdHealth()
+	case "instructions":
+		cmdInstructions()
+	case "config":
+		cmdConfig()
+	case "doctor":
+		cmdDoctor()
+	case "usage":
+		cmdUsage()
 	case "serve":
 		cmdServe()
+	case "completion":
+		cmdCompletion()
 	case "version", "-v", "--version":
-		fmt.Printf("opencompat %s (commit: %s, built: %s)\n", version, commit, date)
+		cmdVersion()
 	case "help", "-h", "--help":
 		fmt.Print(buildUsage())
 	default:
@@ -189,46 +272,491 @@ func cmdLogin() {
 		os.Exit(1)
 	}
 
-	// Perform login based on auth method
+	if err := performLogin(store, providerID, meta); err != nil {
+		fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// performLogin runs the login flow for a provider based on its auth method.
+func performLogin(store *auth.Store, providerID string, meta provider.ProviderMeta) error {
 	switch meta.AuthMethod {
 	case auth.AuthMethodOAuth:
-		if err := auth.PerformOAuthLogin(store, providerID, meta.OAuthCfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
-			os.Exit(1)
-		}
+		return auth.PerformOAuthLogin(store, providerID, meta.OAuthCfg)
 	case auth.AuthMethodDeviceFlow:
-		if err := auth.PerformDeviceFlowLogin(store, providerID, meta.DeviceFlowCfg); err != nil {
-			fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
-			os.Exit(1)
-		}
+		return auth.PerformDeviceFlowLogin(store, providerID, meta.DeviceFlowCfg, hasFlag("--qr"))
 	case auth.AuthMethodAPIKey:
-		fmt.Print("Enter API key: ")
-		apiKeyBytes, err := term.ReadPassword(int(syscall.Stdin))
-		fmt.Println() // Print newline after hidden input
+		apiKey, err := readAPIKey(meta)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to read API key: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-		apiKey := strings.TrimSpace(string(apiKeyBytes))
+		apiKey = strings.TrimSpace(apiKey)
 		if apiKey == "" {
-			fmt.Fprintln(os.Stderr, "API key cannot be empty")
-			os.Exit(1)
+			return fmt.Errorf("API key cannot be empty")
 		}
 		creds := &auth.APIKeyCredentials{
 			APIKey:    apiKey,
 			CreatedAt: time.Now(),
 		}
 		if err := store.SaveAPIKeyCredentials(providerID, creds); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to save credentials: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to save credentials: %w", err)
 		}
 		fmt.Printf("Logged in to %s successfully.\n", providerID)
+		return nil
+	case auth.AuthMethodNone:
+		return fmt.Errorf("provider %s does not require login", providerID)
 	default:
-		fmt.Fprintf(os.Stderr, "Unsupported auth method for provider: %s\n", providerID)
+		return fmt.Errorf("unsupported auth method for provider: %s", providerID)
+	}
+}
+
+// readAPIKey obtains an API key for login. In order of precedence it is read
+// from stdin (--api-key-stdin), from the provider's API key env var, or
+// interactively with hidden input.
+func readAPIKey(meta provider.ProviderMeta) (string, error) {
+	if hasFlag("--api-key-stdin") {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read API key from stdin: %w", err)
+		}
+		return line, nil
+	}
+
+	if meta.APIKeyEnv != "" {
+		if apiKey := config.Getenv(meta.APIKeyEnv); apiKey != "" {
+			return apiKey, nil
+		}
+	}
+
+	fmt.Print("Enter API key: ")
+	apiKeyBytes, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Println() // Print newline after hidden input
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key: %w", err)
+	}
+	return string(apiKeyBytes), nil
+}
+
+// Re-login hooks used by withReauth; replaced in tests.
+var (
+	promptReauth = confirmReauth
+	reauthLogin  = performLogin
+)
+
+// withReauth runs fn and, if it fails because credentials were rejected,
+// offers to log in again and retries once. The prompt is only shown when
+// stdin is a terminal and OPENCOMPAT_REAUTH_PROMPT is enabled.
+func withReauth(store *auth.Store, meta provider.ProviderMeta, fn func() error) error {
+	err := fn()
+	reauthErr, ok := auth.IsReauthRequired(err)
+	if !ok {
+		return err
+	}
+
+	if !config.Load().ReauthPrompt || !promptReauth(reauthErr.ProviderID) {
+		return err
+	}
+
+	if loginErr := reauthLogin(store, meta.ID, meta); loginErr != nil {
+		return fmt.Errorf("re-login failed: %w", loginErr)
+	}
+
+	return fn()
+}
+
+// confirmReauth asks the user whether to log in again after a session expired.
+// It declines without asking when stdin is not a terminal. The question goes
+// to stderr so JSON output on stdout stays parseable.
+func confirmReauth(providerID string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Your %s session expired. Re-login now? (y/n): ", providerID)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+// cmdInstructions prints the instructions a model runs with, including local
+// overrides. Provenance goes to stderr so stdout is the raw prompt.
+func cmdInstructions() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, "Usage: opencompat instructions <provider/model>")
+		os.Exit(1)
+	}
+	model := os.Args[2]
+
+	cfg := config.Load()
+	providerID, modelID, err := provider.ParseModel(model)
+	if err != nil {
+		if cfg.DefaultProvider == "" {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		providerID, modelID = strings.ToLower(cfg.DefaultProvider), model
+	}
+
+	store := auth.NewStore()
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+
+	meta, ok := registry.GetMeta(providerID)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown provider: %s\n", providerID)
+		os.Exit(1)
+	}
+	if !meta.LoggedIn(store) {
+		fmt.Fprintf(os.Stderr, "Not logged in to %s. Run: opencompat login %s\n", providerID, providerID)
+		os.Exit(1)
+	}
+
+	p, err := meta.Factory(store)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading provider %s: %v\n", providerID, err)
+		os.Exit(1)
+	}
+	if !p.SupportsModel(modelID) {
+		fmt.Fprintf(os.Stderr, "Unknown model: %s/%s\n", providerID, modelID)
+		os.Exit(1)
+	}
+	describer, ok := p.(provider.InstructionsDescriber)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Provider %s does not inject instructions\n", providerID)
+		os.Exit(1)
+	}
+
+	info, err := describer.DescribeInstructions(modelID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load instructions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "Model:       %s/%s\n", providerID, info.Model)
+	fmt.Fprintf(os.Stderr, "Prompt file: %s\n", info.PromptFile)
+	fmt.Fprintf(os.Stderr, "Source:      %s\n", info.Source)
+	if info.Version != "" {
+		fmt.Fprintf(os.Stderr, "Version:     %s\n", info.Version)
+	}
+	if !info.FetchedAt.IsZero() {
+		fmt.Fprintf(os.Stderr, "Fetched:     %s\n", info.FetchedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Print(info.Content)
+	if !strings.HasSuffix(info.Content, "\n") {
+		fmt.Println()
+	}
+}
+
+func cmdRefresh() {
+	store := auth.NewStore()
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+
+	metas := registry.ListMetas()
+	if len(os.Args) >= 3 {
+		providerID := strings.ToLower(os.Args[2])
+		meta, ok := registry.GetMeta(providerID)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown provider: %s\n", providerID)
+			os.Exit(1)
+		}
+		if !meta.LoggedIn(store) {
+			fmt.Fprintf(os.Stderr, "Not logged in to %s. Run: opencompat login %s\n", providerID, providerID)
+			os.Exit(1)
+		}
+		metas = []provider.ProviderMeta{meta}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	failed := false
+	refreshed := 0
+	for _, meta := range metas {
+		if !meta.LoggedIn(store) {
+			continue
+		}
+		refreshed++
+
+		p, err := meta.Factory(store)
+		if err != nil {
+			fmt.Printf("  %s: error loading provider: %v\n", meta.ID, err)
+			failed = true
+			continue
+		}
+
+		refresher, ok := p.(provider.Refresher)
+		if !ok {
+			fmt.Printf("  %s: nothing to refresh\n", meta.ID)
+			continue
+		}
+
+		err = withReauth(store, meta, func() error {
+			return refresher.RefreshModels(ctx)
+		})
+		if err != nil {
+			fmt.Printf("  %s: refresh failed: %v\n", meta.ID, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s: refreshed\n", meta.ID)
+	}
+
+	if refreshed == 0 {
+		fmt.Println("No providers logged in. Nothing to refresh.")
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func cmdHealth() {
+	store := auth.NewStore()
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+
+	metas := registry.ListMetas()
+	if len(os.Args) >= 3 {
+		providerID := strings.ToLower(os.Args[2])
+		meta, ok := registry.GetMeta(providerID)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown provider: %s\n", providerID)
+			os.Exit(1)
+		}
+		if !meta.LoggedIn(store) {
+			fmt.Fprintf(os.Stderr, "Not logged in to %s. Run: opencompat login %s\n", providerID, providerID)
+			os.Exit(1)
+		}
+		metas = []provider.ProviderMeta{meta}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	failed := false
+	checked := 0
+	for _, meta := range metas {
+		if !meta.LoggedIn(store) {
+			continue
+		}
+		checked++
+
+		p, err := meta.Factory(store)
+		if err != nil {
+			fmt.Printf("  %s: error loading provider: %v\n", meta.ID, err)
+			failed = true
+			continue
+		}
+
+		checker, ok := p.(provider.HealthChecker)
+		if !ok {
+			fmt.Printf("  %s: no health check available\n", meta.ID)
+			continue
+		}
+
+		err = withReauth(store, meta, func() error {
+			return checker.Health(ctx)
+		})
+		if err != nil {
+			fmt.Printf("  %s: unhealthy: %v\n", meta.ID, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s: ok\n", meta.ID)
+	}
+
+	if checked == 0 {
+		fmt.Println("No providers logged in. Nothing to check.")
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// loadConfigFile applies the config file given by --config, OPENCOMPAT_CONFIG,
+// or the default location. The --config flag is removed from os.Args so
+// commands see only their own arguments.
+func loadConfigFile() error {
+	path, required := os.Getenv("OPENCOMPAT_CONFIG"), true
+	args := []string{os.Args[0]}
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			path = value
+			continue
+		}
+		if arg == "--config" {
+			if i+1 >= len(os.Args) {
+				return fmt.Errorf("--config requires a path")
+			}
+			path = os.Args[i+1]
+			i++
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+
+	if path == "" {
+		path, required = config.DefaultConfigFile(), false
+	}
+
+	// Accept global settings plus every provider's documented env vars
+	known := append([]string{}, config.GlobalEnvVars...)
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+	for _, meta := range registry.ListMetas() {
+		for _, env := range meta.EnvVars {
+			known = append(known, env.Name)
+		}
+		if meta.APIKeyEnv != "" {
+			known = append(known, meta.APIKeyEnv)
+		}
+	}
+
+	values, err := config.LoadFile(path, required, known)
+	if err != nil {
+		return err
+	}
+	if values != nil {
+		config.UseFile(path, values)
+	}
+	return nil
+}
+
+// providerInfo is the JSON representation of a provider's authentication status.
+type providerInfo struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	AuthMethod string     `json:"auth_method"`
+	LoggedIn   bool       `json:"logged_in"`
+	Token      string     `json:"token,omitempty"` // masked
+	Email      string     `json:"email,omitempty"`
+	AccountID  string     `json:"account_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Expired    bool       `json:"expired,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// collectProviderInfo gathers authentication status for all providers.
+func collectProviderInfo(store *auth.Store, registry *provider.Registry) []providerInfo {
+	infos := []providerInfo{}
+	for _, meta := range registry.ListMetas() {
+		info := providerInfo{
+			ID:         meta.ID,
+			Name:       meta.Name,
+			AuthMethod: meta.AuthMethod.String(),
+			LoggedIn:   meta.LoggedIn(store),
+		}
+
+		if info.LoggedIn {
+			switch meta.AuthMethod {
+			case auth.AuthMethodOAuth:
+				if creds, err := store.GetOAuthCredentials(meta.ID); err != nil {
+					info.Error = err.Error()
+				} else {
+					info.Token = maskSecret(creds.AccessToken)
+					info.Email = creds.Email
+					info.AccountID = creds.AccountID
+					info.ExpiresAt = &creds.ExpiresAt
+					info.Expired = creds.IsExpired()
+				}
+			case auth.AuthMethodAPIKey:
+				if creds, err := store.GetAPIKeyCredentials(meta.ID); err != nil {
+					info.Error = err.Error()
+				} else {
+					info.Token = maskSecret(creds.APIKey)
+				}
+			case auth.AuthMethodDeviceFlow:
+				if creds, err := store.GetOAuthCredentials(meta.ID); err != nil {
+					info.Error = err.Error()
+				} else {
+					info.Token = maskSecret(creds.RefreshToken)
+				}
+			}
+		}
+
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// modelInfo is the JSON representation of a model available through a provider.
+type modelInfo struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	Model    string `json:"model"` // provider-prefixed ID as used in API requests
+	OwnedBy  string `json:"owned_by,omitempty"`
+}
+
+// collectModelInfo refreshes and lists models for all providers.
+// Refresh failures are reported on stderr so stdout stays valid JSON.
+func collectModelInfo(ctx context.Context, store *auth.Store, registry *provider.Registry) []modelInfo {
+	models := []modelInfo{}
+	for _, meta := range registry.ListMetas() {
+		p, err := meta.Factory(store)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: error loading provider: %v\n", meta.ID, err)
+			continue
+		}
+
+		if refresher, ok := p.(provider.Refresher); ok {
+			err := withReauth(store, meta, func() error {
+				return refresher.RefreshModels(ctx)
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: refresh failed: %v\n", meta.ID, err)
+			}
+		}
+
+		for _, m := range p.Models() {
+			models = append(models, modelInfo{
+				Provider: meta.ID,
+				ID:       m.ID,
+				Model:    meta.ID + "/" + m.ID,
+				OwnedBy:  m.OwnedBy,
+			})
+		}
+	}
+	return models
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode JSON: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// hasFlag reports whether a flag was passed after the command name.
+func hasFlag(name string) bool {
+	if len(os.Args) < 3 {
+		return false
+	}
+	for _, arg := range os.Args[2:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecret shows only the first and last four characters of a secret.
+func maskSecret(secret string) string {
+	if len(secret) > 8 {
+		return secret[:4] + "..." + secret[len(secret)-4:]
+	}
+	return "****"
+}
+
 func cmdLogout() {
 	if len(os.Args) < 3 {
 		fmt.Fprintln(os.Stderr, "Error: provider argument required")
@@ -264,13 +792,18 @@ func cmdInfo() {
 	registry := provider.NewRegistry()
 	provider.RegisterAll(registry)
 
+	if hasFlag("--json") {
+		printJSON(collectProviderInfo(store, registry))
+		return
+	}
+
 	fmt.Println("Provider Status:")
 	fmt.Println()
 
 	for _, meta := range registry.ListMetas() {
 		fmt.Printf("  %s (%s):\n", meta.Name, meta.ID)
 
-		if !store.IsLoggedIn(meta.ID) {
+		if !meta.LoggedIn(store) {
 			fmt.Printf("    Status: Not logged in\n")
 			fmt.Printf("    Login:  opencompat login %s\n", meta.ID)
 			fmt.Println()
@@ -307,12 +840,7 @@ func cmdInfo() {
 				continue
 			}
 			fmt.Printf("    Status: Logged in\n")
-			// Show masked API key
-			if len(creds.APIKey) > 8 {
-				fmt.Printf("    API Key: %s...%s\n", creds.APIKey[:4], creds.APIKey[len(creds.APIKey)-4:])
-			} else {
-				fmt.Printf("    API Key: ****\n")
-			}
+			fmt.Printf("    API Key: %s\n", maskSecret(creds.APIKey))
 			fmt.Printf("    Created: %s\n", creds.CreatedAt.Format("2006-01-02 15:04:05"))
 
 		case auth.AuthMethodDeviceFlow:
@@ -325,11 +853,10 @@ func cmdInfo() {
 			}
 			fmt.Printf("    Status: Logged in\n")
 			// Show masked GitHub token
-			if len(creds.RefreshToken) > 8 {
-				fmt.Printf("    Token: %s...%s\n", creds.RefreshToken[:4], creds.RefreshToken[len(creds.RefreshToken)-4:])
-			} else {
-				fmt.Printf("    Token: ****\n")
-			}
+			fmt.Printf("    Token: %s\n", maskSecret(creds.RefreshToken))
+
+		case auth.AuthMethodNone:
+			fmt.Printf("    Status: Ready (no login required)\n")
 		}
 		fmt.Println()
 	}
@@ -340,12 +867,17 @@ func cmdModels() {
 	registry := provider.NewRegistry()
 	provider.RegisterAll(registry)
 
-	fmt.Println("Refreshing models from providers...")
-	fmt.Println()
-
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if hasFlag("--json") {
+		printJSON(collectModelInfo(ctx, store, registry))
+		return
+	}
+
+	fmt.Println("Refreshing models from providers...")
+	fmt.Println()
+
 	for _, meta := range registry.ListMetas() {
 		// Get provider instance to list models
 		// Pass store so providers can fetch dynamic models if logged in
@@ -357,7 +889,10 @@ func cmdModels() {
 
 		// Force refresh if provider supports it
 		if refresher, ok := p.(provider.Refresher); ok {
-			if err := refresher.RefreshModels(ctx); err != nil {
+			err := withReauth(store, meta, func() error {
+				return refresher.RefreshModels(ctx)
+			})
+			if err != nil {
 				fmt.Printf("  %s (%s): refresh failed: %v\n", meta.Name, meta.ID, err)
 				// Continue to show cached models anyway
 			}
@@ -381,34 +916,128 @@ func cmdModels() {
 	fmt.Println("Example: chatgpt/gpt-5.1-codex-high")
 }
 
+// serveArgs returns the arguments following the serve command, if any.
+func serveArgs() []string {
+	if len(os.Args) < 3 || os.Args[1] != "serve" {
+		return nil
+	}
+	return os.Args[2:]
+}
+
+// applyServeFlags overrides host and port in cfg from command-line flags.
+// Flags take precedence over environment variables, including OPENCOMPAT_LISTEN.
+func applyServeFlags(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	host := fs.String("host", "", "bind address")
+	port := fs.Int("port", 0, "listen port")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "host":
+			cfg.Host = *host
+		case "port":
+			cfg.Port = *port
+		}
+		cfg.Listen = ""
+	})
+
+	if cfg.Listen == "" && (cfg.Port < 1 || cfg.Port > 65535) {
+		return fmt.Errorf("invalid port %d: must be between 1 and 65535", cfg.Port)
+	}
+	return nil
+}
+
 func cmdServe() {
-	// Check acknowledgment first
+	cfg := config.Load()
+	if err := applyServeFlags(cfg, serveArgs()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: opencompat serve [--host <addr>] [--port <port>]")
+		os.Exit(1)
+	}
+
+	registry := provider.NewRegistry()
+	provider.RegisterAll(registry)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := registry.ValidateConfigs(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.DebugBodies && !strings.EqualFold(cfg.LogLevel, "debug") {
+		slog.Warn("OPENCOMPAT_DEBUG_BODIES has no effect unless OPENCOMPAT_LOG_LEVEL=debug")
+	}
+
+	// Check acknowledgment before starting anything
 	if err := checkAcknowledgment(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 
-	cfg := config.Load()
 	store := auth.NewStore()
-	registry := provider.NewRegistry()
-	provider.RegisterAll(registry)
 
-	// Initialize providers (only those logged in will activate)
+	for _, id := range cfg.DisabledProviders {
+		if _, ok := registry.GetMeta(strings.ToLower(id)); !ok {
+			slog.Warn("ignoring unknown provider in OPENCOMPAT_DISABLED_PROVIDERS", "provider", id)
+		}
+	}
+	registry.DisableProviders(cfg.DisabledProviders)
+
+	// Initialize providers (only those logged in and not disabled will activate)
 	if err := registry.Initialize(store); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize providers: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check if at least one provider is active
-	if !registry.HasProviders() {
+	if !registry.HasProviders() && cfg.AllowNoProviders {
+		slog.Warn("no providers logged in; serving in degraded mode until one is activated (log in, then send SIGHUP)")
+	} else if !registry.HasProviders() {
 		fmt.Fprintln(os.Stderr, "No providers available. Please log in to at least one provider:")
 		for _, meta := range registry.ListMetas() {
+			if registry.IsDisabled(meta.ID) {
+				fmt.Fprintf(os.Stderr, "  %s is disabled by OPENCOMPAT_DISABLED_PROVIDERS\n", meta.ID)
+				continue
+			}
 			fmt.Fprintf(os.Stderr, "  opencompat login %s\n", meta.ID)
 		}
 		os.Exit(1)
 	}
 
-	srv := server.New(registry, cfg)
+	routes, err := config.LoadRoutes(cfg.RoutesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid OPENCOMPAT_ROUTES: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.Routes = routes
+
+	apiKeys, err := config.LoadAPIKeys(cfg.APIKeysFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid OPENCOMPAT_API_KEYS: %v\n", err)
+		os.Exit(1)
+	}
+	cfg.APIKeys = apiKeys
+
+	if err := registry.SetDefaultProvider(cfg.DefaultProvider); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid OPENCOMPAT_DEFAULT_PROVIDER: %v\n", err)
+		os.Exit(1)
+	}
+	if _, ok := registry.GetActiveProvider(cfg.DefaultProvider); cfg.DefaultProvider != "" && !ok {
+		slog.Warn("default provider is not logged in; models without a prefix will be rejected", "provider", cfg.DefaultProvider)
+	}
+
+	srv := server.New(registry, cfg, server.BuildInfo{Version: version, Commit: commit, Date: date})
 
 	// Prefetch instructions before starting server
 	// This ensures all model instructions are available
@@ -418,9 +1047,14 @@ func cmdServe() {
 		os.Exit(1)
 	}
 
-	// Setup signal handling for graceful shutdown
+	// Probe each provider before accepting traffic (opt-in)
+	if cfg.SelfTest {
+		srv.SelfTest(context.Background())
+	}
+
+	// Setup signal handling: SIGINT/SIGTERM shut down gracefully, SIGHUP refreshes
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	// Start server in goroutine
 	errChan := make(chan error, 1)
@@ -428,21 +1062,56 @@ func cmdServe() {
 		errChan <- srv.Start()
 	}()
 
-	// Wait for signal or error
-	select {
-	case sig := <-sigChan:
-		slog.Info("received signal, shutting down", "signal", sig)
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := srv.Shutdown(ctx); err != nil {
-			slog.Error("shutdown error", "error", err)
-		}
-		slog.Info("server stopped")
-	case err := <-errChan:
+	// Wait for a shutdown signal or error
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				go reloadProviders(srv, registry, store)
+				continue
+			}
+			slog.Info("received signal, shutting down", "signal", sig)
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Error("shutdown error", "error", err)
+			}
+			slog.Info("server stopped")
+			return
+		case err := <-errChan:
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+}
+
+// reloadProviders refreshes instructions and models on every active provider
+// in response to SIGHUP, without interrupting in-flight requests.
+func reloadProviders(srv *server.Server, registry *provider.Registry, store *auth.Store) {
+	slog.Info("received SIGHUP, refreshing providers")
+	activated, err := srv.ActivateProviders(store)
+	if err != nil {
+		slog.Error("provider activation failed", "error", err)
+	}
+	for _, id := range activated {
+		slog.Info("provider activated", "provider", id)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	results := registry.RefreshAll(ctx)
+	if len(results) == 0 {
+		slog.Info("no active provider supports refresh")
+	}
+	for id, err := range results {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
-			os.Exit(1)
+			slog.Error("provider refresh failed", "provider", id, "error", err)
+			continue
 		}
+		slog.Info("provider refreshed", "provider", id)
 	}
 }
 
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/edgard/opencompat/internal/auth"
+	"github.com/edgard/opencompat/internal/provider"
+)
+
+// stubReauth replaces the re-login prompt and login for one test, answering
+// the prompt with accept. It returns counters for both calls.
+func stubReauth(t *testing.T, accept bool, loginErr error) (prompts, logins *int) {
+	t.Helper()
+	prompts, logins = new(int), new(int)
+	origPrompt, origLogin := promptReauth, reauthLogin
+	t.Cleanup(func() { promptReauth, reauthLogin = origPrompt, origLogin })
+
+	promptReauth = func(providerID string) bool {
+		*prompts++
+		return accept
+	}
+	reauthLogin = func(store *auth.Store, providerID string, meta provider.ProviderMeta) error {
+		*logins++
+		return loginErr
+	}
+	return prompts, logins
+}
+
+func TestWithReauth(t *testing.T) {
+	meta := provider.ProviderMeta{ID: "chatgpt"}
+	expired := fmt.Errorf("refresh failed: %w", &auth.ReauthRequiredError{ProviderID: "chatgpt"})
+
+	tests := []struct {
+		name        string
+		errs        []error // returned by successive fn calls
+		accept      bool
+		loginErr    error
+		wantCalls   int
+		wantPrompts int
+		wantLogins  int
+		wantErr     bool
+	}{
+		{"success", []error{nil}, true, nil, 1, 0, 0, false},
+		{"other error not prompted", []error{errors.New("boom")}, true, nil, 1, 0, 0, true},
+		{"wrapped reauth error retried after login", []error{expired, nil}, true, nil, 2, 1, 1, false},
+		{"declined", []error{expired}, false, nil, 1, 1, 0, true},
+		{"login fails", []error{expired}, true, errors.New("cancelled"), 1, 1, 1, true},
+		{"retried once only", []error{expired, expired}, true, nil, 2, 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OPENCOMPAT_REAUTH_PROMPT", "true")
+			prompts, logins := stubReauth(t, tt.accept, tt.loginErr)
+
+			calls := 0
+			err := withReauth(nil, meta, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withReauth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || *prompts != tt.wantPrompts || *logins != tt.wantLogins {
+				t.Errorf("calls/prompts/logins = %d/%d/%d, want %d/%d/%d",
+					calls, *prompts, *logins, tt.wantCalls, tt.wantPrompts, tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestWithReauthPromptDisabled(t *testing.T) {
+	t.Setenv("OPENCOMPAT_REAUTH_PROMPT", "false")
+	prompts, _ := stubReauth(t, true, nil)
+
+	err := withReauth(nil, provider.ProviderMeta{ID: "copilot"}, func() error {
+		return &auth.ReauthRequiredError{ProviderID: "copilot"}
+	})
+	if _, ok := auth.IsReauthRequired(err); !ok {
+		t.Errorf("withReauth() error = %v, want the reauth error", err)
+	}
+	if *prompts != 0 {
+		t.Errorf("prompted %d times with OPENCOMPAT_REAUTH_PROMPT=false", *prompts)
+	}
+}
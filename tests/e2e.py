@@ -534,6 +534,22 @@ def register_tests(suite: TestSuite) -> None:
         r = requests.post(f"{s.base_url}/health", timeout=s.timeout)
         s.assert_status_code(r, 405, "POST /health should return 405")
 
+    @suite.test("livez_endpoint", "connectivity")
+    def _(s: TestSuite):
+        """GET /livez returns 200 with status ok."""
+        r = requests.get(f"{s.base_url}/livez", timeout=s.timeout)
+        s.assert_status_code(r, 200, "Liveness endpoint should return 200")
+        s.assert_equal(r.json().get("status"), "ok", "Status should be 'ok'")
+
+    @suite.test("readyz_endpoint", "connectivity")
+    def _(s: TestSuite):
+        """GET /readyz returns 200 once providers are initialized."""
+        r = requests.get(f"{s.base_url}/readyz", timeout=s.timeout)
+        s.assert_status_code(r, 200, "Readiness endpoint should return 200")
+        data = r.json()
+        s.assert_equal(data.get("status"), "ok", "Status should be 'ok'")
+        s.assert_is_not_none(data.get("providers"), "Should report provider readiness")
+
     @suite.test("models_list", "connectivity")
     def _(s: TestSuite):
         """GET /v1/models returns models list."""
@@ -541,6 +557,20 @@ def register_tests(suite: TestSuite) -> None:
         s.assert_is_not_none(models.data, "Models data should not be None")
         s.assert_greater(len(models.data), 0, "Should have at least one model")
 
+    @suite.test("route_variants", "connectivity")
+    def _(s: TestSuite):
+        """Trailing-slash and case variants of known routes are served."""
+        for path in ("/v1/models/", "/V1/Models", "/livez/"):
+            r = requests.get(f"{s.base_url}{path}", timeout=s.timeout)
+            s.assert_status_code(r, 200, f"GET {path} should return 200")
+
+    @suite.test("unknown_endpoint", "connectivity")
+    def _(s: TestSuite):
+        """Unknown /v1/ paths return an OpenAI-style 404."""
+        r = requests.get(f"{s.base_url}/v1/does-not-exist/", timeout=s.timeout)
+        s.assert_status_code(r, 404, "Unknown endpoint should return 404")
+        s.assert_equal(r.json().get("error", {}).get("type"), "not_found_error", "Error type should be not_found_error")
+
     @suite.test("models_structure", "connectivity")
     def _(s: TestSuite):
         """Each model has required fields."""
@@ -593,6 +623,23 @@ def register_tests(suite: TestSuite) -> None:
         s.assert_is_not_none(r.choices, "Response should have 'choices'")
         s.assert_is_not_none(r.usage, "Response should have 'usage'")
 
+    @suite.test("idempotency_key_replay", "basic_chat")
+    def _(s: TestSuite):
+        """A repeated Idempotency-Key replays the first response."""
+        headers = {"Idempotency-Key": f"e2e-{time.time_ns()}"}
+        body = {"model": s.model, "messages": [{"role": "user", "content": "Hi"}]}
+        url = f"{s.base_url}/v1/chat/completions"
+        first = requests.post(url, json=body, headers=headers, timeout=s.timeout)
+        s.assert_status_code(first, 200, "First request should succeed")
+        second = requests.post(url, json=body, headers=headers, timeout=s.timeout)
+        s.assert_status_code(second, 200, "Repeated request should succeed")
+        s.assert_equal(second.headers.get("Idempotent-Replayed"), "true", "Repeated request should be replayed")
+        s.assert_equal(second.json()["id"], first.json()["id"], "Replay should return the same completion")
+
+        body["messages"][0]["content"] = "Hello"
+        changed = requests.post(url, json=body, headers=headers, timeout=s.timeout)
+        s.assert_status_code(changed, 422, "Reusing the key with a different body should return 422")
+
     @suite.test("choice_structure", "basic_chat")
     def _(s: TestSuite):
         """Choice has required fields."""
@@ -1689,6 +1736,106 @@ def register_tests(suite: TestSuite) -> None:
         )
         s.assert_status_code(r, 400, "Tool without tool_call_id should return 400")
 
+    @suite.test("tool_choice_unknown_function", "errors")
+    def _(s: TestSuite):
+        """tool_choice naming a function missing from tools returns 400."""
+        r = requests.post(
+            f"{s.base_url}/v1/chat/completions",
+            json={
+                "model": s.model,
+                "messages": [{"role": "user", "content": "Hi"}],
+                "tools": [
+                    {
+                        "type": "function",
+                        "function": {"name": "func_a", "parameters": {"type": "object", "properties": {}}},
+                    }
+                ],
+                "tool_choice": {"type": "function", "function": {"name": "func_b"}},
+            },
+            timeout=s.timeout,
+        )
+        s.assert_status_code(r, 400, "Unknown tool_choice function should return 400")
+        error = r.json()["error"]
+        s.assert_equal(error.get("param"), "tool_choice", "Error param should be tool_choice")
+        s.assert_contains(error.get("message", ""), "func_b", "Error should name the function")
+
+    @suite.test("tool_choice_missing_name", "errors")
+    def _(s: TestSuite):
+        """tool_choice of type function without a name returns 400."""
+        r = requests.post(
+            f"{s.base_url}/v1/chat/completions",
+            json={
+                "model": s.model,
+                "messages": [{"role": "user", "content": "Hi"}],
+                "tools": [
+                    {
+                        "type": "function",
+                        "function": {"name": "func_a", "parameters": {"type": "object", "properties": {}}},
+                    }
+                ],
+                "tool_choice": {"type": "function"},
+            },
+            timeout=s.timeout,
+        )
+        s.assert_status_code(r, 400, "tool_choice without function.name should return 400")
+        s.assert_equal(r.json()["error"].get("param"), "tool_choice", "Error param should be tool_choice")
+
+    @suite.test("tool_choice_invalid_string", "errors")
+    def _(s: TestSuite):
+        """Unknown tool_choice string returns 400."""
+        r = requests.post(
+            f"{s.base_url}/v1/chat/completions",
+            json={
+                "model": s.model,
+                "messages": [{"role": "user", "content": "Hi"}],
+                "tool_choice": "sometimes",
+            },
+            timeout=s.timeout,
+        )
+        s.assert_status_code(r, 400, "Invalid tool_choice string should return 400")
+        s.assert_equal(r.json()["error"].get("param"), "tool_choice", "Error param should be tool_choice")
+
+    @suite.test("tool_choice_required_without_tools", "errors")
+    def _(s: TestSuite):
+        """tool_choice 'required' without tools returns 400."""
+        r = requests.post(
+            f"{s.base_url}/v1/chat/completions",
+            json={
+                "model": s.model,
+                "messages": [{"role": "user", "content": "Hi"}],
+                "tool_choice": "required",
+            },
+            timeout=s.timeout,
+        )
+        s.assert_status_code(r, 400, "tool_choice required without tools should return 400")
+        s.assert_equal(r.json()["error"].get("param"), "tool_choice", "Error param should be tool_choice")
+
+    @suite.test("tool_invalid_name", "errors")
+    def _(s: TestSuite):
+        """Function name with disallowed characters returns 400 naming the tool."""
+        r = requests.post(
+            f"{s.base_url}/v1/chat/completions",
+            json={
+                "model": s.model,
+                "messages": [{"role": "user", "content": "Hi"}],
+                "tools": [
+                    {
+                        "type": "function",
+                        "function": {"name": "valid_name", "parameters": {"type": "object", "properties": {}}},
+                    },
+                    {
+                        "type": "function",
+                        "function": {"name": "get weather.now", "parameters": {"type": "object", "properties": {}}},
+                    },
+                ],
+            },
+            timeout=s.timeout,
+        )
+        s.assert_status_code(r, 400, "Invalid function name should return 400")
+        error = r.json()["error"]
+        s.assert_equal(error.get("param"), "tools[1].function.name", "Error param should point at the tool")
+        s.assert_contains(error.get("message", ""), "get weather.now", "Error should include the name")
+
     @suite.test("error_structure", "errors")
     def _(s: TestSuite):
         """Error response has proper structure."""
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgard/opencompat/internal/config"
+	"github.com/edgard/opencompat/internal/ledger"
+)
+
+// usageReport is the JSON output of the usage command.
+type usageReport struct {
+	Ledger string         `json:"ledger"`
+	Since  string         `json:"since,omitempty"`
+	Totals []ledger.Total `json:"totals"`
+}
+
+func cmdUsage() {
+	flags := flag.NewFlagSet("usage", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	sinceFlag := flags.String("since", "", "date (YYYY-MM-DD) or age (e.g. 7d, 12h)")
+	jsonOut := flags.Bool("json", false, "output as JSON")
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Usage: opencompat usage [--since <date|age>] [--json]")
+		os.Exit(1)
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		t, err := parseSince(*sinceFlag, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		since = t
+	}
+
+	if !config.Load().UsageLog {
+		fmt.Fprintln(os.Stderr, "Note: the usage ledger is disabled; set OPENCOMPAT_USAGE_LOG=true to record new requests.")
+	}
+
+	path := ledger.Path()
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No usage recorded yet (%s not found).\n", path)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open usage ledger: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = f.Close() }()
+
+	totals, err := ledger.Summarize(f, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read usage ledger: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonOut {
+		report := usageReport{Ledger: path, Totals: totals}
+		if !since.IsZero() {
+			report.Since = since.Format(time.RFC3339)
+		}
+		printJSON(report)
+		return
+	}
+
+	if len(totals) == 0 {
+		fmt.Println("No usage recorded in the selected period.")
+		return
+	}
+
+	fmt.Printf("%-10s  %-30s  %8s  %12s  %12s  %12s\n", "DAY", "MODEL", "REQUESTS", "PROMPT", "COMPLETION", "REASONING")
+	var sum ledger.Total
+	for _, t := range totals {
+		model := t.Provider + "/" + t.Model
+		if t.Estimated > 0 {
+			model += " ~"
+		}
+		fmt.Printf("%-10s  %-30s  %8d  %12d  %12d  %12d\n", t.Day, model, t.Requests, t.PromptTokens, t.CompletionTokens, t.ReasoningTokens)
+		sum.Requests += t.Requests
+		sum.PromptTokens += t.PromptTokens
+		sum.CompletionTokens += t.CompletionTokens
+		sum.ReasoningTokens += t.ReasoningTokens
+		sum.Estimated += t.Estimated
+	}
+	fmt.Printf("%-10s  %-30s  %8d  %12d  %12d  %12d\n", "TOTAL", "", sum.Requests, sum.PromptTokens, sum.CompletionTokens, sum.ReasoningTokens)
+	if sum.Estimated > 0 {
+		fmt.Println("\n~ includes estimated counts (upstream reported no usage)")
+	}
+}
+
+// parseSince parses a --since value: a date (YYYY-MM-DD, local midnight),
+// a Go duration (e.g. 12h) or a number of days (e.g. 7d) before now.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a date (YYYY-MM-DD) or an age (e.g. 7d, 12h)", value)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/edgard/opencompat/internal/provider/chatgpt"
+)
+
+// cmdVersion prints build information and the cached instructions release,
+// read from the shared disk cache so it matches what the server last loaded.
+func cmdVersion() {
+	fmt.Printf("opencompat %s (commit: %s, built: %s)\n", version, commit, date)
+	if tag, fetchedAt, ok := chatgpt.CachedVersion(); ok {
+		fmt.Printf("instructions: %s (fetched %s)\n", tag, fetchedAt.Local().Format(time.RFC3339))
+	}
+}